@@ -0,0 +1,130 @@
+package loupedeck
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned when the Loupedeck doesn't answer in time.
+var ErrTimeout = errors.New("timeout waiting for response")
+
+// ackNudgeInterval is how long SendAndWait and WaitForAck wait for a
+// response before sending a query to shake it loose.
+const ackNudgeInterval = 50 * time.Millisecond
+
+// ackEntry identifies a sent message that hasn't been answered yet.
+type ackEntry struct {
+	messageType MessageType
+	seq         uint64
+}
+
+// ackState tracks which messages the Loupedeck has answered.  Each
+// message type has its own sequence of sent messages; a response to
+// any of them counts as an acknowledgement of it and of everything
+// of the same type sent before it, which keeps this working when
+// responses arrive out of order or not at all.
+type ackState struct {
+	mutex    sync.Mutex
+	sent     map[MessageType]uint64 // sequence number of the last message sent
+	acked    map[MessageType]uint64 // sequence number of the last message answered
+	pending  map[byte]ackEntry      // by transaction ID
+	changed  chan struct{}          // closed and replaced whenever acked changes
+	nudgeMsg time.Time              // when the last nudge was sent
+}
+
+// init makes sure that the maps and channel exist.  The mutex must be
+// held.
+func (a *ackState) init() {
+	if a.pending == nil {
+		a.sent = map[MessageType]uint64{}
+		a.acked = map[MessageType]uint64{}
+		a.pending = map[byte]ackEntry{}
+		a.changed = make(chan struct{})
+	}
+}
+
+// noteSent records that a message is being sent.
+func (l *Loupedeck) noteSent(m *Message) {
+	if m.transactionID == 0 {
+		return
+	}
+	a := &l.acks
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.init()
+	a.sent[m.messageType]++
+	a.pending[m.transactionID] = ackEntry{messageType: m.messageType, seq: a.sent[m.messageType]}
+}
+
+// noteAck records a response from the Loupedeck.
+func (l *Loupedeck) noteAck(m *Message) {
+	a := &l.acks
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.init()
+	e, ok := a.pending[m.transactionID]
+	if !ok {
+		return
+	}
+	delete(a.pending, m.transactionID)
+	if e.seq > a.acked[e.messageType] {
+		a.acked[e.messageType] = e.seq
+		close(a.changed)
+		a.changed = make(chan struct{})
+	}
+}
+
+// WaitForAck waits until the Loupedeck has answered the most recent
+// message of type t, or timeout passes, in which case it returns
+// ErrTimeout.  For example, WaitForAck(Draw, time.Second) after
+// drawing waits until the screen update has actually been completed.
+// Listen needs to be running.
+//
+// It returns immediately if nothing of that type is waiting for an
+// answer.
+func (l *Loupedeck) WaitForAck(t MessageType, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	nudge := time.NewTicker(ackNudgeInterval)
+	defer nudge.Stop()
+
+	a := &l.acks
+	for {
+		a.mutex.Lock()
+		a.init()
+		done := a.acked[t] >= a.sent[t]
+		changed := a.changed
+		a.mutex.Unlock()
+		if done {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-nudge.C:
+			l.nudge()
+		case <-deadline.C:
+			return ErrTimeout
+		}
+	}
+}
+
+// nudge sends a harmless query.  The Loupedeck holds back responses
+// to some messages, like Draw, until it gets another message, so this
+// helps when waiting for them.  Nudges are rate-limited, so that
+// several waiters don't flood the device.
+func (l *Loupedeck) nudge() {
+	a := &l.acks
+	a.mutex.Lock()
+	if time.Since(a.nudgeMsg) < ackNudgeInterval {
+		a.mutex.Unlock()
+		return
+	}
+	a.nudgeMsg = time.Now()
+	a.mutex.Unlock()
+
+	if err := l.Send(l.NewMessage(Version, []byte{})); err != nil {
+		l.log().Debug("Unable to send nudge", "err", err)
+	}
+}
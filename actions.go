@@ -0,0 +1,194 @@
+package loupedeck
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ControlType identifies which kind of physical control a Control
+// refers to.
+type ControlType int
+
+const (
+	ControlButton ControlType = iota
+	ControlKnob
+	ControlTouch
+)
+
+// Control identifies a single physical control: a Button, a Knob, or
+// a TouchButton.
+type Control struct {
+	Type ControlType
+	ID   int
+}
+
+// ButtonControl returns the Control for a Button.
+func ButtonControl(b Button) Control {
+	return Control{Type: ControlButton, ID: int(b)}
+}
+
+// KnobControl returns the Control for a Knob.
+func KnobControl(k Knob) Control {
+	return Control{Type: ControlKnob, ID: int(k)}
+}
+
+// TouchControl returns the Control for a TouchButton.
+func TouchControl(b TouchButton) Control {
+	return Control{Type: ControlTouch, ID: int(b)}
+}
+
+// String returns a human-readable name for the Control.
+func (c Control) String() string {
+	switch c.Type {
+	case ControlButton:
+		return fmt.Sprintf("button:%d", c.ID)
+	case ControlKnob:
+		return fmt.Sprintf("knob:%d", c.ID)
+	case ControlTouch:
+		return fmt.Sprintf("touch:%d", c.ID)
+	}
+	return fmt.Sprintf("control(%d):%d", int(c.Type), c.ID)
+}
+
+// Mapping connects a Control to a named action, along with
+// parameters for that action.
+type Mapping struct {
+	Action string
+	Params map[string]string
+}
+
+// ActionEvent is passed to an ActionFunc when a mapped control is
+// used.
+type ActionEvent struct {
+	Control Control
+	Params  map[string]string
+	// Status is set for buttons and touches.
+	Status ButtonStatus
+	// Delta is set for knobs.
+	Delta int
+	// X and Y are set for touches.
+	X, Y uint16
+}
+
+// ActionFunc is a function signature used for actions.  See
+// RegisterAction.
+type ActionFunc func(ActionEvent)
+
+// actionTable holds registered actions and control mappings.
+type actionTable struct {
+	mutex    sync.RWMutex
+	actions  map[string]ActionFunc
+	mappings map[Control]Mapping
+}
+
+// RegisterAction makes an action available for mapping under the
+// specified name.  Registering the same name again replaces it.
+func (l *Loupedeck) RegisterAction(name string, f ActionFunc) {
+	a := &l.actions
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.actions == nil {
+		a.actions = map[string]ActionFunc{}
+	}
+	a.actions[name] = f
+}
+
+// Actions returns the names of all registered actions.
+func (l *Loupedeck) Actions() []string {
+	a := &l.actions
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	names := make([]string, 0, len(a.actions))
+	for name := range a.actions {
+		names = append(names, name)
+	}
+	return names
+}
+
+// MapControl maps a Control to a registered action.  Mapped controls
+// are handled by their action instead of by any binding set with
+// BindButton, BindKnob, BindTouch, and friends; unmapped controls
+// use their bindings as usual.  Mappings can be changed at any time,
+// which makes it possible to build a "remap controls" UI.
+func (l *Loupedeck) MapControl(c Control, action string, params map[string]string) error {
+	a := &l.actions
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.actions[action] == nil {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if a.mappings == nil {
+		a.mappings = map[Control]Mapping{}
+	}
+	a.mappings[c] = Mapping{Action: action, Params: params}
+	return nil
+}
+
+// UnmapControl removes the mapping for a Control, returning it to its
+// normal bindings.
+func (l *Loupedeck) UnmapControl(c Control) {
+	a := &l.actions
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.mappings, c)
+}
+
+// GetMapping returns the mapping for a Control, if it has one.
+func (l *Loupedeck) GetMapping(c Control) (Mapping, bool) {
+	a := &l.actions
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	m, ok := a.mappings[c]
+	return m, ok
+}
+
+// Mappings returns a copy of all current mappings.
+func (l *Loupedeck) Mappings() map[Control]Mapping {
+	a := &l.actions
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	m := make(map[Control]Mapping, len(a.mappings))
+	for c, mapping := range a.mappings {
+		m[c] = mapping
+	}
+	return m
+}
+
+// dispatchAction runs the action mapped to e.Control, if there is
+// one.  It returns true if the event was handled.
+func (l *Loupedeck) dispatchAction(e ActionEvent) bool {
+	a := &l.actions
+	a.mutex.RLock()
+	m, ok := a.mappings[e.Control]
+	f := a.actions[m.Action]
+	a.mutex.RUnlock()
+
+	if !ok || f == nil {
+		return false
+	}
+	e.Params = m.Params
+	l.call(e.Control, func() { f(e) })
+	return true
+}
+
+// suspendMappings removes all control mappings, returning them so
+// that they can be put back later with restoreMappings.  Used by
+// suspendBindings, so that mapped controls don't run their actions
+// while a modal dialog is open.
+func (l *Loupedeck) suspendMappings() map[Control]Mapping {
+	a := &l.actions
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	m := a.mappings
+	a.mappings = nil
+	return m
+}
+
+// restoreMappings replaces all control mappings with a set
+// previously returned by suspendMappings.
+func (l *Loupedeck) restoreMappings(m map[Control]Mapping) {
+	a := &l.actions
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.mappings = m
+}
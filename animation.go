@@ -0,0 +1,170 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"sync"
+	"time"
+)
+
+// DefaultAnimationFrameRate is the default maximum rate (in frames
+// per second) that animations on a single display are redrawn at.
+// See SetAnimationFrameRate.
+const DefaultAnimationFrameRate = 10
+
+// AnimationFunc renders a single frame of an animation.  It's called
+// with the number of frames rendered so far (starting at 0) and the
+// time since the animation started, and returns the image to draw,
+// or nil if nothing changed since the previous frame.  Animations
+// that should run at a fixed speed regardless of the frame rate, like
+// spinners, should derive their state from elapsed rather than frame.
+type AnimationFunc func(frame int, elapsed time.Duration) image.Image
+
+// Animation is an animation running in a Region; see Animate.
+type Animation struct {
+	loupedeck *Loupedeck
+	region    *Region
+	f         AnimationFunc
+	start     time.Time
+	frame     int
+	owner     string // for the DrawQueue
+	stopped   bool   // guarded by animationState.mutex
+}
+
+// animationState holds the running animations and the per-display
+// frame rate caps.
+type animationState struct {
+	mutex      sync.Mutex
+	animations []*Animation
+	rates      map[byte]time.Duration // minimum time between frames, by framebuffer
+	last       map[byte]time.Time
+	cancel     func()
+}
+
+// Animate starts an animation in a Region.  The Loupedeck calls f
+// from its FrameClock, and sends the images it returns through the
+// DrawQueue at DrawDecoration priority, so animations never delay
+// feedback from other widgets, and a frame that hasn't been sent yet
+// is simply replaced by the next one.
+//
+// All animations on a display advance together, at no more than the
+// display's animation frame rate (see SetAnimationFrameRate), so
+// adding more spinners doesn't add more bursts of traffic.  The
+// animation runs until Stop is called.
+func (l *Loupedeck) Animate(r *Region, f AnimationFunc) *Animation {
+	a := &Animation{
+		loupedeck: l,
+		region:    r,
+		f:         f,
+		start:     time.Now(),
+	}
+	a.owner = fmt.Sprintf("animation:%p", a)
+
+	s := &l.animations
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.animations = append(s.animations, a)
+	if s.cancel == nil {
+		s.cancel = l.FrameClock().Subscribe(l.animationFrame)
+	}
+	return a
+}
+
+// Stop stops the animation.  Whatever frame was last drawn stays on
+// the display.  Calling Stop more than once is harmless.
+func (a *Animation) Stop() {
+	s := &a.loupedeck.animations
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a.stopped = true
+	a.loupedeck.DrawQueue().drop(a.owner)
+	for i, b := range s.animations {
+		if b == a {
+			s.animations = append(s.animations[:i], s.animations[i+1:]...)
+			break
+		}
+	}
+	if len(s.animations) == 0 && s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// SetAnimationFrameRate caps the rate that animations on d are
+// redrawn at, in frames per second.  Displays that share a
+// framebuffer (like the emulated left, main, and right displays on
+// devices with a single display) share a cap.  The cap can't exceed
+// the FrameClock's rate; an fps of 0 restores the default.
+func (l *Loupedeck) SetAnimationFrameRate(d *Display, fps int) {
+	if fps <= 0 {
+		fps = DefaultAnimationFrameRate
+	}
+	s := &l.animations
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.rates == nil {
+		s.rates = map[byte]time.Duration{}
+	}
+	s.rates[d.id] = time.Second / time.Duration(fps)
+}
+
+// animationFrame is the FrameFunc that runs the animations.
+func (l *Loupedeck) animationFrame(_ uint64) bool {
+	l.advanceAnimations(time.Now())
+	// The actual draws happen in the DrawQueue, which has its own
+	// budget.
+	return false
+}
+
+// advanceAnimations advances every animation on each display whose
+// frame rate cap allows it at now.
+func (l *Loupedeck) advanceAnimations(now time.Time) {
+	s := &l.animations
+	s.mutex.Lock()
+	if s.last == nil {
+		s.last = map[byte]time.Time{}
+	}
+	// Allow for ticker jitter, so that a cap equal to the
+	// FrameClock's rate doesn't skip every other frame.
+	slack := l.FrameClock().Interval() / 2
+	due := map[byte]bool{}
+	var run []*Animation
+	for _, a := range s.animations {
+		id := a.region.display.id
+		ok, seen := due[id]
+		if !seen {
+			interval := s.rates[id]
+			if interval == 0 {
+				interval = time.Second / DefaultAnimationFrameRate
+			}
+			ok = now.Sub(s.last[id]) >= interval-slack
+			due[id] = ok
+			if ok {
+				s.last[id] = now
+			}
+		}
+		if ok {
+			run = append(run, a)
+		}
+	}
+	s.mutex.Unlock()
+
+	q := l.DrawQueue()
+	for _, a := range run {
+		// Only the FrameClock calls this, one frame at a time, so
+		// a.frame doesn't need a lock.
+		var im image.Image
+		l.safeCall("", func() { im = a.f(a.frame, now.Sub(a.start)) })
+		a.frame++
+		if im == nil {
+			continue
+		}
+		// Don't queue a frame for an animation that was stopped
+		// while it was being rendered.
+		s.mutex.Lock()
+		if !a.stopped {
+			q.QueueRegion(a.owner, DrawDecoration, a.region, im)
+		}
+		s.mutex.Unlock()
+	}
+}
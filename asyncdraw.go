@@ -0,0 +1,107 @@
+package loupedeck
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+// asyncDrawAckTimeout is how long the async draw worker waits for the
+// Loupedeck to finish one draw before sending the next.
+const asyncDrawAckTimeout = 250 * time.Millisecond
+
+// asyncDraw is a single draw waiting to be sent by DrawAsync.
+type asyncDraw struct {
+	display *Display
+	im      image.Image
+	x, y    int             // relative to display
+	rect    image.Rectangle // in framebuffer coordinates
+}
+
+// asyncDrawQueue holds the draws waiting for a single framebuffer.
+type asyncDrawQueue struct {
+	pending []*asyncDraw
+	running bool
+}
+
+// asyncDrawState holds the async draw queues, one per framebuffer.
+type asyncDrawState struct {
+	mutex  sync.Mutex
+	queues map[byte]*asyncDrawQueue
+}
+
+// DrawAsync is like Draw, but returns immediately and sends the image
+// from a background goroutine.  Each framebuffer has its own worker,
+// which sends one draw at a time and waits for the Loupedeck to finish
+// it before sending the next, so there's never more than one
+// framebuffer update in flight.
+//
+// A draw that hasn't been sent yet is dropped if a newer draw covers
+// the same area, so when a knob is spun quickly only the latest value
+// is drawn, instead of seconds of stale frames piling up.  Draws to
+// different areas are sent in the order they were made.
+//
+// The client-side mirror (see SetMirroring) is updated when the draw
+// is actually sent, not when DrawAsync is called.
+func (d *Display) DrawAsync(im image.Image, xoff, yoff int) {
+	l := d.loupedeck
+	b := im.Bounds()
+	x, y := xoff+d.offsetx, yoff+d.offsety
+	r := &asyncDraw{
+		display: d,
+		im:      im,
+		x:       xoff,
+		y:       yoff,
+		rect:    image.Rect(x, y, x+b.Dx(), y+b.Dy()),
+	}
+
+	s := &l.asyncDraws
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.queues == nil {
+		s.queues = map[byte]*asyncDrawQueue{}
+	}
+	q := s.queues[d.id]
+	if q == nil {
+		q = &asyncDrawQueue{}
+		s.queues[d.id] = q
+	}
+
+	// Drop anything that this draw completely covers.
+	kept := q.pending[:0]
+	for _, p := range q.pending {
+		if !p.rect.In(r.rect) {
+			kept = append(kept, p)
+		}
+	}
+	q.pending = append(kept, r)
+
+	if !q.running {
+		q.running = true
+		go l.asyncDrawWorker(q)
+	}
+}
+
+// asyncDrawWorker sends the draws in q until it's empty.
+func (l *Loupedeck) asyncDrawWorker(q *asyncDrawQueue) {
+	s := &l.asyncDraws
+	for {
+		s.mutex.Lock()
+		if len(q.pending) == 0 || l.closed.Load() {
+			q.pending = nil
+			q.running = false
+			s.mutex.Unlock()
+			return
+		}
+		r := q.pending[0]
+		q.pending = q.pending[1:]
+		s.mutex.Unlock()
+
+		r.display.Draw(r.im, r.x, r.y)
+		if l.listening.Load() {
+			if err := l.WaitForAck(Draw, asyncDrawAckTimeout); err != nil {
+				l.log().Debug("Async draw wasn't acknowledged", "display", r.display.Name, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// badge is a small overlay shown in the corner of a touch button.
+type badge struct {
+	text  string
+	color color.RGBA
+}
+
+// SetBadge shows a small round badge (an unread count, a warning dot,
+// and so on) in the upper right corner of a touch button, on top of
+// whatever is drawn there.  The badge stays on top when the button is
+// redrawn, and can be removed with ClearBadge without having to
+// redraw the button itself.  Use an empty string for a plain dot.
+//
+// Badges are composited using the client-side mirror, so SetBadge
+// enables mirroring.  If mirroring was disabled before, then the
+// button's current contents are unknown and will be shown as black
+// until the button is redrawn.
+func (l *Loupedeck) SetBadge(b TouchButton, text string, c color.RGBA) {
+	if !l.mirroring.Load() {
+		l.SetMirroring(true)
+	}
+	if l.badges == nil {
+		l.badges = map[TouchButton]badge{}
+	}
+	l.badges[b] = badge{text: text, color: c}
+	l.redrawCell(b)
+}
+
+// ClearBadge removes the badge from a touch button, restoring the
+// button's image underneath.
+func (l *Loupedeck) ClearBadge(b TouchButton) {
+	if _, ok := l.badges[b]; !ok {
+		return
+	}
+	delete(l.badges, b)
+	l.redrawCell(b)
+}
+
+// redrawCell redraws a touch button from the mirror, which applies
+// (or removes) its badge.
+func (l *Loupedeck) redrawCell(b TouchButton) {
+	r := l.CellRegion(b)
+	if r == nil {
+		return
+	}
+	if im := l.Snapshot(r.display); im != nil {
+		r.display.Draw(im.(*image.RGBA).SubImage(image.Rect(r.x, r.y, r.x+r.width, r.y+r.height)), r.x, r.y)
+	}
+}
+
+// applyBadges composites any badges that overlap an image about to
+// be drawn at x,y (in framebuffer coordinates).  The mirror must
+// already have been updated with im.  If no badges overlap, im is
+// returned unchanged.
+func (d *Display) applyBadges(im image.Image, x, y int) image.Image {
+	l := d.loupedeck
+	l.mirrorMutex.Lock()
+	defer l.mirrorMutex.Unlock()
+	if len(l.badges) == 0 || l.mirrors[d.id] == nil {
+		return im
+	}
+
+	b := im.Bounds()
+	area := image.Rect(x, y, x+b.Dx(), y+b.Dy())
+	var out *image.RGBA
+	for tb, bd := range l.badges {
+		r := l.CellRegion(tb)
+		if r == nil || r.display.id != d.id {
+			continue
+		}
+		cell := image.Rect(r.x, r.y, r.x+r.width, r.y+r.height).Add(image.Pt(r.display.offsetx, r.display.offsety))
+		overlap := cell.Intersect(area)
+		if overlap.Empty() {
+			continue
+		}
+
+		if out == nil {
+			out = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+			draw.Draw(out, out.Bounds(), im, b.Min, draw.Src)
+		}
+
+		composite := image.NewRGBA(image.Rect(0, 0, cell.Dx(), cell.Dy()))
+		draw.Draw(composite, composite.Bounds(), l.mirrors[d.id], cell.Min, draw.Src)
+		drawBadge(l, composite, bd.text, bd.color, color.RGBA{255, 255, 255, 255})
+		draw.Draw(out, overlap.Sub(area.Min), composite, overlap.Min.Sub(cell.Min), draw.Src)
+	}
+	if out == nil {
+		return im
+	}
+	return out
+}
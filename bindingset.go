@@ -0,0 +1,124 @@
+package loupedeck
+
+// BindingSet groups input bindings that belong together, like those
+// of a single page or widget, so that they can be installed and
+// removed as a unit.  Bindings added to a BindingSet don't take
+// effect until Apply is called; after that, any further bindings
+// added to the set take effect immediately.  Remove uninstalls every
+// binding in the set, so a widget can be torn down without leaving
+// stale handlers behind.
+type BindingSet struct {
+	loupedeck *Loupedeck
+	applied   bool
+	buttons   map[Button]ButtonFunc
+	buttonsUp map[Button]ButtonFunc
+	knobs     map[Knob]KnobFunc
+	touches   map[TouchButton]TouchFunc
+	touchesUp map[TouchButton]TouchFunc
+}
+
+// NewBindingSet creates a new, empty BindingSet.
+func (l *Loupedeck) NewBindingSet() *BindingSet {
+	return &BindingSet{
+		loupedeck: l,
+		buttons:   map[Button]ButtonFunc{},
+		buttonsUp: map[Button]ButtonFunc{},
+		knobs:     map[Knob]KnobFunc{},
+		touches:   map[TouchButton]TouchFunc{},
+		touchesUp: map[TouchButton]TouchFunc{},
+	}
+}
+
+// BindButton adds a button binding to the set.  See
+// Loupedeck.BindButton.
+func (s *BindingSet) BindButton(b Button, f ButtonFunc) {
+	s.buttons[b] = f
+	if s.applied {
+		s.loupedeck.BindButton(b, f)
+	}
+}
+
+// BindButtonUp adds a button release binding to the set.  See
+// Loupedeck.BindButtonUp.
+func (s *BindingSet) BindButtonUp(b Button, f ButtonFunc) {
+	s.buttonsUp[b] = f
+	if s.applied {
+		s.loupedeck.BindButtonUp(b, f)
+	}
+}
+
+// BindKnob adds a knob binding to the set.  See Loupedeck.BindKnob.
+func (s *BindingSet) BindKnob(k Knob, f KnobFunc) {
+	s.knobs[k] = f
+	if s.applied {
+		s.loupedeck.BindKnob(k, f)
+	}
+}
+
+// BindTouch adds a touch binding to the set.  See
+// Loupedeck.BindTouch.
+func (s *BindingSet) BindTouch(b TouchButton, f TouchFunc) {
+	s.touches[b] = f
+	if s.applied {
+		s.loupedeck.BindTouch(b, f)
+	}
+}
+
+// BindTouchUp adds a touch release binding to the set.  See
+// Loupedeck.BindTouchUp.
+func (s *BindingSet) BindTouchUp(b TouchButton, f TouchFunc) {
+	s.touchesUp[b] = f
+	if s.applied {
+		s.loupedeck.BindTouchUp(b, f)
+	}
+}
+
+// Apply installs every binding in the set, replacing any existing
+// bindings for the same controls.
+func (s *BindingSet) Apply() {
+	l := s.loupedeck
+	for b, f := range s.buttons {
+		l.BindButton(b, f)
+	}
+	for b, f := range s.buttonsUp {
+		l.BindButtonUp(b, f)
+	}
+	for k, f := range s.knobs {
+		l.BindKnob(k, f)
+	}
+	for b, f := range s.touches {
+		l.BindTouch(b, f)
+	}
+	for b, f := range s.touchesUp {
+		l.BindTouchUp(b, f)
+	}
+	s.applied = true
+}
+
+// Remove uninstalls every binding in the set.  The controls are left
+// unbound, even if something else was bound to them before Apply.
+// The set keeps its bindings, and can be applied again later.
+func (s *BindingSet) Remove() {
+	l := s.loupedeck
+	for b := range s.buttons {
+		delete(l.buttonBindings, b)
+	}
+	for b := range s.buttonsUp {
+		delete(l.buttonUpBindings, b)
+	}
+	for k := range s.knobs {
+		delete(l.knobBindings, k)
+	}
+	for b := range s.touches {
+		delete(l.touchBindings, b)
+	}
+	for b := range s.touchesUp {
+		delete(l.touchUpBindings, b)
+	}
+	s.applied = false
+}
+
+// Applied returns true if the set is currently installed.
+func (s *BindingSet) Applied() bool {
+	return s.applied
+}
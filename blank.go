@@ -0,0 +1,114 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Blank makes the Loupedeck go dark for when it isn't going to be
+// used for a while, like overnight.  It fills every display with
+// black, turns off every button light, and turns the brightness all
+// the way down.  Brightness 0 on its own still leaves the backlight
+// glowing, and leaves whatever was on the screen faintly visible;
+// blanking the displays takes care of that.
+//
+// This isn't a hardware sleep mode: the Loupedeck protocol has no
+// documented command for one, so the device stays fully powered and
+// this is done with ordinary drawing, color, and brightness commands.
+// Applications that know of a sleep command in their firmware can
+// send it themselves; see RegisterMessageType.
+//
+// While blanked, draws, button colors, and brightness changes are
+// remembered but not sent.  The Loupedeck is unblanked when Unblank
+// is called, or on the first button press, knob turn, or touch.  The
+// input that unblanks it is swallowed, so that touching a dark screen
+// doesn't press whatever button happens to be underneath.
+//
+// Unblank puts the button colors and brightness back.  Displays are
+// redrawn from the client-side mirror if mirroring is enabled (see
+// SetMirroring), or by the callback set with SetRedrawStrategy;
+// otherwise they stay black until they're next drawn.
+func (l *Loupedeck) Blank() error {
+	if !l.blanked.CompareAndSwap(false, true) {
+		return nil
+	}
+	// Hold draws first, so that nothing is drawn over the displays
+	// while they're being blanked.
+	l.holdDraws.Store(true)
+
+	if err := l.blank(); err != nil {
+		// Don't leave the Loupedeck half blanked; put back
+		// whatever was already turned off.
+		_ = l.Unblank()
+		return err
+	}
+	return nil
+}
+
+// blank sends the commands that make the Loupedeck go dark.
+func (l *Loupedeck) blank() error {
+	for _, d := range l.displays {
+		w, h := d.Width(), d.Height()
+		data := d.framebufferHeader(d.offsetx, d.offsety, w, h)
+		data = append(data, make([]byte, 2*w*h)...)
+		if err := d.writeFramebuffer(data, nil); err != nil {
+			return fmt.Errorf("unable to blank display %s: %v", d.Name, err)
+		}
+	}
+	for _, b := range l.ledButtons() {
+		if err := l.Send(l.NewMessage(SetColor, []byte{byte(b), 0, 0, 0})); err != nil {
+			return err
+		}
+	}
+	return l.Send(l.NewMessage(SetBrightness, []byte{0}))
+}
+
+// Unblank undoes Blank.  Calling it when the Loupedeck isn't blanked
+// does nothing.
+func (l *Loupedeck) Unblank() error {
+	if !l.blanked.CompareAndSwap(true, false) {
+		return nil
+	}
+	l.holdDraws.Store(false)
+
+	if err := l.restoreLEDs(); err != nil {
+		return err
+	}
+	if l.mirroring.Load() {
+		l.replayMirrors()
+	} else if l.redraw != nil {
+		l.redraw()
+	}
+	return nil
+}
+
+// Blanked returns true if the Loupedeck has been blanked with Blank
+// and hasn't been unblanked yet.
+func (l *Loupedeck) Blanked() bool {
+	return l.blanked.Load()
+}
+
+// unblankOnInput unblanks the Loupedeck if it's blanked, returning
+// true if it was, so that the input can be dropped.
+func (l *Loupedeck) unblankOnInput() bool {
+	if !l.blanked.Load() {
+		return false
+	}
+	if err := l.Unblank(); err != nil {
+		l.reportError(fmt.Errorf("unable to unblank: %v", err))
+	}
+	return true
+}
+
+// setButtonColorBlanked records a button color while blanked,
+// without sending it.  It returns false if the Loupedeck isn't
+// blanked.
+func (l *Loupedeck) setButtonColorBlanked(b Button, c color.RGBA) bool {
+	if !l.blanked.Load() {
+		return false
+	}
+	l.ledMutex.Lock()
+	l.buttonColors[b] = c
+	l.ledMutex.Unlock()
+	return true
+}
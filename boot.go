@@ -0,0 +1,46 @@
+package loupedeck
+
+import (
+	"fmt"
+)
+
+// InitFunc is a function signature used for boot-time initialization.
+// See AddInitFunc.
+type InitFunc func(*Loupedeck) error
+
+// AddInitFunc registers a function to be run by Boot.  Init functions
+// are run in the order that they were added.
+func (l *Loupedeck) AddInitFunc(f InitFunc) {
+	l.initFuncs = append(l.initFuncs, f)
+}
+
+// Boot runs all of the functions registered with AddInitFunc with
+// drawing held, so that pages and widgets are built in the
+// client-side mirror without sending anything to the Loupedeck.  Once
+// they've all finished, the mirror is sent to the device in one pass,
+// so the displays go straight from their previous contents (perhaps
+// a splash screen; see ShowSplash) to the finished layout, without
+// widgets popping in one at a time.
+//
+// Boot enables mirroring, since that's where the off-screen
+// framebuffer lives.  If an init function returns an error, Boot
+// stops, releases drawing without presenting anything, and returns
+// the error.
+func (l *Loupedeck) Boot() error {
+	if !l.mirroring.Load() {
+		l.SetMirroring(true)
+	}
+
+	l.holdDraws.Store(true)
+	for i, f := range l.initFuncs {
+		if err := f(l); err != nil {
+			l.holdDraws.Store(false)
+			return fmt.Errorf("init function %d failed: %v", i, err)
+		}
+	}
+	l.holdDraws.Store(false)
+
+	l.log().Info("Boot complete, presenting displays", "initFuncs", len(l.initFuncs))
+	l.replayMirrors()
+	return nil
+}
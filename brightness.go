@@ -0,0 +1,118 @@
+package loupedeck
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"github.com/jphsd/graphics2d"
+)
+
+// drawLevelArc draws a 270 degree arc centered at cx,cy with radius
+// r, open at the bottom, with the first fraction of it highlighted.
+// This is the same style of arc used by DKAnalogWidget.
+func drawLevelArc(im draw.Image, t *Theme, cx, cy, r, fraction, width float64) {
+	start := d2r(135)
+	p := []float64{cx + math.Cos(start)*r, cy + math.Sin(start)*r}
+	center := []float64{cx, cy}
+
+	graphics2d.DrawArc(im, p, center, d2r(270), graphics2d.NewPen(t.Inactive, t.LineWidth(1)))
+	if fraction > 0 {
+		graphics2d.DrawArc(im, p, center, d2r(270)*fraction, graphics2d.NewPen(t.Active, t.LineWidth(width)))
+	}
+}
+
+// BrightnessKnob binds the Loupedeck's display brightness to one of
+// the knobs, showing the current level as an arc on the display next
+// to the knob.
+type BrightnessKnob struct {
+	loupedeck *Loupedeck
+	region    *Region
+}
+
+// NewBrightnessKnob creates a new BrightnessKnob on the specified
+// Knob.  For the Loupedeck CT's big knob, use DKBrightnessWidget
+// instead.
+func (l *Loupedeck) NewBrightnessKnob(k Knob) *BrightnessKnob {
+	b := &BrightnessKnob{
+		loupedeck: l,
+		region:    l.KnobRegion(k),
+	}
+	l.BindKnob(k, func(_ Knob, v int) {
+		_ = l.StepBrightness(v)
+		b.Draw()
+	})
+	b.Draw()
+	return b
+}
+
+// Draw draws the BrightnessKnob's level arc.
+func (b *BrightnessKnob) Draw() {
+	r := b.region
+	if r == nil {
+		return
+	}
+	im := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	theme := b.loupedeck.Theme()
+	draw.Draw(im, im.Bounds(), &image.Uniform{theme.Background}, image.Point{}, draw.Src)
+
+	cx, cy := float64(r.width)/2, float64(r.height)/2
+	level := b.loupedeck.GetBrightness()
+	drawLevelArc(im, theme, cx, cy, cx-6, float64(level)/MaxBrightness, 3)
+
+	fd := b.loupedeck.FontDrawer()
+	fd.Dst = im
+	drawCenteredStringAt(fd, strconv.Itoa(level), int(cx), int(cy)+10)
+
+	r.Draw(im)
+}
+
+// DKBrightnessWidget is a DKWidget for the Loupedeck CT's big knob
+// that controls the display brightness.
+type DKBrightnessWidget struct {
+	active bool
+}
+
+// NewDKBrightnessWidget creates a new DKBrightnessWidget.
+func NewDKBrightnessWidget() *DKBrightnessWidget {
+	return &DKBrightnessWidget{}
+}
+
+// Activate is called when the widget gains focus.  It binds the CT
+// knob to the display brightness.
+func (w *DKBrightnessWidget) Activate(l *Loupedeck) {
+	w.active = true
+	l.BindKnob(CTKnob, func(_ Knob, v int) {
+		_ = l.StepBrightness(v)
+		w.Draw(l)
+	})
+	w.Draw(l)
+}
+
+// Deactivate is called when the widget loses focus.
+func (w *DKBrightnessWidget) Deactivate(l *Loupedeck) {
+	w.active = false
+}
+
+// Draw draws the widget on the dial display, if the widget is
+// currently active.
+func (w *DKBrightnessWidget) Draw(l *Loupedeck) {
+	display := l.GetDisplay("dial")
+	if !w.active || display == nil {
+		return
+	}
+
+	im := image.NewRGBA(image.Rect(0, 0, 240, 210))
+	draw.Draw(im, im.Bounds(), &image.Uniform{l.theme.Background}, image.Point{}, draw.Src)
+
+	level := l.GetBrightness()
+	drawLevelArc(im, &l.theme, 120, 120, 110, float64(level)/MaxBrightness, 4)
+
+	fd := l.FontDrawer()
+	fd.Dst = im
+	drawCenteredStringAt(fd, "Brightness", 120, 80)
+	drawCenteredStringAt(fd, strconv.Itoa(level), 120, 160)
+
+	display.Draw(im, 0, 0)
+}
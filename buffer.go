@@ -0,0 +1,73 @@
+package loupedeck
+
+import (
+	"errors"
+)
+
+// bufferedWrite is a framebuffer write waiting for Flush.
+type bufferedWrite struct {
+	display *Display
+	data    []byte
+}
+
+// drawBuffer holds framebuffer writes while buffering is enabled.  It's
+// guarded by the drawMutex.
+type drawBuffer struct {
+	enabled bool
+	writes  []bufferedWrite
+}
+
+// SetDrawBuffering enables or disables buffered drawing.  While it's
+// enabled, Draw (and everything built on it) only queues framebuffer
+// writes, and nothing reaches the Loupedeck until Flush is called.
+// Flush then sends all of the writes, followed by a single Draw
+// command per display, so an update that touches many parts of the
+// screen appears all at once, instead of piece by piece, and takes
+// fewer messages.
+//
+//	l.SetDrawBuffering(true)
+//	for _, w := range widgets {
+//		w.Draw()
+//	}
+//	l.Flush()
+//
+// Disabling buffering flushes anything that's queued.
+func (l *Loupedeck) SetDrawBuffering(enabled bool) error {
+	l.drawMutex.Lock()
+	l.buffer.enabled = enabled
+	l.drawMutex.Unlock()
+	if !enabled {
+		return l.Flush()
+	}
+	return nil
+}
+
+// Flush sends all of the framebuffer writes queued while buffering is
+// enabled, and then a Draw command for each display that was written
+// to.  It does nothing if nothing is queued.  See SetDrawBuffering.
+func (l *Loupedeck) Flush() error {
+	l.drawMutex.Lock()
+	defer l.drawMutex.Unlock()
+	writes := l.buffer.writes
+	l.buffer.writes = nil
+
+	var errs []error
+	var displays []*Display
+	seen := map[byte]bool{}
+	for _, w := range writes {
+		if err := w.display.sendFramebuffer(w.data); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !seen[w.display.id] {
+			seen[w.display.id] = true
+			displays = append(displays, w.display)
+		}
+	}
+	for _, d := range displays {
+		if err := d.sendDraw(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,203 @@
+package loupedeck
+
+import (
+	"container/list"
+	"image"
+	"sync"
+)
+
+// DefaultImageCacheSize is the default number of rendered images kept
+// by DrawCached.  See SetImageCacheSize.
+const DefaultImageCacheSize = 256
+
+// cacheKey identifies a single cell on a single display.
+type cacheKey struct {
+	display string
+	cell    string
+}
+
+// cacheEntry records which content is currently shown in a cell, and
+// where that cell lives in framebuffer coordinates.
+type cacheEntry struct {
+	id        byte
+	rect      image.Rectangle
+	contentID string
+}
+
+// imageKey identifies one rendered image for one cell.
+type imageKey struct {
+	cacheKey
+	contentID string
+}
+
+// cachedImage is an element of imageCache.lru.
+type cachedImage struct {
+	key imageKey
+	im  image.Image
+}
+
+// imageCache tracks what has been drawn into each Region, along with
+// previously-rendered images, so that redrawing a page only sends
+// cells whose content has actually changed.  Rendered images are
+// evicted least recently used first once there are more than size of
+// them.
+type imageCache struct {
+	mutex   sync.Mutex
+	current map[cacheKey]cacheEntry
+	images  map[imageKey]*list.Element
+	lru     *list.List // of *cachedImage, most recently used first
+	size    int
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{
+		current: map[cacheKey]cacheEntry{},
+		images:  map[imageKey]*list.Element{},
+		lru:     list.New(),
+		size:    DefaultImageCacheSize,
+	}
+}
+
+// SetImageCacheSize sets the maximum number of rendered images kept by
+// DrawCached, across all Regions.  Once the cache is full, the least
+// recently used image is discarded, and rendered again if it's needed
+// later.  A size of 0 restores the default.
+func (l *Loupedeck) SetImageCacheSize(size int) {
+	if size <= 0 {
+		size = DefaultImageCacheSize
+	}
+	c := l.cache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.size = size
+	c.evict()
+}
+
+// get returns the image rendered for key, if it's cached, and marks it
+// as recently used.  The caller must hold the mutex.
+func (c *imageCache) get(key imageKey) image.Image {
+	e, ok := c.images[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedImage).im
+}
+
+// put caches the image rendered for key.  The caller must hold the
+// mutex.
+func (c *imageCache) put(key imageKey, im image.Image) {
+	if e, ok := c.images[key]; ok {
+		e.Value.(*cachedImage).im = im
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.images[key] = c.lru.PushFront(&cachedImage{key: key, im: im})
+	c.evict()
+}
+
+// evict discards the least recently used images until the cache is
+// no bigger than its size.  The caller must hold the mutex.
+func (c *imageCache) evict() {
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.images, e.Value.(*cachedImage).key)
+	}
+}
+
+// DrawCached draws content into a Region, unless the content
+// identified by contentID is already being shown there.  The render
+// function is only called if no image for this contentID has been
+// rendered for this Region before.  DrawCached returns true if
+// anything was actually sent to the Loupedeck.
+//
+// Content IDs are chosen by the caller, and should change whenever
+// the rendered image would change; something like "volume:37" works
+// well.
+func (l *Loupedeck) DrawCached(r *Region, contentID string, render func() image.Image) bool {
+	c := l.cache
+	key := cacheKey{display: r.display.Name, cell: r.Name}
+
+	c.mutex.Lock()
+	if e, ok := c.current[key]; ok && e.contentID == contentID {
+		c.mutex.Unlock()
+		return false
+	}
+	im := c.get(imageKey{key, contentID})
+	c.mutex.Unlock()
+
+	if im == nil {
+		im = render()
+		c.mutex.Lock()
+		c.put(imageKey{key, contentID}, im)
+		c.mutex.Unlock()
+	}
+
+	// Draw invalidates the cell, so record it afterwards.
+	r.Draw(im)
+
+	rect := image.Rect(r.x, r.y, r.x+r.width, r.y+r.height).Add(image.Pt(r.display.offsetx, r.display.offsety))
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.current[key] = cacheEntry{
+		id:        r.display.id,
+		rect:      rect,
+		contentID: contentID,
+	}
+	return true
+}
+
+// Invalidate forgets what is shown in a Region and discards any
+// images rendered for it, so the next DrawCached call will always
+// render and send.  Use this when external state that affects
+// rendering changes without a corresponding change in content ID.
+func (l *Loupedeck) Invalidate(r *Region) {
+	key := cacheKey{display: r.display.Name, cell: r.Name}
+	c := l.cache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.current, key)
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if ci := e.Value.(*cachedImage); ci.key.cacheKey == key {
+			c.lru.Remove(e)
+			delete(c.images, ci.key)
+		}
+		e = next
+	}
+}
+
+// InvalidateAll empties the image cache completely.
+func (l *Loupedeck) InvalidateAll() {
+	c := l.cache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.current = map[cacheKey]cacheEntry{}
+	c.images = map[imageKey]*list.Element{}
+	c.lru.Init()
+}
+
+// forgetCurrent forgets what is shown in every cell, but keeps the
+// rendered images.  This is called when the Loupedeck is reset, since
+// that clears its displays.
+func (c *imageCache) forgetCurrent() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.current = map[cacheKey]cacheEntry{}
+}
+
+// invalidateRect forgets the current contents of any cached cell that
+// overlaps the given rectangle (in framebuffer coordinates).  This is
+// called for every Draw, so that drawing over a cell without going
+// through the cache doesn't leave stale entries behind.  Rendered
+// images are kept, as they're still valid.
+func (c *imageCache) invalidateRect(id byte, r image.Rectangle) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for k, e := range c.current {
+		if e.id == id && e.rect.Overlaps(r) {
+			delete(c.current, k)
+		}
+	}
+}
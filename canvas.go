@@ -0,0 +1,64 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Canvas is an image tied to an area of a Display.  Draw on it like
+// any other draw.Image, using coordinates relative to the top left
+// corner of the area, and then call Flush to send it to the
+// Loupedeck.  This saves the usual dance of creating an image,
+// drawing on it, and calling Draw with the right offsets.
+//
+//	c := d.Canvas(0, 0, 60, 270)
+//	c.Fill(theme.Background)
+//	draw.Draw(c, image.Rect(4, 10, 56, 100), fg, image.Point{}, draw.Src)
+//	c.Flush()
+//
+// A Canvas can be drawn on and flushed as many times as needed.
+type Canvas struct {
+	*image.RGBA
+
+	display *Display
+	x, y    int
+}
+
+// Canvas returns a new Canvas covering the w x h area of the Display
+// with its top left corner at x,y.  It starts out transparent black.
+func (d *Display) Canvas(x, y, w, h int) *Canvas {
+	return &Canvas{
+		RGBA:    image.NewRGBA(image.Rect(0, 0, w, h)),
+		display: d,
+		x:       x,
+		y:       y,
+	}
+}
+
+// Canvas returns a new Canvas covering the Region.
+func (r *Region) Canvas() *Canvas {
+	return r.display.Canvas(r.x, r.y, r.width, r.height)
+}
+
+// Display returns the Display that the Canvas draws on.
+func (c *Canvas) Display() *Display {
+	return c.display
+}
+
+// Fill fills the whole Canvas with a single color.
+func (c *Canvas) Fill(col color.Color) {
+	draw.Draw(c.RGBA, c.Bounds(), &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+// Flush sends the Canvas to its Display.
+func (c *Canvas) Flush() {
+	c.display.Draw(c.RGBA, c.x, c.y)
+}
+
+// FlushAsync is like Flush, but uses DrawAsync.  The Canvas shouldn't
+// be drawn on again until the draw has been sent, so it's best used
+// with a fresh Canvas each time.
+func (c *Canvas) FlushAsync() {
+	c.display.DrawAsync(c.RGBA, c.x, c.y)
+}
@@ -0,0 +1,79 @@
+package loupedeck
+
+import (
+	"sort"
+)
+
+// Capabilities describes what the connected device can do, so that
+// applications can support several models without checking product
+// IDs themselves.
+type Capabilities struct {
+	// Model is the name of the device, like "Loupedeck Live".
+	Model string
+
+	// Displays lists the names of the device's displays, as used
+	// with GetDisplay.
+	Displays []string
+	// HasDialScreen is true if the device has a display in its
+	// center knob (the Loupedeck CT).
+	HasDialScreen bool
+	// HasTouchStrips is true if the device has touchscreen strips
+	// next to its knobs.
+	HasTouchStrips bool
+
+	// TouchColumns and TouchRows are the size of the grid of
+	// touch buttons, and NumTouchKeys is the number of keys in it.
+	TouchColumns, TouchRows int
+	NumTouchKeys            int
+
+	// Knobs lists the device's knobs, and NumKnobs is how many
+	// there are.
+	Knobs    []Knob
+	NumKnobs int
+	// Buttons lists the device's buttons, including knob presses.
+	Buttons []Button
+
+	// SupportsVibration is true if the device can vibrate.
+	SupportsVibration bool
+	// SupportsDisplays is false for devices that can't be drawn on
+	// at all, like the Loupedeck+.
+	SupportsDisplays bool
+}
+
+// Capabilities describes the connected device.  It's available as
+// soon as the Loupedeck is connected, even before SetDisplays is
+// called.  If the device isn't recognized, the returned Capabilities
+// are empty apart from Model.
+func (l *Loupedeck) Capabilities() Capabilities {
+	p := l.profile
+	if p == nil && l.hid {
+		p = loupedeckPlusProfile
+	}
+	if p == nil {
+		p = deviceProfiles[l.Product]
+	}
+	if p == nil {
+		return Capabilities{Model: "unknown (" + l.Product + ")"}
+	}
+
+	c := Capabilities{
+		Model:             p.Name,
+		HasTouchStrips:    p.Touch.StripWidth > 0,
+		TouchColumns:      p.Touch.Columns,
+		TouchRows:         p.Touch.Rows,
+		NumTouchKeys:      p.Touch.Columns * p.Touch.Rows,
+		Knobs:             append([]Knob{}, p.Knobs...),
+		NumKnobs:          len(p.Knobs),
+		Buttons:           append([]Button{}, p.Buttons...),
+		SupportsVibration: p.Vibration,
+		SupportsDisplays:  len(p.Displays) > 0,
+	}
+	for _, d := range p.Displays {
+		c.Displays = append(c.Displays, d.Name)
+		if d.Name == "dial" {
+			c.HasDialScreen = true
+		}
+	}
+	sort.Strings(c.Displays)
+	return c
+}
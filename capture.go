@@ -0,0 +1,129 @@
+package loupedeck
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// CaptureRecord is a single message in a protocol capture.  Captures
+// are written as JSON Lines, one CaptureRecord per line, so they can
+// be read with ReadCapture, or with jq and friends.
+type CaptureRecord struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // "inbound" or "outbound"
+	Data      string    `json:"data"`      // the message, in hex
+}
+
+// Bytes returns the record's message.
+func (r CaptureRecord) Bytes() ([]byte, error) {
+	return hex.DecodeString(r.Data)
+}
+
+// captureState holds the writer set by StartCapture.
+type captureState struct {
+	mutex sync.Mutex
+	w     io.Writer
+	enc   *json.Encoder
+	err   error
+}
+
+// StartCapture starts writing every message sent to and received from
+// the Loupedeck to w, with timestamps, exactly as it goes over the
+// wire; outbound messages are captured after any message hooks have
+// changed them, and inbound messages before.  This is mostly useful for
+// reporting problems with specific models; captures can be fed back
+// through the library with Replay.  Any previous capture is stopped.
+func (l *Loupedeck) StartCapture(w io.Writer) {
+	c := &l.capture
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.w = w
+	c.enc = json.NewEncoder(w)
+	c.err = nil
+}
+
+// StopCapture stops capturing, and returns the first error that
+// happened while writing the capture, if any.  It doesn't close the
+// writer passed to StartCapture.
+func (l *Loupedeck) StopCapture() error {
+	c := &l.capture
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := c.err
+	c.w = nil
+	c.enc = nil
+	c.err = nil
+	return err
+}
+
+// captureMessage writes a message to the capture, if one is running.
+func (l *Loupedeck) captureMessage(d Direction, b []byte) {
+	c := &l.capture
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.enc == nil || c.err != nil {
+		return
+	}
+	c.err = c.enc.Encode(CaptureRecord{
+		Time:      time.Now(),
+		Direction: d.String(),
+		Data:      hex.EncodeToString(b),
+	})
+}
+
+// ReadCapture reads a capture written by StartCapture.
+func ReadCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<24)
+	for line := 1; s.Scan(); line++ {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var rec CaptureRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, s.Err()
+}
+
+// Replay reads a capture written by StartCapture, and feeds the
+// inbound messages through the same decoding and dispatch as Listen,
+// so that bindings and callbacks are called just as they were when
+// the capture was made.  Outbound messages are skipped.  If realtime
+// is true, the original gaps between messages are kept; otherwise
+// the messages are replayed as fast as possible.
+//
+// Replay works on any Loupedeck, including one connected to a
+// MockDevice, so a capture from someone else's hardware can be
+// debugged without that hardware.
+func (l *Loupedeck) Replay(r io.Reader, realtime bool) error {
+	records, err := ReadCapture(r)
+	if err != nil {
+		return err
+	}
+
+	var last time.Time
+	for i, rec := range records {
+		if rec.Direction != Inbound.String() {
+			continue
+		}
+		b, err := rec.Bytes()
+		if err != nil {
+			return fmt.Errorf("record %d: %v", i+1, err)
+		}
+		if realtime && !last.IsZero() {
+			time.Sleep(rec.Time.Sub(last))
+		}
+		last = rec.Time
+		l.safeCall("", func() { l.handleMessage(b) })
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package loupedeck
+
+import (
+	"image"
+	"image/draw"
+	"time"
+)
+
+// EnableChooser changes a MultiButton so that a short tap still
+// advances to the next value, but holding the button down for at
+// least hold opens a chooser (see OpenChooser).  This is useful for
+// MultiButtons with more values than it's practical to tap through.
+func (m *MultiButton) EnableChooser(hold time.Duration) {
+	l := m.loupedeck
+	var pressed time.Time
+
+	l.BindTouch(m.button, func(TouchButton, ButtonStatus, uint16, uint16) {
+		// Touch events repeat while the finger moves; only the
+		// first one starts the clock.
+		if pressed.IsZero() {
+			pressed = time.Now()
+		}
+	})
+	l.BindTouchUp(m.button, func(TouchButton, ButtonStatus, uint16, uint16) {
+		held := time.Since(pressed)
+		pressed = time.Time{}
+		if held >= hold {
+			m.OpenChooser()
+		} else {
+			m.Advance()
+		}
+	})
+}
+
+// OpenChooser takes over the main display and shows all of the
+// MultiButton's images at once, one per touch button.  Tapping an
+// image selects its value and returns to the normal display.  If
+// there are more values than touch buttons, then the bottom-right two
+// buttons page backwards and forwards through them.
+//
+// Like ShowConfirm, all other bindings are suspended while the
+// chooser is open, and the display is restored from the client-side
+// mirror (if enabled) when it closes.
+func (m *MultiButton) OpenChooser() {
+	l := m.loupedeck
+	d := m.display
+	saved := l.Snapshot(d)
+	bindings := l.suspendBindings()
+
+	cells := l.TouchButtons()
+	perPage := len(cells)
+	if len(m.images) > perPage {
+		// Leave room for the "<" and ">" buttons.
+		perPage -= 2
+	}
+	pages := (len(m.images) + perPage - 1) / perPage
+	page := m.GetCur() / perPage
+
+	done := func(value int) {
+		l.restoreBindings(bindings)
+		if saved != nil {
+			d.Draw(saved, 0, 0)
+		} else {
+			d.Draw(blankImage(d.Width(), d.Height()), 0, 0)
+		}
+		m.value.Set(value)
+		m.Draw()
+	}
+
+	var show func()
+	show = func() {
+		l.touchUpBindings = make(map[TouchButton]TouchFunc)
+		d.Draw(blankImage(d.Width(), d.Height()), 0, 0)
+
+		for i := 0; i < perPage; i++ {
+			idx := page*perPage + i
+			if idx >= len(m.images) {
+				break
+			}
+			b := TouchButton(int(Touch1) + i)
+			x, y := l.cellXY(b)
+			d.Draw(m.images[idx], x, y)
+
+			value := m.values[idx]
+			l.BindTouchUp(b, func(TouchButton, ButtonStatus, uint16, uint16) {
+				done(value)
+			})
+		}
+
+		if pages > 1 {
+			for _, nav := range []struct {
+				b     TouchButton
+				label string
+				delta int
+			}{{cells[len(cells)-2], "<", -1}, {cells[len(cells)-1], ">", 1}} {
+				delta := nav.delta
+				size := l.touchGrid().CellSize
+				im, err := l.TextInBox(size, size, nav.label, l.theme.Text, l.theme.Inactive)
+				if err == nil {
+					x, y := l.cellXY(nav.b)
+					d.Draw(im, x, y)
+				}
+				l.BindTouchUp(nav.b, func(TouchButton, ButtonStatus, uint16, uint16) {
+					page = (page + delta + pages) % pages
+					show()
+				})
+			}
+		}
+	}
+	show()
+}
+
+// blankImage returns a w x h image filled with the background color.
+func blankImage(w, h int) image.Image {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(im, im.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+	return im
+}
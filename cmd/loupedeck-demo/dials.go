@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// runDials puts a TouchDial on each side strip, each controlling three
+// values, and on the Loupedeck CT a set of widgets on the dial screen.
+func runDials(l *loupedeck.Loupedeck, _ *loupedeck.MockDevice) (bool, error) {
+	caps := l.Capabilities()
+	if !caps.HasTouchStrips && !caps.HasDialScreen {
+		return false, fmt.Errorf("%s has no touch strips or dial screen", caps.Model)
+	}
+
+	if caps.HasTouchStrips {
+		var values []*loupedeck.WatchedInt
+		for i := 1; i <= 6; i++ {
+			w := loupedeck.NewWatchedInt(0)
+			w.AddWatcher(printer(fmt.Sprintf("dial %d", i)))
+			values = append(values, w)
+		}
+		_ = l.NewTouchDial(l.GetDisplay("left"), values[0], values[1], values[2], 0, 100)
+		_ = l.NewTouchDial(l.GetDisplay("right"), values[3], values[4], values[5], 0, 10)
+	}
+
+	if caps.HasDialScreen {
+		x1 := loupedeck.NewWatchedInt(50)
+		w1 := loupedeck.NewDKAnalogWidget(0, 100, x1, "Level")
+		x1.AddWatcher(func(i int) {
+			fmt.Printf("level -> %d\n", i)
+			w1.Draw(l)
+		})
+
+		x2 := loupedeck.NewWatchedInt(10)
+		w2 := loupedeck.NewDKAnalogWidget(0, 30, x2, "Delay")
+		x2.AddWatcher(func(i int) {
+			fmt.Printf("delay -> %d\n", i)
+			w2.Draw(l)
+		})
+
+		l.WidgetHolder([]loupedeck.DKWidget{w1, w2})
+	}
+	return false, nil
+}
@@ -0,0 +1,131 @@
+// Command loupedeck-demo is a gallery of demos for
+// github.com/scottlaird/loupedeck.  Each subcommand exercises one of
+// the library's major pieces, so it doubles as a manual integration
+// test when bringing up a new device model:
+//
+//	loupedeck-demo [-mock] [-target /dev/ttyACM0] [-v] <subcommand>
+//
+// Run it without a subcommand for a list.  With -mock, the demos run
+// against an in-memory MockDevice instead of real hardware, which is
+// mostly useful for checking that they start up cleanly; the
+// simulator subcommand always uses the mock device and drives it with
+// scripted input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// demo is a single subcommand.
+type demo struct {
+	help string
+	// mock forces the demo to run against a MockDevice.
+	mock bool
+	// run sets up the demo.  If it returns done, the demo has
+	// already finished; otherwise it runs until interrupted.  d is
+	// nil unless the demo is running on a mock device.
+	run func(l *loupedeck.Loupedeck, d *loupedeck.MockDevice) (done bool, err error)
+}
+
+var demos = map[string]demo{
+	"dials":     {help: "touch dials on the side strips, and the dial screen on the CT", run: runDials},
+	"widgets":   {help: "multi-state touch buttons and knob widgets", run: runWidgets},
+	"mixer":     {help: "a small mixer with knobs, strip sliders, and mute buttons", run: runMixer},
+	"pages":     {help: "swipeable pages with per-page button colors", run: runPages},
+	"simulator": {help: "the mixer, driven by scripted input on a mock device", mock: true, run: runSimulator},
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <subcommand>\n\nSubcommands:\n", os.Args[0])
+	names := make([]string, 0, len(demos))
+	for name := range demos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s %s\n", name, demos[name].help)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	mock := flag.Bool("mock", false, "use a mock device instead of real hardware")
+	target := flag.String("target", "", "serial port or ws:// URL to connect to, instead of searching for a Loupedeck")
+	verbose := flag.Bool("v", false, "log every message sent to and received from the Loupedeck")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	dm, ok := demos[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	opts := []loupedeck.Option{
+		loupedeck.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))),
+	}
+	if *target != "" {
+		opts = append(opts, loupedeck.WithTarget(*target))
+	}
+
+	if err := run(dm, *mock || dm.mock, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the Loupedeck and runs a demo on it.
+func run(dm demo, mock bool, opts []loupedeck.Option) error {
+	var d *loupedeck.MockDevice
+	var l *loupedeck.Loupedeck
+	var err error
+	if mock {
+		d = loupedeck.NewMockDevice()
+		l, err = loupedeck.ConnectMock(d, opts...)
+	} else {
+		l, err = loupedeck.Connect(opts...)
+	}
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	go l.Listen()
+	if err := l.SetDisplays(); err != nil {
+		return err
+	}
+	fmt.Printf("Connected to %s\n", l.Capabilities().Model)
+
+	done, err := dm.run(l, d)
+	if err != nil || done {
+		return err
+	}
+
+	fmt.Printf("Running; press ^C to exit.\n")
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	<-ch
+	return nil
+}
+
+// printer returns a watcher that prints a value's changes.
+func printer(name string) func(int) {
+	return func(i int) { fmt.Printf("%s -> %d\n", name, i) }
+}
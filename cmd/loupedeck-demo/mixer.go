@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// mixer is a tiny mixer: one channel per knob, plus a master level
+// on the right strip.
+type mixer struct {
+	channels []*loupedeck.WatchedInt
+	master   *loupedeck.WatchedInt
+}
+
+// runMixer sets up the mixer demo.
+func runMixer(l *loupedeck.Loupedeck, _ *loupedeck.MockDevice) (bool, error) {
+	_, err := newMixer(l)
+	return false, err
+}
+
+// newMixer binds each knob to a channel level, with clicks muting
+// the channel, shows channel 1 and the master level on the side
+// strips, and lights the numbered buttons red while their channel is
+// muted.
+func newMixer(l *loupedeck.Loupedeck) (*mixer, error) {
+	caps := l.Capabilities()
+	if len(caps.Knobs) == 0 {
+		return nil, fmt.Errorf("%s has no knobs", caps.Model)
+	}
+
+	m := &mixer{master: loupedeck.NewWatchedInt(80)}
+	m.master.AddWatcher(printer("master"))
+	for i, k := range caps.Knobs {
+		w := loupedeck.NewWatchedInt(50)
+		w.AddWatcher(printer(fmt.Sprintf("channel %d", i+1)))
+		ik := l.IntKnob(k, 0, 100, w)
+		ik.SetPressAction(loupedeck.PressMute)
+		m.channels = append(m.channels, w)
+
+		b := loupedeck.Button1 + loupedeck.Button(i)
+		w.AddWatcher(func(v int) {
+			c := color.RGBA{0, 64, 0, 255}
+			if v == 0 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			_ = l.SetButtonColor(b, c)
+		})
+	}
+
+	if caps.HasTouchStrips {
+		_ = l.NewStripSlider(l.GetDisplay("left"), m.channels[0], 0, 100)
+		_ = l.NewStripSlider(l.GetDisplay("right"), m.master, 0, 100)
+	}
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// runPages shows three pages, switched by swiping on the main
+// display.  Each page labels its touch buttons and sets its own
+// button colors.
+func runPages(l *loupedeck.Loupedeck, _ *loupedeck.MockDevice) (bool, error) {
+	if l.GetDisplay("main") == nil {
+		return false, fmt.Errorf("%s has no main display", l.Capabilities().Model)
+	}
+
+	colors := []color.RGBA{
+		{0, 0, 160, 255},
+		{0, 128, 0, 255},
+		{160, 0, 0, 255},
+	}
+	var pages []*loupedeck.Page
+	for i, c := range colors {
+		name := fmt.Sprintf("Page %d", i+1)
+		c := c
+		pages = append(pages, &loupedeck.Page{
+			Name: name,
+			Activate: func(l *loupedeck.Loupedeck) {
+				fmt.Printf("showing %s\n", name)
+				for j, b := range l.TouchButtons() {
+					r := l.CellRegion(b)
+					if r == nil {
+						continue
+					}
+					label := fmt.Sprintf("%d.%d", i+1, j+1)
+					im, err := l.TextInBox(r.Width(), r.Height(), label, color.White, c)
+					if err != nil {
+						continue
+					}
+					r.Draw(im)
+					l.BindTouch(b, func(loupedeck.TouchButton, loupedeck.ButtonStatus, uint16, uint16) {
+						fmt.Printf("touched %s\n", label)
+					})
+				}
+			},
+			ButtonColors: map[loupedeck.Button]color.RGBA{
+				loupedeck.Circle: c,
+			},
+		})
+	}
+
+	p := l.NewPager(pages)
+	p.Animate = true
+	p.ShowIndicator = true
+	return false, nil
+}
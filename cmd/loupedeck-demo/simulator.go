@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// simulatorStep is how long the simulator waits after each input, so
+// that its effects are printed in order.
+const simulatorStep = 100 * time.Millisecond
+
+// runSimulator sets up the mixer on a mock device, and then plays
+// the part of a user: it turns knobs, mutes a channel, and drags the
+// left strip slider.  Afterwards it prints what was sent to the
+// device.
+func runSimulator(l *loupedeck.Loupedeck, d *loupedeck.MockDevice) (bool, error) {
+	if d == nil {
+		return false, errors.New("simulator needs a mock device")
+	}
+	m, err := newMixer(l)
+	if err != nil {
+		return false, err
+	}
+
+	steps := []struct {
+		what string
+		do   func()
+	}{
+		{"turn knob 1 up 5", func() { d.TurnKnob(loupedeck.Knob1, 5) }},
+		{"turn knob 2 down 3", func() { d.TurnKnob(loupedeck.Knob2, -3) }},
+		{"click knob 2 to mute", func() {
+			d.PressButton(loupedeck.KnobPress2)
+			d.ReleaseButton(loupedeck.KnobPress2)
+		}},
+		{"drag the left strip", func() {
+			for y := uint16(200); y >= 50; y -= 50 {
+				d.Touch(30, y, 1)
+			}
+			d.TouchEnd(30, 50, 1)
+		}},
+		{"drag the right strip", func() {
+			d.Touch(450, 250, 2)
+			d.TouchEnd(450, 250, 2)
+		}},
+	}
+	for _, s := range steps {
+		fmt.Printf("== %s\n", s.what)
+		s.do()
+		time.Sleep(simulatorStep)
+	}
+
+	fmt.Printf("\nFinal levels:")
+	for i, c := range m.channels {
+		fmt.Printf(" %d=%d", i+1, c.Get())
+	}
+	fmt.Printf(" master=%d\n", m.master.Get())
+
+	fmt.Printf("\nMessages sent to the device:\n")
+	counts := map[loupedeck.MessageType]int{}
+	for _, msg := range d.Sent() {
+		counts[msg.Type()]++
+	}
+	types := make([]loupedeck.MessageType, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	for _, t := range types {
+		fmt.Printf("  %-16s %d\n", t, counts[t])
+	}
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/scottlaird/loupedeck"
+)
+
+// runWidgets shows a MultiButton on each of the first few touch
+// buttons, an IntKnob on the first knob, and an EnumKnob on the
+// second.
+func runWidgets(l *loupedeck.Loupedeck, _ *loupedeck.MockDevice) (bool, error) {
+	caps := l.Capabilities()
+	if !caps.SupportsDisplays {
+		return false, fmt.Errorf("%s has no displays", caps.Model)
+	}
+
+	states := []struct {
+		name  string
+		color color.RGBA
+	}{
+		{"Off", color.RGBA{32, 32, 32, 255}},
+		{"Low", color.RGBA{0, 96, 0, 255}},
+		{"Mid", color.RGBA{160, 160, 0, 255}},
+		{"High", color.RGBA{192, 0, 0, 255}},
+	}
+	ims := make([]image.Image, len(states))
+	for i, s := range states {
+		im, err := l.TextInBox(90, 90, s.name, color.White, s.color)
+		if err != nil {
+			return false, err
+		}
+		ims[i] = im
+	}
+
+	buttons := l.TouchButtons()
+	if len(buttons) > 4 {
+		buttons = buttons[:4]
+	}
+	for i, b := range buttons {
+		w := loupedeck.NewWatchedInt(0)
+		w.AddWatcher(printer(fmt.Sprintf("button %d", i+1)))
+		m := l.NewMultiButton(w, b, ims[0], 0)
+		for j := 1; j < len(ims); j++ {
+			m.Add(ims[j], j)
+		}
+	}
+
+	if len(caps.Knobs) >= 2 {
+		level := loupedeck.NewWatchedInt(0)
+		level.AddWatcher(printer("level"))
+		k := l.IntKnob(caps.Knobs[0], 0, 100, level)
+		k.SetFineCoarse(10, 1)
+
+		mode := loupedeck.NewWatchedInt(0)
+		e := l.NewEnumKnob(caps.Knobs[1], []string{"sine", "square", "saw"}, mode)
+		e.AddWatcher(func(s string) { fmt.Printf("mode -> %s\n", s) })
+	}
+	return false, nil
+}
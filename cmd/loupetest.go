@@ -20,10 +20,10 @@ import (
 )
 
 func main() {
-	// Right now, the Loupedeck doesn't always respond if the
-	// previous run didn't shut down correctly.  Unsure why yet.
-	// Re-running fixes the problem.
-	fmt.Printf("Trying to connect to Loupedeck.  If this fails, try hitting ctrl-C and re-running.\n")
+	// The Loupedeck doesn't always respond if the previous run
+	// didn't shut down correctly; ConnectAuto resets the serial
+	// port before connecting to work around that.
+	fmt.Printf("Trying to connect to Loupedeck.\n")
 	l, err := loupedeck.ConnectAuto()
 	if err != nil {
 		panic(err)
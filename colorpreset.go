@@ -0,0 +1,96 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// ColorPreset adjusts colors as images are converted for the
+// Loupedeck's displays.  The panels are fairly over-saturated,
+// especially in the reds, which can make status colors hard to tell
+// apart; a preset can compensate for that without needing a full
+// color profile.
+type ColorPreset struct {
+	Name string
+	// Saturation scales how far each color is from gray.  1
+	// leaves colors alone, 0 gives grayscale, and values above 1
+	// make colors more vivid.
+	Saturation float64
+	// Brightness scales each channel after the saturation
+	// adjustment.
+	Brightness float64
+	// Red scales the red channel on its own, to tame the panels'
+	// reds.
+	Red float64
+}
+
+// ColorPresets holds the built-in presets, keyed by name.
+// Applications may add their own.
+var ColorPresets = map[string]*ColorPreset{
+	"natural": {Name: "natural", Saturation: 1, Brightness: 1, Red: 1},
+	"vivid":   {Name: "vivid", Saturation: 1.3, Brightness: 1, Red: 1},
+	"muted":   {Name: "muted", Saturation: 0.7, Brightness: 0.95, Red: 0.9},
+	"night":   {Name: "night", Saturation: 0.8, Brightness: 0.45, Red: 1},
+}
+
+// SetColorPreset selects one of the ColorPresets by name.  Everything
+// drawn afterwards is adjusted by the preset; if mirroring is
+// enabled, the displays are redrawn right away so that the change is
+// visible everywhere.
+func (l *Loupedeck) SetColorPreset(name string) error {
+	p := ColorPresets[name]
+	if p == nil {
+		names := make([]string, 0, len(ColorPresets))
+		for n := range ColorPresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown color preset %q, want one of %v", name, names)
+	}
+	if p.isIdentity() {
+		p = nil
+	}
+	l.colorPreset.Store(p)
+	l.replayMirrors()
+	return nil
+}
+
+// ColorPreset returns the name of the color preset in use.
+func (l *Loupedeck) ColorPreset() string {
+	p := l.colorPreset.Load()
+	if p == nil {
+		return "natural"
+	}
+	return p.Name
+}
+
+// isIdentity returns true if the preset doesn't change anything.
+func (p *ColorPreset) isIdentity() bool {
+	return p.Saturation == 1 && p.Brightness == 1 && p.Red == 1
+}
+
+// adjust applies the preset to a single color.
+func (p *ColorPreset) adjust(c color.Color) color.Color {
+	r16, g16, b16, _ := c.RGBA()
+	r := float64(r16 >> 8)
+	g := float64(g16 >> 8)
+	b := float64(b16 >> 8)
+
+	y := 0.299*r + 0.587*g + 0.114*b
+	r = (y + (r-y)*p.Saturation) * p.Brightness * p.Red
+	g = (y + (g-y)*p.Saturation) * p.Brightness
+	b = (y + (b-y)*p.Saturation) * p.Brightness
+
+	return color.RGBA{clamp8(r), clamp8(g), clamp8(b), 255}
+}
+
+func clamp8(v float64) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,98 @@
+package loupedeck
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// messageTypeNames names the MessageTypes, for logging.  It starts
+// with the commands in the foxxyz list (see the MessageType
+// constants); RegisterMessageType adds more.
+var messageTypeNames = struct {
+	sync.RWMutex
+	names map[MessageType]string
+}{names: map[MessageType]string{
+	ButtonPress:    "ButtonPress",
+	KnobRotate:     "KnobRotate",
+	SetColor:       "SetColor",
+	Serial:         "Serial",
+	Reset:          "Reset",
+	Version:        "Version",
+	SetBrightness:  "SetBrightness",
+	MCU:            "MCU",
+	Draw:           "Draw",
+	WriteFramebuff: "WriteFramebuff",
+	SetVibration:   "SetVibration",
+	Touch:          "Touch",
+	TouchCT:        "TouchCT",
+	TouchEnd:       "TouchEnd",
+	TouchEndCT:     "TouchEndCT",
+}}
+
+// String returns the name of the MessageType, or its number if it
+// doesn't have one.
+func (t MessageType) String() string {
+	messageTypeNames.RLock()
+	defer messageTypeNames.RUnlock()
+	if n, ok := messageTypeNames.names[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("MessageType(0x%02x)", byte(t))
+}
+
+// RegisterMessageType gives a name to a MessageType that the library
+// doesn't know about, for experimenting with commands that newer
+// firmware supports.  Messages of registered types are logged with
+// their names, and replies to them that arrive without a transaction
+// ID aren't reported as unknown (see UnknownEvents).  Commands can be
+// sent with NewMessage and SendAndWait.
+func RegisterMessageType(t MessageType, name string) {
+	messageTypeNames.Lock()
+	defer messageTypeNames.Unlock()
+	messageTypeNames.names[t] = name
+}
+
+// knownMessageType returns true if t has a name.
+func knownMessageType(t MessageType) bool {
+	messageTypeNames.RLock()
+	defer messageTypeNames.RUnlock()
+	_, ok := messageTypeNames.names[t]
+	return ok
+}
+
+// QueryVersion asks the Loupedeck for its firmware version, and waits
+// up to timeout for the answer.  Listen needs to be running.  The
+// version reported when connecting is in the Version field.
+func (l *Loupedeck) QueryVersion(timeout time.Duration) (string, error) {
+	m, err := l.SendAndWait(l.NewMessage(Version, []byte{}), timeout)
+	if err != nil {
+		return "", err
+	}
+	if len(m.data) < 3 {
+		return "", fmt.Errorf("short Version response: %v", m.data)
+	}
+	return fmt.Sprintf("%d.%d.%d", m.data[0], m.data[1], m.data[2]), nil
+}
+
+// QuerySerial asks the Loupedeck for its serial number, and waits up
+// to timeout for the answer.  Listen needs to be running.  The serial
+// number reported when connecting is in the SerialNo field.
+func (l *Loupedeck) QuerySerial(timeout time.Duration) (string, error) {
+	m, err := l.SendAndWait(l.NewMessage(Serial, []byte{}), timeout)
+	if err != nil {
+		return "", err
+	}
+	return string(m.data), nil
+}
+
+// QueryMCU asks the Loupedeck about its microcontroller, and waits up
+// to timeout for the answer.  Listen needs to be running.  What the
+// response means isn't known, so it's returned raw.
+func (l *Loupedeck) QueryMCU(timeout time.Duration) ([]byte, error) {
+	m, err := l.SendAndWait(l.NewMessage(MCU, []byte{}), timeout)
+	if err != nil {
+		return nil, err
+	}
+	return m.data, nil
+}
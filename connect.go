@@ -1,8 +1,11 @@
 package loupedeck
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"image/color"
 	"log/slog"
 	"net"
 	"net/http"
@@ -13,24 +16,70 @@ import (
 // the first USB Loupedeck device in the system.  If you have more
 // than one device and want to connect to a specific one, then use
 // ConnectPath().
+//
+// If the only Loupedecks found are in use by other processes, it
+// returns an error wrapping ErrDeviceInUse.
+//
+// A Loupedeck+ only shows up as a HID device, and isn't detected
+// automatically, since HID support is experimental.  Opt in with
+// Connect and WithExperimentalHID, giving the Loupedeck+'s USB product
+// ID.
 func ConnectAuto() (*Loupedeck, error) {
+	return ConnectAutoContext(context.Background())
+}
+
+// ConnectAutoContext is like ConnectAuto, but gives up and returns
+// ctx's error if ctx is cancelled or its deadline passes before the
+// connection is made.
+func ConnectAutoContext(ctx context.Context) (*Loupedeck, error) {
+	return connectAuto(ctx, newConnectOptions(nil))
+}
+
+func connectAuto(ctx context.Context, o *connectOptions) (*Loupedeck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	c, err := ConnectSerialAuto()
-	if err != nil {
+	if errors.Is(err, ErrDeviceInUse) {
 		return nil, err
 	}
+	if err != nil {
+		if o.hidProduct == "" {
+			return nil, err
+		}
+		path, vendor, product, hidErr := findHIDDevice(o.hidProduct)
+		if hidErr != nil {
+			return nil, err
+		}
+		return connectHID(path, vendor, product, o)
+	}
 
-	return tryConnect(c)
+	return tryConnect(ctx, c, o)
 }
 
 // ConnectPath connects to a Loupedeck Live via a specified serial
 // device.  If successful it returns a new Loupedeck.
 func ConnectPath(serialPath string) (*Loupedeck, error) {
+	return ConnectPathContext(context.Background(), serialPath)
+}
+
+// ConnectPathContext is like ConnectPath, but gives up and returns
+// ctx's error if ctx is cancelled or its deadline passes before the
+// connection is made.
+func ConnectPathContext(ctx context.Context, serialPath string) (*Loupedeck, error) {
+	return connectPath(ctx, serialPath, newConnectOptions(nil))
+}
+
+func connectPath(ctx context.Context, serialPath string, o *connectOptions) (*Loupedeck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	c, err := ConnectSerialPath(serialPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return tryConnect(c)
+	return tryConnect(ctx, c, o)
 }
 
 type connectResult struct {
@@ -48,33 +97,98 @@ type connectResult struct {
 // This is a painful workaround for that.  It uses the generic Go
 // pattern for implementing a timeout (do the "real work" in a
 // goroutine, feeding answers to a channel, and then add a timeout on
-// select).  If the timeout triggers, then it tries a second time to
-// connect.  This has a 100% success rate for me.
+// select).  If the timeout triggers, then it tries again to connect.
+// One retry has a 100% success rate for me; see WithRetries and
+// WithHandshakeTimeout for changing this.  The last attempt waits for
+// as long as ctx allows.
+//
+// Before each attempt, the serial lines are reset and the buffers are
+// flushed (see SerialWebSockConn.ResetLines), which gets a device
+// that was left stuck by a crashed program to answer the first
+// handshake.
+//
+// If ctx is done first, the serial port is closed (which unsticks any
+// attempt that's still waiting) and ctx's error is returned.  The
+// port is closed after a failed attempt, too, so that it (and its
+// lock) can be opened again.
 //
 // The actual connection logic is all in doConnect(), below.
-func tryConnect(c *SerialWebSockConn) (*Loupedeck, error) {
-	result := make(chan connectResult, 1)
-	go func() {
-		r := connectResult{}
-		r.l, r.err = doConnect(c)
-		result <- r
-	}()
-
-	select {
-	case <-time.After(2 * time.Second):
-		// timeout
-		slog.Info("Timeout! Trying again without timeout.")
-		return doConnect(c)
-
-	case result := <-result:
-		return result.l, result.err
+func tryConnect(ctx context.Context, c *SerialWebSockConn, o *connectOptions) (*Loupedeck, error) {
+	attempt := func() <-chan connectResult {
+		if o.portReset {
+			if err := c.ResetLines(); err != nil {
+				o.log().Warn("Unable to reset serial port", "err", err)
+			}
+		}
+		result := make(chan connectResult, 1)
+		go func() {
+			r := connectResult{}
+			r.l, r.err = doConnect(c, o)
+			result <- r
+		}()
+		return result
+	}
+
+	handshakeTimeout := o.handshakeTimeout
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+
+	for i := 0; ; i++ {
+		var timeout <-chan time.Time
+		if i < o.retries {
+			timeout = time.After(handshakeTimeout)
+		}
+
+		select {
+		case <-timeout:
+			o.log().Info("Timeout! Trying again.", "attempt", i+1)
+
+		case result := <-attempt():
+			if result.err != nil {
+				// Release the port and its lock, so that
+				// the caller can try again.
+				c.Close()
+				return nil, result.err
+			}
+			return result.l, nil
+
+		case <-ctx.Done():
+			c.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newLoupedeck creates a Loupedeck that talks over conn.
+func newLoupedeck(conn messageConn, serial *SerialWebSockConn, vendor, product string) *Loupedeck {
+	return &Loupedeck{
+		conn:                 conn,
+		serial:               serial,
+		buttonBindings:       make(map[Button]ButtonFunc),
+		buttonUpBindings:     make(map[Button]ButtonFunc),
+		knobBindings:         make(map[Knob]KnobFunc),
+		touchBindings:        make(map[TouchButton]TouchFunc),
+		touchUpBindings:      make(map[TouchButton]TouchFunc),
+		Vendor:               vendor,
+		Product:              product,
+		transactionCallbacks: map[byte]transactionCallback{},
+		displays:             map[string]*Display{},
+		displayAliases:       map[string]string{},
+		regions:              map[string]*Region{},
+		cache:                newImageCache(),
+		errors:               make(chan error, errorChannelSize),
+		buttonColors:         map[Button]color.RGBA{},
+		theme:                DefaultTheme,
+		serialReceived:       make(chan struct{}),
 	}
 }
 
-func doConnect(c *SerialWebSockConn) (*Loupedeck, error) {
+// dialWebsocket opens a websocket connection over the serial port.
+func dialWebsocket(c *SerialWebSockConn, log *slog.Logger) (*websocket.Conn, error) {
 	dialer := websocket.Dialer{
 		NetDial: func(network, addr string) (net.Conn, error) {
-			slog.Info("Dialing...")
+			log.Info("Dialing...")
 			return c, nil
 		},
 		HandshakeTimeout: 1 * time.Second,
@@ -82,74 +196,92 @@ func doConnect(c *SerialWebSockConn) (*Loupedeck, error) {
 
 	header := http.Header{}
 
-	slog.Info("Attempting to open websocket connection")
+	log.Info("Attempting to open websocket connection")
 	conn, resp, err := dialer.Dial("ws://fake", header)
 
 	if err != nil {
-		slog.Warn("dial failed", "err", err)
+		log.Warn("dial failed", "err", err)
 		return nil, err
 	}
 
-	slog.Info("Connect successful", "resp", resp)
+	log.Info("Connect successful", "resp", resp)
+	return conn, nil
+}
 
-	l := &Loupedeck{
-		conn:                 conn,
-		serial:               c,
-		buttonBindings:       make(map[Button]ButtonFunc),
-		buttonUpBindings:     make(map[Button]ButtonFunc),
-		knobBindings:         make(map[Knob]KnobFunc),
-		touchBindings:        make(map[TouchButton]TouchFunc),
-		touchUpBindings:      make(map[TouchButton]TouchFunc),
-		Vendor:               c.Vendor,
-		Product:              c.Product,
-		Model:                "foo",
-		transactionCallbacks: map[byte]transactionCallback{},
-		displays:             map[string]*Display{},
+func doConnect(c *SerialWebSockConn, o *connectOptions) (*Loupedeck, error) {
+	var conn messageConn
+	if o.native {
+		o.log().Info("Using native framing")
+		conn = newNativeConn(c)
+	} else {
+		ws, err := dialWebsocket(c, o.log())
+		if err != nil {
+			return nil, err
+		}
+		conn = ws
 	}
-	err = l.SetDefaultFont()
+
+	l := newLoupedeck(conn, c, c.Vendor, c.Product)
+	l.logger = o.logger
+	if err := l.initialize(o); err != nil {
+		l.stopWriter()
+		conn.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// initialize resets a newly connected Loupedeck and asks it about
+// itself.  It's the same for every transport.
+func (l *Loupedeck) initialize(o *connectOptions) error {
+	err := l.SetDefaultFont()
 	if err != nil {
-		return nil, fmt.Errorf("Unable to set default font: %v", err)
+		return fmt.Errorf("Unable to set default font: %v", err)
 	}
+	l.detectModel()
 
-	slog.Info("Found Loupedeck", "vendor", l.Vendor, "product", l.Product)
+	l.log().Info("Found Loupedeck", "vendor", l.Vendor, "product", l.Product, "model", l.Model)
 
-	slog.Info("Sending reset.")
 	data := make([]byte, 0)
-	m := l.NewMessage(Reset, data)
-	err = l.Send(m)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to send: %v", err)
+	if o.reset {
+		l.log().Info("Sending reset.")
+		m := l.NewMessage(Reset, data)
+		err = l.Send(m)
+		if err != nil {
+			return fmt.Errorf("Unable to send: %v", err)
+		}
 	}
 
-	slog.Info("Setting default brightness.")
-	data = []byte{9}
-	m = l.NewMessage(SetBrightness, data)
-	err = l.Send(m)
+	l.log().Info("Setting default brightness.")
+	err = l.SetBrightness(o.brightness)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to send: %v", err)
+		return fmt.Errorf("Unable to send: %v", err)
 	}
 
 	// Ask the device about itself.  The responses come back
 	// asynchronously, so we need to provide a callback.  Since
 	// `listen()` hasn't been called yet, we *have* to use
 	// callbacks, blocking via 'sendAndWait' isn't going to work.
-	m = l.NewMessage(Version, data)
+	m := l.NewMessage(Version, data)
 	err = l.SendWithCallback(m, func(m *Message) {
 		l.Version = fmt.Sprintf("%d.%d.%d", m.data[0], m.data[1], m.data[2])
-		slog.Info("Received 'Version' response", "version", l.Version)
+		l.log().Info("Received 'Version' response", "version", l.Version)
+		l.detectModel()
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Unable to send: %v", err)
+		return fmt.Errorf("Unable to send: %v", err)
 	}
 
 	m = l.NewMessage(Serial, data)
 	err = l.SendWithCallback(m, func(m *Message) {
 		l.SerialNo = string(m.data)
-		slog.Info("Received 'Serial' response", "serial", l.SerialNo)
+		l.log().Info("Received 'Serial' response", "serial", l.SerialNo)
+		l.detectModel()
+		close(l.serialReceived)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Unable to send: %v", err)
+		return fmt.Errorf("Unable to send: %v", err)
 	}
 
-	return l, nil
+	return nil
 }
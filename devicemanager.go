@@ -0,0 +1,171 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// serialTimeout is how long DeviceManager waits for a Loupedeck to
+// report its serial number after connecting.
+const serialTimeout = 2 * time.Second
+
+// DeviceManager connects to every Loupedeck attached to the system,
+// so that programs can use more than one at a time.  Each Loupedeck
+// gets its own Listen loop, and bindings, displays, and so on are all
+// per-device, exactly as if each had been connected with ConnectPath.
+// Devices are addressed by their serial number, which (unlike the
+// serial device path) doesn't change when devices are unplugged and
+// plugged back in.
+type DeviceManager struct {
+	mutex   sync.Mutex
+	devices map[string]*Loupedeck // by serial number
+	paths   map[string]string     // serial device path to serial number
+}
+
+// NewDeviceManager creates a new DeviceManager.  Call ConnectAll to
+// connect to devices.
+func NewDeviceManager() *DeviceManager {
+	return &DeviceManager{
+		devices: map[string]*Loupedeck{},
+		paths:   map[string]string{},
+	}
+}
+
+// ConnectAll connects to all of the Loupedecks in the system that
+// aren't already connected, starts a Listen loop for each, and calls
+// SetDisplays for them.  Devices that are in use by other processes
+// are skipped.  It can be called again later to pick up devices that
+// have been plugged in since.
+//
+// It returns the serial numbers of the newly connected devices.  If
+// some devices fail to connect, the rest are still connected and
+// the errors are returned together.
+func (m *DeviceManager) ConnectAll() ([]string, error) {
+	paths, err := SerialPorts()
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	var errs []error
+	for _, path := range paths {
+		m.mutex.Lock()
+		_, known := m.paths[path]
+		m.mutex.Unlock()
+		if known {
+			continue
+		}
+
+		serial, err := m.connect(path)
+		if errors.Is(err, ErrDeviceInUse) {
+			slog.Info("Skipping Loupedeck in use by another process", "path", path)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		added = append(added, serial)
+	}
+	return added, errors.Join(errs...)
+}
+
+// connect connects to a single Loupedeck and waits for it to report
+// its serial number.
+func (m *DeviceManager) connect(path string) (string, error) {
+	l, err := ConnectPath(path)
+	if err != nil {
+		return "", err
+	}
+	go l.Listen()
+
+	select {
+	case <-l.serialReceived:
+	case <-time.After(serialTimeout):
+		l.Close()
+		return "", fmt.Errorf("timed out waiting for serial number")
+	}
+
+	if err := l.SetDisplays(); err != nil {
+		l.Close()
+		return "", err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if old := m.devices[l.SerialNo]; old != nil {
+		// Probably the same device, at a new path after being
+		// replugged.
+		old.Close()
+		for p, s := range m.paths {
+			if s == l.SerialNo {
+				delete(m.paths, p)
+			}
+		}
+	}
+	m.devices[l.SerialNo] = l
+	m.paths[path] = l.SerialNo
+	slog.Info("Connected Loupedeck", "serial", l.SerialNo, "path", path, "model", l.Model)
+	return l.SerialNo, nil
+}
+
+// Device returns the connected Loupedeck with the specified serial
+// number, or nil if there isn't one.
+func (m *DeviceManager) Device(serial string) *Loupedeck {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.devices[serial]
+}
+
+// SerialNumbers returns the serial numbers of all connected
+// Loupedecks, sorted.
+func (m *DeviceManager) SerialNumbers() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	serials := make([]string, 0, len(m.devices))
+	for s := range m.devices {
+		serials = append(serials, s)
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// Devices returns all connected Loupedecks, sorted by serial number.
+func (m *DeviceManager) Devices() []*Loupedeck {
+	var devices []*Loupedeck
+	for _, s := range m.SerialNumbers() {
+		if l := m.Device(s); l != nil {
+			devices = append(devices, l)
+		}
+	}
+	return devices
+}
+
+// Disconnect closes the connection to the Loupedeck with the
+// specified serial number, and forgets about it.
+func (m *DeviceManager) Disconnect(serial string) {
+	m.mutex.Lock()
+	l := m.devices[serial]
+	delete(m.devices, serial)
+	for p, s := range m.paths {
+		if s == serial {
+			delete(m.paths, p)
+		}
+	}
+	m.mutex.Unlock()
+
+	if l != nil {
+		l.Close()
+	}
+}
+
+// Close closes the connections to all of the Loupedecks.
+func (m *DeviceManager) Close() {
+	for _, s := range m.SerialNumbers() {
+		m.Disconnect(s)
+	}
+}
@@ -0,0 +1,96 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Round returns true if the display is round, like the dial screen in
+// the middle of the Loupedeck CT's big knob.  Round displays are
+// addressed as squares, but only the pixels inside the inscribed
+// circle can be seen.
+func (d *Display) Round() bool {
+	return d.Name == "dial"
+}
+
+// VisibleSpan returns the range of columns, from x0 up to but not
+// including x1, that can actually be seen in row y of the display.
+// For round displays, this is the chord of the visible circle at that
+// row, which is 2*sqrt(r^2-(r-y)^2) pixels wide; for rectangular
+// displays, it's the whole row.  Rows outside the display have an
+// empty span.
+func (d *Display) VisibleSpan(y int) (x0, x1 int) {
+	if y < 0 || y >= d.height {
+		return 0, 0
+	}
+	if !d.Round() {
+		return 0, d.width
+	}
+	return circleSpan(d.width, d.height, y)
+}
+
+// VisibleWidth returns the number of pixels that can be seen in row y
+// of the display.  See VisibleSpan.
+func (d *Display) VisibleWidth(y int) int {
+	x0, x1 := d.VisibleSpan(y)
+	return x1 - x0
+}
+
+// circleSpan returns the columns of row y that fall inside the circle
+// (or ellipse) inscribed in a w by h rectangle.  Pixels count as
+// inside if their centers are.
+func circleSpan(w, h, y int) (x0, x1 int) {
+	rx, ry := float64(w)/2, float64(h)/2
+	dy := (float64(y) + 0.5 - ry) / ry
+	if dy*dy >= 1 {
+		return 0, 0
+	}
+	half := rx * math.Sqrt(1-dy*dy)
+	x0 = int(math.Ceil(rx - half - 0.5))
+	x1 = int(math.Floor(rx+half-0.5)) + 1
+	if x0 < 0 {
+		x0 = 0
+	}
+	if x1 > w {
+		x1 = w
+	}
+	if x1 < x0 {
+		x1 = x0
+	}
+	return x0, x1
+}
+
+// ClipToVisible returns a copy of im, which is to be drawn at xoff,
+// yoff on the display, with every pixel that can't be seen replaced
+// by bg.  On round displays, this shows exactly what will be visible,
+// which helps when laying out widgets; it also makes images compress
+// and compare better, since hidden pixels no longer vary.  For
+// rectangular displays, it's just a copy.
+func (d *Display) ClipToVisible(im image.Image, xoff, yoff int, bg color.Color) *image.RGBA {
+	b := im.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), im, b.Min, draw.Src)
+	if !d.Round() {
+		return out
+	}
+
+	bgc := color.RGBAModel.Convert(bg).(color.RGBA)
+	for y := 0; y < b.Dy(); y++ {
+		x0, x1 := d.VisibleSpan(y + yoff)
+		for x := 0; x < b.Dx(); x++ {
+			if dx := x + xoff; dx < x0 || dx >= x1 {
+				out.SetRGBA(x, y, bgc)
+			}
+		}
+	}
+	return out
+}
+
+// DrawClipped draws an image onto the display, like Draw, but with
+// the parts that can't be seen replaced by the theme's background
+// color.  See ClipToVisible.
+func (d *Display) DrawClipped(im image.Image, xoff, yoff int) {
+	d.Draw(d.ClipToVisible(im, xoff, yoff, d.loupedeck.theme.Background), xoff, yoff)
+}
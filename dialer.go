@@ -7,6 +7,7 @@ import (
 	"go.bug.st/serial/enumerator"
 	"log/slog"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -23,6 +24,8 @@ type SerialWebSockConn struct {
 	Name            string
 	Port            serial.Port
 	Vendor, Product string
+	lock            *deviceLock
+	closeOnce       sync.Once
 }
 
 // Read reads bytes from the connected serial port.
@@ -39,9 +42,19 @@ func (l *SerialWebSockConn) Write(b []byte) (n int, err error) {
 	return l.Port.Write(b)
 }
 
-// Close closed the connection.
+// Close closes the serial port, and releases our lock on the serial
+// device so that other processes can use it.  Calling Close more than
+// once is harmless; the websocket library closes its underlying
+// connection too.
 func (l *SerialWebSockConn) Close() error {
-	return nil // l.Port.Close()
+	var err error
+	l.closeOnce.Do(func() {
+		if l.Port != nil {
+			err = l.Port.Close()
+		}
+		l.lock.unlock()
+	})
+	return err
 }
 
 // LocalAddr is needed for Gorilla compatibility, but doesn't actually
@@ -74,8 +87,32 @@ func (l *SerialWebSockConn) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+// isLoupedeckPort returns true if port looks like a Loupedeck (or a
+// Razer Stream Controller, which is a rebadged Loupedeck).
+func isLoupedeckPort(port *enumerator.PortDetails) bool {
+	return port.IsUSB && (port.VID == "2ec2" || port.VID == "1532")
+}
+
+// SerialPorts returns the paths of the serial devices for all
+// Loupedecks in the system, whether or not they're in use.  Pass them
+// to ConnectPath to connect to a specific Loupedeck.
+func SerialPorts() ([]string, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, port := range ports {
+		if isLoupedeckPort(port) {
+			paths = append(paths, port.Name)
+		}
+	}
+	return paths, nil
+}
+
 // ConnectSerialAuto connects to the first compatible Loupedeck in the
-// system.  To connect to a specific Loupedeck, use ConnectSerialPath.
+// system that isn't already in use by another process.  To connect to
+// a specific Loupedeck, use ConnectSerialPath.
 func ConnectSerialAuto() (*SerialWebSockConn, error) {
 	slog.Info("Enumerating ports")
 
@@ -87,11 +124,19 @@ func ConnectSerialAuto() (*SerialWebSockConn, error) {
 		return nil, fmt.Errorf("no serial ports found")
 	}
 
+	var busy error
 	for _, port := range ports {
 		slog.Info("Trying to open port", "port", port.Name)
-		if port.IsUSB && (port.VID == "2ec2" || port.VID == "1532") {
+		if isLoupedeckPort(port) {
+			lock, err := lockDevice(port.Name)
+			if err != nil {
+				slog.Info("Skipping port", "port", port.Name, "err", err)
+				busy = err
+				continue
+			}
 			p, err := serial.Open(port.Name, &serial.Mode{})
 			if err != nil {
+				lock.unlock()
 				return nil, fmt.Errorf("unable to open port %q", port.Name)
 			}
 			conn := &SerialWebSockConn{
@@ -99,24 +144,45 @@ func ConnectSerialAuto() (*SerialWebSockConn, error) {
 				Port:    p,
 				Vendor:  port.VID,
 				Product: port.PID,
+				lock:    lock,
 			}
 			return conn, nil
 		}
 	}
 
+	if busy != nil {
+		return nil, busy
+	}
 	return nil, fmt.Errorf("no Loupedeck devices found")
 }
 
 // ConnectSerialPath connects to a specific Loupedeck, using the path
-// to the USB serial device as a key.
+// to the USB serial device as a key.  It returns an error wrapping
+// ErrDeviceInUse if another process is already connected to it.
 func ConnectSerialPath(serialPath string) (*SerialWebSockConn, error) {
+	lock, err := lockDevice(serialPath)
+	if err != nil {
+		return nil, err
+	}
 	p, err := serial.Open(serialPath, &serial.Mode{})
 	if err != nil {
+		lock.unlock()
 		return nil, fmt.Errorf("Unable to open serial device %q", serialPath)
 	}
 	conn := &SerialWebSockConn{
 		Name: serialPath,
 		Port: p,
+		lock: lock,
+	}
+
+	// Look up the USB IDs, so that we know which model this is.
+	if ports, err := enumerator.GetDetailedPortsList(); err == nil {
+		for _, port := range ports {
+			if port.Name == serialPath && port.IsUSB {
+				conn.Vendor = port.VID
+				conn.Product = port.PID
+			}
+		}
 	}
 
 	return conn, nil
@@ -0,0 +1,82 @@
+package loupedeck
+
+import (
+	"image/color"
+)
+
+var (
+	colorConfirm = color.RGBA{0, 160, 0, 255}
+	colorCancel  = color.RGBA{160, 0, 0, 255}
+)
+
+// ConfirmFunc is called when a confirmation dialog is dismissed.
+// The parameter is true if the user chose Confirm, and false if they
+// chose Cancel.
+type ConfirmFunc func(confirmed bool)
+
+// ShowConfirm shows a modal confirmation dialog on the main display,
+// with a message across the top two rows and Cancel (left) and
+// Confirm (right) touch targets across the bottom row.  This is
+// intended for guarding destructive actions, like turning off all of
+// the lights at once.
+//
+// While the dialog is visible, all other bindings (buttons, knobs,
+// and touches) and control mappings (see MapControl) are suspended.  Once the user touches Cancel or
+// Confirm, the previous bindings are restored, the main display is
+// restored (if mirroring is enabled; see SetMirroring, otherwise it's
+// cleared), and f is called.
+//
+// ShowConfirm returns immediately; it doesn't wait for the user.
+func (l *Loupedeck) ShowConfirm(message string, f ConfirmFunc) error {
+	d := l.GetDisplay("main")
+	if d == nil {
+		return nil
+	}
+
+	cols, rows := l.GridSize()
+	cell := l.touchGrid().CellSize
+	top := (rows - 1) * cell
+
+	// The bottom row is split in half, along cell boundaries so
+	// that what's drawn matches what's touched.  With an odd number
+	// of columns, the middle button counts as Cancel, to be safe.
+	cancelCols := (cols + 1) / 2
+	split := cancelCols * cell
+
+	msg, err := l.TextInBox(d.Width(), top, message, l.theme.Text, l.theme.Background)
+	if err != nil {
+		return err
+	}
+	cancel, err := l.TextInBox(split, cell, "Cancel", color.White, colorCancel)
+	if err != nil {
+		return err
+	}
+	confirm, err := l.TextInBox((cols-cancelCols)*cell, cell, "Confirm", color.White, colorConfirm)
+	if err != nil {
+		return err
+	}
+
+	saved := l.Snapshot(d)
+	bindings := l.suspendBindings()
+
+	dismiss := func(confirmed bool) {
+		l.restoreBindings(bindings)
+		if saved == nil {
+			saved = blankImage(d.Width(), d.Height())
+		}
+		d.Draw(saved, 0, 0)
+		f(confirmed)
+	}
+
+	for col := 0; col < cols; col++ {
+		confirmed := col >= cancelCols
+		l.BindTouchUp(l.gridButton(col, rows-1), func(TouchButton, ButtonStatus, uint16, uint16) {
+			dismiss(confirmed)
+		})
+	}
+
+	d.Draw(msg, 0, 0)
+	d.Draw(cancel, 0, top)
+	d.Draw(confirm, split, top)
+	return nil
+}
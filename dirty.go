@@ -0,0 +1,182 @@
+package loupedeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"sync"
+)
+
+// shownSurface is a copy of what's been sent to one framebuffer, as
+// encoded pixels.
+type shownSurface struct {
+	bounds image.Rectangle
+	pix    []byte // 2 bytes per pixel, row by row
+	known  []bool // false for pixels that have never been sent
+}
+
+// shownState holds the shownSurfaces, for dirty-rectangle tracking.
+// See SetDirtyTracking.
+type shownState struct {
+	mutex    sync.Mutex
+	disabled bool
+	surfaces map[byte]*shownSurface
+	frames   map[frameKey]frameSum // see Display.Draw
+}
+
+// SetDirtyTracking enables or disables dirty-rectangle tracking,
+// which is enabled by default.  The library keeps a copy of what's
+// been sent to each display, and when something is drawn, only the
+// smallest rectangle that covers the pixels that actually changed is
+// sent.  Most widget updates only change a few digits, so this saves
+// most of the framebuffer traffic, and a draw that changes nothing
+// isn't sent at all.  Redrawing an image that's identical to the last
+// one drawn in the same place is skipped before it's even encoded.
+//
+// The copy is thrown away whenever the Loupedeck is reset, so that
+// the next draw is sent in full.  Disabling tracking is only needed
+// if something other than this library is drawing on the Loupedeck.
+func (l *Loupedeck) SetDirtyTracking(enabled bool) {
+	s := &l.shown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disabled = !enabled
+	s.surfaces = nil
+	s.frames = nil
+}
+
+// forgetShown forgets what's been sent to the framebuffer identified
+// by id, so that the next draw to it is sent in full.
+func (l *Loupedeck) forgetShown(id byte) {
+	s := &l.shown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.surfaces, id)
+	s.forgetFrames(id, image.Rect(0, 0, 0xffff, 0xffff))
+}
+
+// forgetAllShown forgets what's been sent to every framebuffer.
+func (l *Loupedeck) forgetAllShown() {
+	s := &l.shown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.surfaces = nil
+	s.frames = nil
+}
+
+// trimFramebuffer takes a WriteFramebuff payload and returns one
+// covering only the pixels that differ from what's already been sent,
+// recording the new pixels as sent.  It returns nil if nothing
+// changed.  Payloads that can't be tracked are returned unchanged.
+//
+// If frame isn't nil, it's recorded as the checksum of the image
+// being drawn; see sameFrame.
+func (l *Loupedeck) trimFramebuffer(data []byte, frame *frameSum) []byte {
+	if len(data) < framebufferHeaderLength {
+		return data
+	}
+	id := byte(binary.BigEndian.Uint16(data[0:]))
+	x := int(binary.BigEndian.Uint16(data[2:]))
+	y := int(binary.BigEndian.Uint16(data[4:]))
+	w := int(binary.BigEndian.Uint16(data[6:]))
+	h := int(binary.BigEndian.Uint16(data[8:]))
+	pixels := data[framebufferHeaderLength:]
+	area := image.Rect(x, y, x+w, y+h)
+
+	s := &l.shown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.disabled {
+		return data
+	}
+	surface := s.surfaces[id]
+	if surface == nil {
+		bounds := l.surfaceBounds(id)
+		surface = &shownSurface{
+			bounds: bounds,
+			pix:    make([]byte, 2*bounds.Dx()*bounds.Dy()),
+			known:  make([]bool, bounds.Dx()*bounds.Dy()),
+		}
+		if s.surfaces == nil {
+			s.surfaces = map[byte]*shownSurface{}
+		}
+		s.surfaces[id] = surface
+	}
+	if len(pixels) != 2*w*h || area.Empty() || !area.In(surface.bounds) {
+		// Not something we can keep track of, so it's no
+		// longer clear what's shown.
+		delete(s.surfaces, id)
+		s.forgetFrames(id, image.Rect(0, 0, 0xffff, 0xffff))
+		return data
+	}
+	defer func() {
+		if frame != nil {
+			if s.frames == nil {
+				s.frames = map[frameKey]frameSum{}
+			}
+			s.frames[frameKey{id: id, rect: area}] = *frame
+		}
+	}()
+
+	// Find the changed area, and record the new pixels.
+	changed := image.Rectangle{}
+	stride := surface.bounds.Dx()
+	for row := 0; row < h; row++ {
+		src := pixels[2*w*row : 2*w*(row+1)]
+		start := (y+row-surface.bounds.Min.Y)*stride + (x - surface.bounds.Min.X)
+		dst := surface.pix[2*start : 2*(start+w)]
+		known := surface.known[start : start+w]
+
+		first, last := -1, -1
+		if allTrue(known) && bytes.Equal(src, dst) {
+			continue
+		}
+		for i := 0; i < w; i++ {
+			if !known[i] || src[2*i] != dst[2*i] || src[2*i+1] != dst[2*i+1] {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		copy(dst, src)
+		for i := range known {
+			known[i] = true
+		}
+		changed = changed.Union(image.Rect(x+first, y+row, x+last+1, y+row+1))
+	}
+
+	if changed.Empty() {
+		return nil
+	}
+	s.forgetFrames(id, changed)
+	if changed == area {
+		return data
+	}
+
+	cw, ch := changed.Dx(), changed.Dy()
+	trimmed := make([]byte, framebufferHeaderLength, framebufferHeaderLength+2*cw*ch)
+	copy(trimmed, data[:2])
+	binary.BigEndian.PutUint16(trimmed[2:], uint16(changed.Min.X))
+	binary.BigEndian.PutUint16(trimmed[4:], uint16(changed.Min.Y))
+	binary.BigEndian.PutUint16(trimmed[6:], uint16(cw))
+	binary.BigEndian.PutUint16(trimmed[8:], uint16(ch))
+	for row := changed.Min.Y; row < changed.Max.Y; row++ {
+		offset := 2 * ((row-y)*w + (changed.Min.X - x))
+		trimmed = append(trimmed, pixels[offset:offset+2*cw]...)
+	}
+	return trimmed
+}
+
+// allTrue returns true if every element of b is true.
+func allTrue(b []bool) bool {
+	for _, v := range b {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
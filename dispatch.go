@@ -0,0 +1,142 @@
+package loupedeck
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// asyncQueueSize is the number of events that can be waiting for an
+// asynchronous control's handler before further events are dropped.
+const asyncQueueSize = 64
+
+// postQueueSize is the number of functions that can be waiting to run
+// on the Listen goroutine before further ones are dropped; see post.
+const postQueueSize = 64
+
+// PanicError is reported via Errors when a binding panics.  The panic
+// is recovered, so the rest of the bindings keep working.
+type PanicError struct {
+	// Control is the control whose binding panicked, or "" if the
+	// panic wasn't in a binding for a specific control.
+	Control string
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	if e.Control == "" {
+		return fmt.Sprintf("panic while handling input: %v", e.Value)
+	}
+	return fmt.Sprintf("panic in binding for %s: %v", e.Control, e.Value)
+}
+
+// dispatchState holds the controls whose handlers run asynchronously,
+// and functions waiting to run on the Listen goroutine.
+type dispatchState struct {
+	mutex    sync.Mutex
+	workers  map[Control]chan func()
+	postOnce sync.Once
+	posted   chan func()
+}
+
+// SetAsync makes the bindings for a control run on their own
+// goroutine, rather than on the Listen goroutine, so that a slow
+// handler (one that makes a network request, say) doesn't hold up
+// input from every other control.  Events for the control are still
+// handled one at a time, in order.  If the handler falls more than 64
+// events behind, further events are dropped and reported via Errors.
+//
+// Asynchronous handlers run at the same time as everything else, so
+// they need to be careful about what they touch.
+func (l *Loupedeck) SetAsync(c Control, async bool) {
+	s := &l.dispatch
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.workers == nil {
+		s.workers = map[Control]chan func(){}
+	}
+	if w := s.workers[c]; w != nil && !async {
+		close(w)
+		delete(s.workers, c)
+	}
+	if async && s.workers[c] == nil {
+		w := make(chan func(), asyncQueueSize)
+		s.workers[c] = w
+		go func() {
+			for f := range w {
+				l.safeCall(c.String(), f)
+			}
+		}()
+	}
+}
+
+// stopAsync stops all of the goroutines started by SetAsync.
+func (l *Loupedeck) stopAsync() {
+	s := &l.dispatch
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for c, w := range s.workers {
+		close(w)
+		delete(s.workers, c)
+	}
+}
+
+// call runs a binding for a control, either right away or on the
+// control's own goroutine (see SetAsync), recovering from any panic.
+func (l *Loupedeck) call(c Control, f func()) {
+	s := &l.dispatch
+	s.mutex.Lock()
+	w := s.workers[c]
+	if w != nil {
+		select {
+		case w <- f:
+		default:
+			l.reportError(fmt.Errorf("handler for %s is too slow, dropping event", c))
+		}
+		s.mutex.Unlock()
+		return
+	}
+	s.mutex.Unlock()
+	l.safeCall(c.String(), f)
+}
+
+// safeCall runs f, turning any panic into a PanicError that's reported
+// via Errors.
+func (l *Loupedeck) safeCall(control string, f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.reportError(&PanicError{Control: control, Value: r, Stack: debug.Stack()})
+		}
+	}()
+	f()
+}
+
+// postQueue returns the channel of functions waiting to run on the
+// Listen goroutine.
+func (l *Loupedeck) postQueue() chan func() {
+	s := &l.dispatch
+	s.postOnce.Do(func() {
+		s.posted = make(chan func(), postQueueSize)
+	})
+	return s.posted
+}
+
+// post runs f on the Listen goroutine, between input events, so that
+// it can safely touch bindings, Pagers, and widgets that are
+// otherwise only used from there.  Timers and other background
+// goroutines use this to act on the UI.  If Listen isn't running, f
+// runs right away instead.
+func (l *Loupedeck) post(f func()) {
+	if !l.listening.Load() {
+		l.safeCall("", f)
+		return
+	}
+	select {
+	case l.postQueue() <- f:
+	default:
+		l.reportError(fmt.Errorf("Listen is too slow, dropping posted callback"))
+	}
+}
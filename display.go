@@ -2,9 +2,10 @@ package loupedeck
 
 import (
 	"encoding/binary"
+	"fmt"
 	"image"
-	"log/slog"
 	"maze.io/x/pixel/pixelcolor"
+	"sort"
 	// "time"
 )
 
@@ -16,7 +17,7 @@ type Display struct {
 	width, height    int
 	offsetx, offsety int // used for mapping legacy left/center/right screens onto unified devices.
 	Name             string
-	bigEndian        bool
+	format           PixelFormat
 }
 
 // GetDisplay returns a Display object with a given name if it exists,
@@ -38,11 +39,66 @@ type Display struct {
 //   - main (on all devices, emulated on newer hardware)
 //   - dial (Loupedeck CT only)
 //   - main (only on newer hardware)
+//
+// The Loupedeck+ has no displays at all, so GetDisplay always returns
+// nil for it.
+//
+// Names added with AddDisplayAlias are also accepted.
 func (l *Loupedeck) GetDisplay(name string) *Display {
-	return l.displays[name]
+	if d := l.displays[name]; d != nil {
+		return d
+	}
+	return l.displays[l.displayAliases[name]]
+}
+
+// Displays returns all of the Loupedeck's displays, sorted by name.
+// Aliases aren't included, so each Display appears once.
+//
+// On devices with a single unified display, the emulated left, main,
+// and right displays overlap the "all" display, so generic code that
+// draws to every Display will draw some pixels more than once.
+func (l *Loupedeck) Displays() []*Display {
+	names := make([]string, 0, len(l.displays))
+	for name := range l.displays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	displays := make([]*Display, 0, len(names))
+	for _, name := range names {
+		displays = append(displays, l.displays[name])
+	}
+	return displays
 }
 
-func (l *Loupedeck) addDisplay(name string, id byte, width, height, offsetx, offsety int, bigEndian bool) {
+// AddDisplayAlias adds another name for an existing display, so
+// applications can use their own names (like "meters") for displays
+// without caring which device they're running on.  It returns an
+// error if the display doesn't exist or if alias is already the name
+// of a real display.
+func (l *Loupedeck) AddDisplayAlias(alias, name string) error {
+	if l.displays[alias] != nil {
+		return fmt.Errorf("display %q already exists", alias)
+	}
+	d := l.GetDisplay(name)
+	if d == nil {
+		return fmt.Errorf("unknown display %q", name)
+	}
+	l.displayAliases[alias] = d.Name
+	return nil
+}
+
+// DisplayAliases returns a map of aliases to the names of the
+// displays that they refer to.
+func (l *Loupedeck) DisplayAliases() map[string]string {
+	aliases := make(map[string]string, len(l.displayAliases))
+	for alias, name := range l.displayAliases {
+		aliases[alias] = name
+	}
+	return aliases
+}
+
+func (l *Loupedeck) addDisplay(name string, id byte, width, height, offsetx, offsety int, format PixelFormat) {
 	d := &Display{
 		loupedeck: l,
 		Name:      name,
@@ -51,42 +107,33 @@ func (l *Loupedeck) addDisplay(name string, id byte, width, height, offsetx, off
 		height:    height,
 		offsetx:   offsetx,
 		offsety:   offsety,
-		bigEndian: bigEndian,
+		format:    format,
 	}
 	l.displays[name] = d
 }
 
 // SetDisplays configures the Loupdeck's displays based on the
-// hardware ID of the conencted device.
-func (l *Loupedeck) SetDisplays() {
-	switch l.Product {
-	case "0003":
-		slog.Info("Using Loupedeck CT v1 display settings.")
-		l.addDisplay("left", 'L', 60, 270, 0, 0, false)
-		l.addDisplay("main", 'A', 360, 270, 60, 0, false)
-		l.addDisplay("right", 'R', 60, 270, 420, 0, false)
-		l.addDisplay("dial", 'W', 240, 240, 0, 0, true)
-	case "0007":
-		slog.Info("Using Loupedeck CT v2 display settings.")
-		l.addDisplay("left", 'M', 60, 270, 0, 0, false)
-		l.addDisplay("main", 'M', 360, 270, 60, 0, false)
-		l.addDisplay("right", 'M', 60, 270, 420, 0, false)
-		l.addDisplay("all", 'M', 480, 270, 0, 0, false) // Same as left+main+right
-		l.addDisplay("dial", 'W', 240, 240, 0, 0, true)
-	case "0004":
-		slog.Info("Using Loupedeck Live display settings.")
-		l.addDisplay("left", 'L', 60, 270, 0, 0, false)
-		l.addDisplay("main", 'A', 360, 270, 0, 0, false)
-		l.addDisplay("right", 'R', 60, 270, 0, 0, false)
-	case "0006", "0d06":
-		slog.Info("Using Loupedeck Live S/Razor Stream Controller display settings.")
-		l.addDisplay("left", 'M', 60, 270, 0, 0, false)
-		l.addDisplay("main", 'M', 360, 270, 60, 0, false)
-		l.addDisplay("right", 'M', 60, 270, 420, 0, false)
-		l.addDisplay("all", 'M', 480, 270, 0, 0, false) // Same as left+main+right
-	default:
-		panic("Unknown device type: " + l.Product)
+// hardware ID of the conencted device.  See DeviceProfile.  If the
+// device isn't recognized, it falls back to a generic profile; see
+// SafeMode.
+func (l *Loupedeck) SetDisplays() error {
+	if l.hid {
+		// HID devices don't have displays, but the profile is
+		// still useful for its button and knob maps.
+		l.applyProfile(loupedeckPlusProfile)
+		l.addRegions()
+		return nil
+	}
+	p := deviceProfiles[l.Product]
+	if p == nil {
+		// Let people with new hardware experiment, instead of
+		// giving up.  See SafeMode.
+		l.enterSafeMode()
+		return nil
 	}
+	l.applyProfile(p)
+	l.addRegions()
+	return nil
 }
 
 // Height returns the height (in pixels) of the Loupedeck's displays.
@@ -108,70 +155,223 @@ func (d *Display) Width() int {
 // button's worth of image, and will not touch other pixels.
 //
 // Most Loupedeck screens are little-endian, except for the knob
-// screen on the Loupedeck CT, which is big-endian.  This does not
-// deal with this case correctly yet.
+// screen on the Loupedeck CT, which is big-endian.  See PixelFormat.
 func (d *Display) Draw(im image.Image, xoff, yoff int) {
-	slog.Info("Draw called", "Display", d.Name, "xoff", xoff, "yoff", yoff, "width", im.Bounds().Dx(), "height", im.Bounds().Dy())
+	d.loupedeck.log().Info("Draw called", "Display", d.Name, "xoff", xoff, "yoff", yoff, "width", im.Bounds().Dx(), "height", im.Bounds().Dy())
 
 	x := xoff + d.offsetx
 	y := yoff + d.offsety
 	width := im.Bounds().Dx()
 	height := im.Bounds().Dy()
-	slog.Info("Draw parameters", "x", x, "y", y, "width", width, "height", height)
+	d.loupedeck.log().Info("Draw parameters", "x", x, "y", y, "width", width, "height", height)
 
-	// Call 'WriteFramebuff'
-	data := make([]byte, 10)
-	binary.BigEndian.PutUint16(data[0:], uint16(d.id))
-	binary.BigEndian.PutUint16(data[2:], uint16(x))
-	binary.BigEndian.PutUint16(data[4:], uint16(y))
-	binary.BigEndian.PutUint16(data[6:], uint16(width))
-	binary.BigEndian.PutUint16(data[8:], uint16(height))
+	// Update the mirror and the device in the same order, even when
+	// several goroutines are drawing at once.
+	d.loupedeck.drawMutex.Lock()
+	defer d.loupedeck.drawMutex.Unlock()
+
+	d.updateMirror(im, x, y)
+	d.loupedeck.cache.invalidateRect(d.id, image.Rect(x, y, x+width, y+height))
+	if d.loupedeck.holdDraws.Load() {
+		return
+	}
+	im = d.applyBadges(im, x, y)
+
+	// Skip images that are already shown; see SetDirtyTracking.
+	preset := d.loupedeck.colorPreset.Load()
+	sum, ok := frameFingerprint(im, preset)
+	if ok && d.loupedeck.sameFrame(d.id, image.Rect(x, y, x+width, y+height), sum) {
+		return
+	}
+	var frame *frameSum
+	if ok {
+		frame = &sum
+	}
+
+	data := d.encode(im, x, y, preset)
+	if err := d.writeFramebufferLocked(data, frame); err != nil {
+		d.loupedeck.reportError(err)
+	}
+}
 
+// encode returns a WriteFramebuff payload for drawing im at x,y (in
+// framebuffer coordinates), adjusted by preset (which may be nil).
+func (d *Display) encode(im image.Image, x, y int, preset *ColorPreset) []byte {
 	b := im.Bounds()
+	data := d.framebufferHeader(x, y, b.Dx(), b.Dy())
+	if rgba, ok := im.(*image.RGBA); ok && preset == nil {
+		return d.encodeRGBA(data, rgba)
+	}
 
 	for y := b.Min.Y; y < b.Max.Y; y++ {
 		for x := b.Min.X; x < b.Max.X; x++ {
-			pixel := pixelcolor.ToRGB565(im.At(x, y))
+			c := im.At(x, y)
+			if preset != nil {
+				c = preset.adjust(c)
+			}
+			pixel := pixelcolor.ToRGB565(c)
 			lowByte := byte(pixel & 0xff)
 			highByte := byte(pixel >> 8)
 
 			// The Loupedeck CT's center knob screen wants
 			// images fed to it big endian; all other
 			// displays are little endian.
-			if d.bigEndian {
+			if d.format == RGB565BE {
 				data = append(data, highByte, lowByte)
 			} else {
 				data = append(data, lowByte, highByte)
 			}
 		}
 	}
+	return data
+}
 
-	m := d.loupedeck.NewMessage(WriteFramebuff, data)
-	err := d.loupedeck.Send(m)
-	if err != nil {
-		slog.Warn("Send failed", "err", err)
+const (
+	// framebufferHeaderLength is the length of the header at the
+	// start of every WriteFramebuff payload: the display ID, X,
+	// Y, width, and height, as 16-bit values.
+	framebufferHeaderLength = 10
+
+	// maxFramebufferPayload is the largest WriteFramebuff payload
+	// that's sent in one message.  Messages this size fit in a
+	// websocket frame with a 16-bit length, rather than relying on
+	// every firmware revision accepting a full-screen write on the
+	// Loupedeck Live (about 250KB) in one go.
+	maxFramebufferPayload = 0xffff - 3
+)
+
+// framebufferHeader returns the header for a WriteFramebuff message
+// covering the specified area, in framebuffer coordinates, with enough
+// capacity for the pixel data to be appended.
+func (d *Display) framebufferHeader(x, y, width, height int) []byte {
+	data := make([]byte, framebufferHeaderLength, framebufferHeaderLength+2*width*height)
+	binary.BigEndian.PutUint16(data[0:], uint16(d.id))
+	binary.BigEndian.PutUint16(data[2:], uint16(x))
+	binary.BigEndian.PutUint16(data[4:], uint16(y))
+	binary.BigEndian.PutUint16(data[6:], uint16(width))
+	binary.BigEndian.PutUint16(data[8:], uint16(height))
+	return data
+}
+
+// writeFramebuffer sends a WriteFramebuff message (with a header from
+// framebufferHeader), followed by a Draw message to show it.  frame
+// is the checksum of the image being drawn, if there is one.
+//
+// Pixels that are already shown aren't sent again, and if nothing has
+// changed, nothing is sent at all; see SetDirtyTracking.
+//
+// Writes whose payload is larger than maxFramebufferPayload are split
+// into bands of whole rows, each sent as its own WriteFramebuff
+// message, with a single Draw at the end.  When buffering is enabled,
+// nothing is sent until Flush; see SetDrawBuffering.
+func (d *Display) writeFramebuffer(data []byte, frame *frameSum) error {
+	// Keep each update's pieces and its Draw together, even when
+	// several goroutines are drawing at once.
+	d.loupedeck.drawMutex.Lock()
+	defer d.loupedeck.drawMutex.Unlock()
+	return d.writeFramebufferLocked(data, frame)
+}
+
+// writeFramebufferLocked is writeFramebuffer, for callers that
+// already hold the drawMutex.
+func (d *Display) writeFramebufferLocked(data []byte, frame *frameSum) error {
+	// Only send what's changed; see SetDirtyTracking.
+	data = d.loupedeck.trimFramebuffer(data, frame)
+	if data == nil {
+		return nil
+	}
+
+	if d.loupedeck.buffer.enabled {
+		// Sent by Flush; see SetDrawBuffering.
+		d.loupedeck.buffer.writes = append(d.loupedeck.buffer.writes, bufferedWrite{display: d, data: data})
+		return nil
+	}
+	if err := d.sendFramebuffer(data); err != nil {
+		return err
+	}
+	return d.sendDraw()
+}
+
+// sendFramebuffer sends WriteFramebuff messages for data, splitting it
+// if needed.  The drawMutex must be held.
+func (d *Display) sendFramebuffer(data []byte) error {
+	for _, part := range splitFramebuffer(data, maxFramebufferPayload) {
+		m := d.loupedeck.NewMessage(WriteFramebuff, part)
+		err := d.loupedeck.Send(m)
+		if err != nil {
+			return fmt.Errorf("unable to write framebuffer for display %q: %v", d.Name, err)
+		}
 	}
 
 	// I'd love to watch the return code for WriteFramebuff, but
-	// it doesn't seem to come back until after Draw, below.
+	// it doesn't seem to come back until after Draw.
 
 	//resp, err := d.loupedeck.SendAndWait(m, 50*time.Millisecond)
 	//if err != nil {
-	//	slog.Warn("Received error on draw", "message", resp)
+	//	d.loupedeck.log().Warn("Received error on draw", "message", resp)
 	//}
+	return nil
+}
 
-	// Call 'Draw'.  The screen isn't actually updated until
-	// 'draw' arrives.  Unclear if we should wait for the previous
-	// Framebuffer transaction to complete first, but adding a
-	// giant sleep here doesn't seem to change anything.
-	//
-	// Ideally, we'd batch these and only call Draw when we're
-	// doing with multiple FB updates.
+// sendDraw sends a Draw message, which makes the Loupedeck show what's
+// been written to the display's framebuffer.  The drawMutex must be
+// held.
+func (d *Display) sendDraw() error {
+	// The screen isn't actually updated until 'draw' arrives.
+	// Unclear if we should wait for the previous Framebuffer
+	// transaction to complete first, but adding a giant sleep
+	// here doesn't seem to change anything.  Several framebuffer
+	// writes can share a single Draw; see SetDrawBuffering.
 	data2 := make([]byte, 2)
 	binary.BigEndian.PutUint16(data2[0:], uint16(d.id))
 	m2 := d.loupedeck.NewMessage(Draw, data2)
-	err = d.loupedeck.Send(m2)
+	err := d.loupedeck.Send(m2)
 	if err != nil {
-		slog.Warn("Send failed", "err", err)
+		return fmt.Errorf("unable to draw display %q: %v", d.Name, err)
+	}
+	return nil
+}
+
+// splitFramebuffer splits a WriteFramebuff payload (a header from
+// framebufferHeader followed by pixels) into payloads of at most max
+// bytes each, covering horizontal bands of the original area.  Each
+// band has its own header, so the device never needs to know that
+// they were once a single write.  A payload that's already small
+// enough is returned unchanged.
+func splitFramebuffer(data []byte, max int) [][]byte {
+	if len(data) <= max || len(data) < framebufferHeaderLength {
+		return [][]byte{data}
+	}
+	id := binary.BigEndian.Uint16(data[0:])
+	x := binary.BigEndian.Uint16(data[2:])
+	y := int(binary.BigEndian.Uint16(data[4:]))
+	width := int(binary.BigEndian.Uint16(data[6:]))
+	height := int(binary.BigEndian.Uint16(data[8:]))
+	pixels := data[framebufferHeaderLength:]
+
+	rowBytes := 2 * width
+	if rowBytes == 0 || len(pixels) != rowBytes*height {
+		return [][]byte{data}
+	}
+	rows := (max - framebufferHeaderLength) / rowBytes
+	if rows < 1 {
+		rows = 1
+	}
+
+	var parts [][]byte
+	for row := 0; row < height; row += rows {
+		n := rows
+		if row+n > height {
+			n = height - row
+		}
+		part := make([]byte, framebufferHeaderLength, framebufferHeaderLength+n*rowBytes)
+		binary.BigEndian.PutUint16(part[0:], id)
+		binary.BigEndian.PutUint16(part[2:], x)
+		binary.BigEndian.PutUint16(part[4:], uint16(y+row))
+		binary.BigEndian.PutUint16(part[6:], uint16(width))
+		binary.BigEndian.PutUint16(part[8:], uint16(n))
+		part = append(part, pixels[row*rowBytes:(row+n)*rowBytes]...)
+		parts = append(parts, part)
 	}
+	return parts
 }
@@ -5,9 +5,7 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"log/slog"
 	"math"
-	"strconv"
 	"time"
 
 	"github.com/jphsd/graphics2d"
@@ -38,12 +36,6 @@ func (k *DisplayKnob) Get() int {
 // Set sets the current value of the DisplayKnob, triggering any
 // callbacks set on the WatchedInt that underlies the DisplayKnob.
 func (k *DisplayKnob) Set(v int) {
-	if v < k.min {
-		v = k.min
-	}
-	if v > k.max {
-		v = k.max
-	}
 	k.watchedint.Set(v)
 }
 
@@ -51,15 +43,7 @@ func (k *DisplayKnob) Set(v int) {
 // IntKnob by a specified amount.  This triggers a callback on the
 // WatchedInt that underlies the DisplayKnob.
 func (k *DisplayKnob) Inc(v int) {
-	x := k.watchedint.Get()
-	x += v
-	if x < k.min {
-		x = k.min
-	}
-	if x > k.max {
-		x = k.max
-	}
-	k.watchedint.Set(x)
+	k.watchedint.inc(v)
 }
 
 // DisplayKnob implements a generic dial knob for the big knob in the
@@ -75,6 +59,7 @@ func (l *Loupedeck) DisplayKnob(min int, max int, watchedint *WatchedInt) *Displ
 		min:        min,
 		max:        max,
 	}
+	watchedint.SetRange(min, max)
 	l.BindKnob(CTKnob, func(_ Knob, v int) {
 		k.Inc(v)
 	})
@@ -133,9 +118,9 @@ func (l *Loupedeck) RegisterDragDisplayKnobWatcher(f DragDisplayKnobFunc) {
 				l.dragDKStartTime = time.Now()
 			} else if b == ButtonUp {
 				// Where did *that* come from?
-				slog.Warn("Received CT ButtonUp event while not dragging")
+				l.log().Warn("Received CT ButtonUp event while not dragging")
 			} else {
-				slog.Warn("Received unknown CT button event while not dragging", "event", b)
+				l.noteUnknown("CT touch status", TouchCT, int(b), nil)
 			}
 		} else {
 			// Already started dragging
@@ -156,7 +141,7 @@ func (l *Loupedeck) RegisterDragDisplayKnobWatcher(f DragDisplayKnobFunc) {
 				}
 
 			} else {
-				slog.Warn("Received unknown CT button event while dragging", "event", b)
+				l.noteUnknown("CT touch status", TouchCT, int(b), nil)
 			}
 		}
 	})
@@ -225,6 +210,7 @@ type DKAnalogWidget struct {
 	MinDegrees, TotalDegrees float64 // 0 is straight up
 	Value                    *WatchedInt
 	Name                     string
+	Format                   ValueFormat
 	active                   bool
 }
 
@@ -253,6 +239,7 @@ func (w *DKAnalogWidget) Activate(l *Loupedeck) {
 // Deactivate is called when the widget loses focus.
 func (w *DKAnalogWidget) Deactivate(l *Loupedeck) {
 	w.active = false
+	l.DrawQueue().drop(fmt.Sprintf("dkanalog:%p", w))
 }
 
 func d2r(d float64) float64 {
@@ -262,23 +249,11 @@ func d2r(d float64) float64 {
 // Draw draws the widget on the display if the widget is currently active.
 //
 // Note that this is kind of expensive as it sends a lot of bits to
-// the Loupedeck, and it's possible to lag several seconds behind when
-// the user spins the dial quickly.  We'll probably want to break this
-// out into its own thread, and only allow ~1 draw at a time to be
-// queued.  Then, when the user spins the dial, we do *one* draw, then
-// fetch new events, which try to queue up a zillion new draw events,
-// but we only allow one to be queued at a time, so we end up doing 1
-// draw per event block.  I think.
-//
-// Since none of the code is really thread-safe yet, this will take a
-// bit more work.  At a minimum, we'll need to add locks around USB
-// communication.
-//
-// The alternative would be to do some sort of "future draw" thing,
-// where draws are scheduled for (say) 100ms in the future, and then
-// we drop duplicates.  Then we inject the "draw event" back into the
-// main loop, so we don't need to worry about locking.  Might be
-// easier, and likely it'd have better performance.
+// the Loupedeck, so the image is sent through the DrawQueue at
+// DrawFeedback priority.  When the user spins the dial quickly, draws
+// that haven't been sent yet are replaced by newer ones, rather than
+// lagging several seconds behind, and meters and animations can't
+// hold them up.
 func (w *DKAnalogWidget) Draw(l *Loupedeck) {
 	// Only draw if we have the focus
 	if !w.active {
@@ -292,7 +267,7 @@ func (w *DKAnalogWidget) Draw(l *Loupedeck) {
 	// TODO: actually draw something.
 
 	im := image.NewRGBA(image.Rect(0, 0, 240, 210))
-	bg := colorBackground
+	bg := l.theme.Background
 	draw.Draw(im, im.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
 
 	startRadian := d2r(w.MinDegrees + 180)
@@ -304,7 +279,7 @@ func (w *DKAnalogWidget) Draw(l *Loupedeck) {
 
 	fmt.Printf("X: %f, Y: %f, startRadian: %f, radians: %f, stopRadian: %f\n", startX, startY, startRadian, radians, stopRadian)
 
-	pen := graphics2d.NewPen(colorInActive, 1)
+	pen := graphics2d.NewPen(l.theme.Inactive, l.theme.LineWidth(1))
 	// I'm officially mystified by the graphics2d coordinate
 	// system, but this seems to draw correct arcs.  I started
 	// with angle=0 being to the east and (x, y) coordinates, but
@@ -313,25 +288,29 @@ func (w *DKAnalogWidget) Draw(l *Loupedeck) {
 	graphics2d.DrawArc(im, []float64{startY, startX}, []float64{120, 120}, stopRadian, pen)
 
 	stopRadian = radians * (float64(w.Value.Get()) / float64(w.Max))
-	pen = graphics2d.NewPen(colorActive, 4)
+	pen = graphics2d.NewPen(l.theme.Active, l.theme.LineWidth(4))
 	graphics2d.DrawArc(im, []float64{startY, startX}, []float64{120, 120}, stopRadian, pen)
 
 	fd := l.FontDrawer()
 	fd.Dst = im
 
 	drawCenteredStringAt(fd, w.Name, 120, 80)
-	drawCenteredStringAt(fd, strconv.Itoa(w.Value.Get()), 120, 160)
+	drawCenteredStringAt(fd, l.FormatValue(w.Value.Get(), w.Format), 120, 160)
 
-	display.Draw(im, 0, 0)
+	l.DrawQueue().Queue(fmt.Sprintf("dkanalog:%p", w), DrawFeedback, display, im, 0, 0)
 }
 
 // WidgetHolder is a container that can hold multiple DKWidgets and
 // allows the user to select between them by swiping right/left on the
-// CT's display.
+// CT's display.  It starts on the tab from the saved UIState, if there
+// is one; see SetStateDir.
 func (l *Loupedeck) WidgetHolder(widgets []DKWidget) {
 	active := 0
 	count := len(widgets)
-	widgets[0].Activate(l)
+	if t := l.uiState.WidgetTab; t > 0 && t < count {
+		active = t
+	}
+	widgets[active].Activate(l)
 
 	l.RegisterDragDisplayKnobWatcher(func(b DragEvent, x, y int) {
 		if b == DragClick {
@@ -347,6 +326,7 @@ func (l *Loupedeck) WidgetHolder(widgets []DKWidget) {
 				}
 				widgets[active].Activate(l)
 				l.drawWidgetHolderNavBar(active, count)
+				l.updateState(func(s *UIState) { s.WidgetTab = active })
 			} else if x > 20 {
 				widgets[active].Deactivate(l)
 				active--
@@ -355,10 +335,11 @@ func (l *Loupedeck) WidgetHolder(widgets []DKWidget) {
 				}
 				widgets[active].Activate(l)
 				l.drawWidgetHolderNavBar(active, count)
+				l.updateState(func(s *UIState) { s.WidgetTab = active })
 			}
 		}
 	})
-	l.drawWidgetHolderNavBar(0, count)
+	l.drawWidgetHolderNavBar(active, count)
 }
 
 // drawWidgetHolderNavBar draws in the bottom 20x240 of the knob to
@@ -377,12 +358,22 @@ func (l *Loupedeck) drawWidgetHolderNavBar(position int, tabCount int) {
 	//
 	// A bit of math; we have a circular display with r=120px.  If
 	// we lop off S pixels at the bottom, then the circular area
-	// is 2*sqrt(2*s*r-s^s) pixels wide.  Calculating the angle is
-	// left as an exercise for the reader, but when S=30 we're
-	// looking at about 70 degrees total.
+	// is 2*sqrt(2*s*r-s^s) pixels wide (see Display.VisibleSpan).
+	// Calculating the angle is left as an exercise for the reader,
+	// but when S=30 we're looking at about 70 degrees total.
 
 	if tabCount > 10 {
-		panic("We can't draw more than 10 tabs right now.  So either create fewer tabs or fix the logic in displayknob.go")
+		// We can't fit more than 10 blips.  Rather than giving
+		// up, just show the 10 around the current tab.
+		first := position - 5
+		if first < 0 {
+			first = 0
+		}
+		if first > tabCount-10 {
+			first = tabCount - 10
+		}
+		position -= first
+		tabCount = 10
 	}
 
 	// We have about 70 degrees available, but we don't want to
@@ -401,10 +392,10 @@ func (l *Loupedeck) drawWidgetHolderNavBar(position int, tabCount int) {
 	display := l.GetDisplay("dial")
 
 	im := image.NewRGBA(image.Rect(0, 0, 240, 30))
-	bg := colorBackground
+	bg := l.theme.Background
 	draw.Draw(im, im.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
-	penActive := graphics2d.NewPen(colorActive, 10)
-	penInActive := graphics2d.NewPen(colorInActive, 8)
+	penActive := graphics2d.NewPen(l.theme.Active, l.theme.LineWidth(10))
+	penInActive := graphics2d.NewPen(l.theme.Inactive, l.theme.LineWidth(8))
 	var pen *graphics2d.Pen
 
 	for i := 0; i < tabCount; i++ {
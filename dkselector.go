@@ -0,0 +1,144 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// detentPattern is the vibration pattern used for emulated detents.
+const detentPattern = VibrationShort
+
+// DKSelectorWidget is a widget for the Loupedeck CT's display knob
+// that chooses between a list of named options, like a menu or the
+// iris settings on a lens.  Turning the knob moves through the
+// options; the current option is shown in the middle of the display,
+// with its neighbors above and below.
+type DKSelectorWidget struct {
+	Name    string
+	Options []string
+	// Value holds the index of the selected option.
+	Value *WatchedInt
+	// StepsPerItem is the number of clicks of the knob needed to
+	// move to the next option.  The CT's knob spins freely and
+	// has a lot of clicks per turn, so values above 1 make
+	// selection less twitchy.
+	StepsPerItem int
+	// Detents, if true, makes the Loupedeck vibrate briefly each
+	// time the selection moves to a new option, so the
+	// free-spinning knob feels like it has detents.
+	Detents bool
+
+	active bool
+	accum  int
+}
+
+// NewDKSelectorWidget creates a new DKSelectorWidget.
+func NewDKSelectorWidget(options []string, value *WatchedInt, name string) *DKSelectorWidget {
+	return &DKSelectorWidget{
+		Name:         name,
+		Options:      options,
+		Value:        value,
+		StepsPerItem: 1,
+	}
+}
+
+// Activate is called when the widget gains focus.  It binds the CT
+// knob to the widget.
+func (w *DKSelectorWidget) Activate(l *Loupedeck) {
+	w.active = true
+	w.accum = 0
+	w.Value.SetRange(0, len(w.Options)-1)
+	l.BindKnob(CTKnob, func(_ Knob, v int) {
+		w.turn(l, v)
+	})
+	w.Draw(l)
+}
+
+// Deactivate is called when the widget loses focus.
+func (w *DKSelectorWidget) Deactivate(l *Loupedeck) {
+	w.active = false
+	l.DrawQueue().drop(w.drawOwner())
+}
+
+// turn handles a knob rotation of v clicks.
+func (w *DKSelectorWidget) turn(l *Loupedeck, v int) {
+	steps := w.StepsPerItem
+	if steps < 1 {
+		steps = 1
+	}
+	w.accum += v
+
+	prev := w.Value.Get()
+	i := prev
+	for w.accum >= steps {
+		w.accum -= steps
+		i++
+	}
+	for w.accum <= -steps {
+		w.accum += steps
+		i--
+	}
+	if i == prev {
+		return
+	}
+
+	w.Value.Set(i)
+	if w.Value.Get() != prev {
+		if w.Detents {
+			_ = l.Vibrate(detentPattern)
+		}
+		w.queueDraw(l)
+	}
+}
+
+// Draw draws the widget on the display if the widget is currently
+// active.
+func (w *DKSelectorWidget) Draw(l *Loupedeck) {
+	display := l.GetDisplay("dial")
+	if !w.active || display == nil {
+		return
+	}
+	l.DrawQueue().drop(w.drawOwner())
+	display.Draw(w.render(l), 0, 0)
+}
+
+// queueDraw redraws the widget through the DrawQueue at DrawFeedback
+// priority, so that the selection moving as the knob turns is sent
+// ahead of meters and animations.
+func (w *DKSelectorWidget) queueDraw(l *Loupedeck) {
+	display := l.GetDisplay("dial")
+	if !w.active || display == nil {
+		return
+	}
+	l.DrawQueue().Queue(w.drawOwner(), DrawFeedback, display, w.render(l), 0, 0)
+}
+
+// drawOwner is the widget's owner name in the DrawQueue.
+func (w *DKSelectorWidget) drawOwner() string {
+	return fmt.Sprintf("dkselector:%p", w)
+}
+
+// render returns the widget's current image.
+func (w *DKSelectorWidget) render(l *Loupedeck) image.Image {
+	im := image.NewRGBA(image.Rect(0, 0, 240, 210))
+	draw.Draw(im, im.Bounds(), &image.Uniform{l.theme.Background}, image.Point{}, draw.Src)
+
+	fd := l.FontDrawer()
+	fd.Dst = im
+	drawCenteredStringAt(fd, w.Name, 120, 50)
+
+	i := w.Value.Get()
+	if i >= 0 && i < len(w.Options) {
+		drawCenteredStringAt(fd, w.Options[i], 120, 125)
+	}
+
+	fd.Src = &image.Uniform{l.theme.Inactive}
+	if i > 0 && i-1 < len(w.Options) {
+		drawCenteredStringAt(fd, w.Options[i-1], 120, 90)
+	}
+	if i+1 >= 0 && i+1 < len(w.Options) {
+		drawCenteredStringAt(fd, w.Options[i+1], 120, 160)
+	}
+	return im
+}
@@ -0,0 +1,160 @@
+package loupedeck
+
+import (
+	"image"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DrawPriority is the priority of a queued draw.  When the DrawQueue
+// can't send everything in a single frame, higher priorities go
+// first.
+type DrawPriority int
+
+const (
+	// DrawDecoration is for things that are nice to have up to
+	// date, but don't matter much, like clocks and animations.
+	DrawDecoration DrawPriority = iota
+	// DrawMeter is for continuously-updating levels, like Meter.
+	DrawMeter
+	// DrawFeedback is for direct feedback to user input, like a
+	// knob's value changing as it's turned.
+	DrawFeedback
+)
+
+// DefaultDrawsPerFrame is the default value of
+// DrawQueue.MaxDrawsPerFrame.
+const DefaultDrawsPerFrame = 4
+
+// drawRequest is a single pending draw.
+type drawRequest struct {
+	owner    string
+	priority DrawPriority
+	display  *Display
+	im       image.Image
+	x, y     int
+	seq      uint64
+}
+
+// DrawQueue schedules draws from widgets that update frequently, so
+// that they share the Loupedeck's limited bandwidth sensibly.  Each
+// draw is queued under an owner name (usually one per widget); if an
+// owner queues a new draw before its previous one was sent, the old
+// one is dropped, since it's out of date anyway.  On each tick of
+// the FrameClock, up to MaxDrawsPerFrame draws are sent, highest
+// priority first.  Owners can also be given a rate budget with
+// SetBudget, so that a chatty widget can't hog the queue even at high
+// priority.
+type DrawQueue struct {
+	// MaxDrawsPerFrame limits how many queued draws are sent per
+	// frame.  Zero means no limit.
+	MaxDrawsPerFrame int
+
+	loupedeck *Loupedeck
+	mutex     sync.Mutex
+	pending   map[string]*drawRequest
+	budgets   map[string]time.Duration // minimum time between draws
+	lastDraw  map[string]time.Time
+	seq       uint64
+	cancel    func()
+}
+
+// DrawQueue returns the Loupedeck's DrawQueue, creating it if needed.
+func (l *Loupedeck) DrawQueue() *DrawQueue {
+	l.drawQueueOnce.Do(func() {
+		l.drawQueue = &DrawQueue{
+			MaxDrawsPerFrame: DefaultDrawsPerFrame,
+			loupedeck:        l,
+			pending:          map[string]*drawRequest{},
+			budgets:          map[string]time.Duration{},
+			lastDraw:         map[string]time.Time{},
+		}
+	})
+	return l.drawQueue
+}
+
+// Queue queues im to be drawn on d at x,y, replacing any draw that
+// owner has queued but that hasn't been sent yet.
+func (q *DrawQueue) Queue(owner string, priority DrawPriority, d *Display, im image.Image, x, y int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.seq++
+	q.pending[owner] = &drawRequest{
+		owner:    owner,
+		priority: priority,
+		display:  d,
+		im:       im,
+		x:        x,
+		y:        y,
+		seq:      q.seq,
+	}
+	if q.cancel == nil {
+		q.cancel = q.loupedeck.FrameClock().Subscribe(q.frame)
+	}
+}
+
+// QueueRegion is like Queue, but draws into a Region.
+func (q *DrawQueue) QueueRegion(owner string, priority DrawPriority, r *Region, im image.Image) {
+	q.Queue(owner, priority, r.display, im, r.x, r.y)
+}
+
+// SetBudget limits owner to at most maxPerSecond draws per second.
+// Draws beyond that stay queued (and are replaced by newer ones)
+// until the budget allows them.  A budget of 0 removes the limit.
+func (q *DrawQueue) SetBudget(owner string, maxPerSecond float64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if maxPerSecond <= 0 {
+		delete(q.budgets, owner)
+		return
+	}
+	q.budgets[owner] = time.Duration(float64(time.Second) / maxPerSecond)
+}
+
+// frame sends the most important queued draws.
+func (q *DrawQueue) frame(_ uint64) bool {
+	now := time.Now()
+
+	q.mutex.Lock()
+	var ready []*drawRequest
+	for owner, r := range q.pending {
+		if b := q.budgets[owner]; b > 0 && now.Sub(q.lastDraw[owner]) < b {
+			continue
+		}
+		ready = append(ready, r)
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if ready[i].priority != ready[j].priority {
+			return ready[i].priority > ready[j].priority
+		}
+		return ready[i].seq < ready[j].seq
+	})
+	if q.MaxDrawsPerFrame > 0 && len(ready) > q.MaxDrawsPerFrame {
+		ready = ready[:q.MaxDrawsPerFrame]
+	}
+	for _, r := range ready {
+		delete(q.pending, r.owner)
+		q.lastDraw[r.owner] = now
+	}
+	if len(q.pending) == 0 && q.cancel != nil {
+		// Unsubscribing takes the FrameClock's lock, which it
+		// doesn't hold while calling us.
+		q.cancel()
+		q.cancel = nil
+	}
+	q.mutex.Unlock()
+
+	for _, r := range ready {
+		r.display.Draw(r.im, r.x, r.y)
+	}
+	return len(ready) > 0
+}
+
+// drop discards any draw that owner has queued but that hasn't been
+// sent yet.
+func (q *DrawQueue) drop(owner string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.pending, owner)
+}
@@ -0,0 +1,121 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+)
+
+// EnumKnob binds a knob to a list of named options.  Turning the
+// knob steps through the options, and the current option is shown on
+// the part of the left or right display next to the knob.  It's the
+// knob counterpart to MultiButton.
+//
+// The index of the selected option is stored in a WatchedInt, so the
+// usual watcher mechanism works; AddWatcher can be used to get the
+// option's name instead.
+type EnumKnob struct {
+	loupedeck *Loupedeck
+	knob      Knob
+	region    *Region
+	options   []string
+	value     *WatchedInt
+	watchers  []func(string)
+}
+
+// NewEnumKnob creates a new EnumKnob on the specified Knob, choosing
+// between options.  The WatchedInt holds the index of the currently
+// selected option.
+func (l *Loupedeck) NewEnumKnob(k Knob, options []string, watchedint *WatchedInt) *EnumKnob {
+	e := &EnumKnob{
+		loupedeck: l,
+		knob:      k,
+		region:    l.KnobRegion(k),
+		options:   options,
+		value:     watchedint,
+	}
+
+	watchedint.AddWatcher(func(i int) {
+		e.Draw()
+		for _, f := range e.watchers {
+			f(e.Get())
+		}
+	})
+
+	l.BindKnob(k, func(_ Knob, v int) {
+		e.Inc(v)
+	})
+
+	e.Draw()
+	return e
+}
+
+// AddWatcher adds a callback that is called with the name of the
+// selected option whenever the selection changes.
+func (e *EnumKnob) AddWatcher(f func(string)) {
+	e.watchers = append(e.watchers, f)
+}
+
+// Index returns the index of the currently selected option.
+func (e *EnumKnob) Index() int {
+	i := e.value.Get()
+	if i < 0 {
+		return 0
+	}
+	if i >= len(e.options) {
+		return len(e.options) - 1
+	}
+	return i
+}
+
+// Get returns the name of the currently selected option.
+func (e *EnumKnob) Get() string {
+	if len(e.options) == 0 {
+		return ""
+	}
+	return e.options[e.Index()]
+}
+
+// Set selects an option by index.  Out-of-range indexes are clamped.
+func (e *EnumKnob) Set(i int) {
+	if i >= len(e.options) {
+		i = len(e.options) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	e.value.Set(i)
+}
+
+// SetOption selects an option by name.  It returns false if no
+// option has that name.
+func (e *EnumKnob) SetOption(s string) bool {
+	for i, o := range e.options {
+		if o == s {
+			e.Set(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Inc moves the selection forward (or backward, for negative values)
+// by a number of options.
+func (e *EnumKnob) Inc(v int) {
+	e.Set(e.Index() + v)
+}
+
+// Draw shows the current option next to the knob.
+func (e *EnumKnob) Draw() {
+	if e.region == nil {
+		return
+	}
+	s := e.Get()
+	id := fmt.Sprintf("enumknob:%p:%s", e, s)
+	e.loupedeck.DrawCached(e.region, id, func() image.Image {
+		im, err := e.loupedeck.TextInBox(e.region.width, e.region.height, s, e.loupedeck.theme.Text, e.loupedeck.theme.Background)
+		if err != nil {
+			return blankImage(e.region.width, e.region.height)
+		}
+		return im
+	})
+}
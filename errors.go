@@ -0,0 +1,28 @@
+package loupedeck
+
+// errorChannelSize is the number of errors buffered by the channel
+// returned by Errors.
+const errorChannelSize = 16
+
+// Errors returns a channel that receives transport and protocol
+// errors that happen outside of any caller's call stack, such as
+// failed draws or messages from the Loupedeck that can't be decoded.
+// Most sends are fire-and-forget, so this is the only way to find out
+// about them other than the log.
+//
+// The channel is buffered; if nobody is reading it and it fills up,
+// further errors are logged and dropped.
+func (l *Loupedeck) Errors() <-chan error {
+	return l.errors
+}
+
+// reportError sends an error to the channel returned by Errors,
+// without blocking.
+func (l *Loupedeck) reportError(err error) {
+	l.log().Warn("Loupedeck error", "err", err)
+	select {
+	case l.errors <- err:
+	default:
+		l.log().Warn("Error channel full, dropping error", "err", err)
+	}
+}
@@ -0,0 +1,156 @@
+package loupedeck
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultFrameRate is the rate (in frames per second) of the
+// FrameClock returned by Loupedeck.FrameClock.
+const DefaultFrameRate = 20
+
+// FrameFunc is called once per frame by a FrameClock, with the
+// current frame number.  Animations that need to stay in phase with
+// each other, like blinking indicators, should derive their state
+// from the frame number rather than counting calls.  The return
+// value reports whether the function drew anything, which is used
+// for enforcing the clock's draw budget.
+type FrameFunc func(frame uint64) bool
+
+// FrameClock is a single shared ticker for animated widgets.  Having
+// every animation tick off the same clock keeps blinking, scrolling,
+// and transitions in phase with each other, and makes it possible to
+// cap the total number of draws per frame in one place.
+//
+// The clock only runs while it has subscribers.
+type FrameClock struct {
+	// MaxDrawsPerFrame limits how many subscribers may draw in a
+	// single frame.  Once the limit is reached, the remaining
+	// subscribers are skipped for that frame, and get called first
+	// on the next frame.  Zero means no limit.
+	MaxDrawsPerFrame int
+
+	interval    time.Duration
+	mutex       sync.Mutex
+	subscribers []*frameSubscriber
+	frame       uint64
+	next        int // index of the first subscriber to call next frame
+	stop        chan struct{}
+}
+
+type frameSubscriber struct {
+	f FrameFunc
+}
+
+// NewFrameClock creates a new FrameClock that ticks fps times per
+// second.
+func NewFrameClock(fps int) *FrameClock {
+	if fps <= 0 {
+		fps = DefaultFrameRate
+	}
+	return &FrameClock{
+		interval: time.Second / time.Duration(fps),
+	}
+}
+
+// FrameClock returns the Loupedeck's shared FrameClock, creating it
+// if needed.
+func (l *Loupedeck) FrameClock() *FrameClock {
+	l.frameClockOnce.Do(func() {
+		// Tests may have installed their own clock already.
+		if l.frameClock == nil {
+			l.frameClock = NewFrameClock(DefaultFrameRate)
+		}
+	})
+	return l.frameClock
+}
+
+// Interval returns the time between frames.
+func (c *FrameClock) Interval() time.Duration {
+	return c.interval
+}
+
+// Subscribe registers a FrameFunc to be called on every frame,
+// starting the clock if needed.  It returns a function that
+// unsubscribes it again.
+func (c *FrameClock) Subscribe(f FrameFunc) (cancel func()) {
+	s := &frameSubscriber{f: f}
+
+	c.mutex.Lock()
+	c.subscribers = append(c.subscribers, s)
+	if c.stop == nil {
+		c.stop = make(chan struct{})
+		go c.run(c.stop)
+	}
+	c.mutex.Unlock()
+
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for i, sub := range c.subscribers {
+			if sub == s {
+				c.subscribers = append(c.subscribers[:i], c.subscribers[i+1:]...)
+				break
+			}
+		}
+		if len(c.subscribers) == 0 && c.stop != nil {
+			close(c.stop)
+			c.stop = nil
+		}
+	}
+}
+
+// stopAll unsubscribes everything and stops the clock.
+func (c *FrameClock) stopAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.subscribers = nil
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+func (c *FrameClock) run(stop chan struct{}) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.tick()
+		}
+	}
+}
+
+// tick calls each subscriber once, honoring MaxDrawsPerFrame.
+func (c *FrameClock) tick() {
+	c.mutex.Lock()
+	c.frame++
+	frame := c.frame
+	subs := make([]*frameSubscriber, len(c.subscribers))
+	copy(subs, c.subscribers)
+	start := c.next
+	max := c.MaxDrawsPerFrame
+	c.mutex.Unlock()
+
+	n := len(subs)
+	draws := 0
+	for i := 0; i < n; i++ {
+		idx := (start + i) % n
+		if max > 0 && draws >= max {
+			c.mutex.Lock()
+			c.next = idx
+			c.mutex.Unlock()
+			return
+		}
+		if subs[idx].f(frame) {
+			draws++
+		}
+	}
+
+	c.mutex.Lock()
+	c.next = 0
+	c.mutex.Unlock()
+}
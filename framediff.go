@@ -0,0 +1,66 @@
+package loupedeck
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"image"
+)
+
+// frameSum is a checksum of an image drawn with Display.Draw, used to
+// skip draws that wouldn't change anything.
+type frameSum [sha256.Size]byte
+
+// frameKey identifies the area that an image was drawn to, in
+// framebuffer coordinates.
+type frameKey struct {
+	id   byte
+	rect image.Rectangle
+}
+
+// frameFingerprint returns a checksum of im as it would be drawn with
+// preset.  It only handles the image types that widgets usually
+// produce; for anything else, it returns false, and the draw is
+// always encoded.  Checksumming is much cheaper than encoding.
+func frameFingerprint(im image.Image, preset *ColorPreset) (frameSum, bool) {
+	var pix []byte
+	var stride, bpp int
+	var rect image.Rectangle
+	switch i := im.(type) {
+	case *image.RGBA:
+		pix, stride, bpp, rect = i.Pix, i.Stride, 4, i.Rect
+	case *image.NRGBA:
+		pix, stride, bpp, rect = i.Pix, i.Stride, 4, i.Rect
+	default:
+		return frameSum{}, false
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%T %v %p\n", im, rect.Size(), preset)
+	for y := 0; y < rect.Dy(); y++ {
+		h.Write(pix[y*stride : y*stride+rect.Dx()*bpp])
+	}
+	var sum frameSum
+	h.Sum(sum[:0])
+	return sum, true
+}
+
+// sameFrame returns true if the last thing drawn to exactly rect on
+// framebuffer id had the checksum sum, and nothing has been drawn
+// over it since.
+func (l *Loupedeck) sameFrame(id byte, rect image.Rectangle, sum frameSum) bool {
+	s := &l.shown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	last, ok := s.frames[frameKey{id: id, rect: rect}]
+	return ok && last == sum
+}
+
+// forgetFrames forgets the checksums of images on framebuffer id that
+// overlap r.  The shownState mutex must be held.
+func (s *shownState) forgetFrames(id byte, r image.Rectangle) {
+	for k := range s.frames {
+		if k.id == id && k.rect.Overlaps(r) {
+			delete(s.frames, k)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package loupedeck
+
+import (
+	"time"
+)
+
+// SwipeDirection indicates which way a swipe or fling on the main
+// touchscreen went.
+type SwipeDirection int
+
+const (
+	// SwipeLeft is a right-to-left swipe.
+	SwipeLeft SwipeDirection = iota
+	// SwipeRight is a left-to-right swipe.
+	SwipeRight
+	// SwipeUp is a bottom-to-top fling.  Swipes are only ever
+	// horizontal.
+	SwipeUp
+	// SwipeDown is a top-to-bottom fling.
+	SwipeDown
+)
+
+// SwipeFunc is a function signature used for callbacks on swipe
+// gestures.
+type SwipeFunc func(SwipeDirection)
+
+// Fling describes a fast swipe: a touch that was still moving quickly
+// when the finger was lifted.
+type Fling struct {
+	// Direction is the main direction of travel.
+	Direction SwipeDirection
+	// VX and VY are the touch velocity when the finger was lifted,
+	// in pixels per second.  Positive values are to the right and
+	// down.
+	VX, VY float64
+}
+
+// FlingFunc is a function signature used for callbacks on fling
+// gestures.
+type FlingFunc func(Fling)
+
+// DragFunc is a function signature used for callbacks on drags.  dx
+// and dy are the distance (in pixels) moved since the touch started,
+// and vx and vy are the current velocity in pixels per second.
+type DragFunc func(dx, dy int, vx, vy float64)
+
+const (
+	swipeMinDistance = 60
+	swipeMaxDuration = time.Second
+
+	// flingMinVelocity is the release speed (in pixels per
+	// second) needed for a touch to count as a fling.
+	flingMinVelocity = 600
+	// flingMaxPause is how long the finger can rest before being
+	// lifted and still have the touch count as a fling.
+	flingMaxPause = 100 * time.Millisecond
+	// velocitySmoothing is the weight given to the newest sample
+	// when updating the touch velocity.
+	velocitySmoothing = 0.6
+)
+
+// swipeState tracks an in-progress touch on the main touchscreen for
+// swipe, fling, and drag detection.
+type swipeState struct {
+	started   bool
+	startX    uint16
+	startY    uint16
+	startTime time.Time
+	lastX     uint16
+	lastY     uint16
+	lastTime  time.Time
+	vx, vy    float64
+	binding   SwipeFunc
+	fling     FlingFunc
+	drag      DragFunc
+}
+
+// BindSwipe sets a callback for horizontal swipes across the
+// touchscreen.  A swipe needs to travel at least 60 pixels, mostly
+// horizontally, in under a second.  Only one swipe callback can be
+// set at a time; calling BindSwipe again replaces it, and passing nil
+// removes it.
+//
+// Swipes are recognized in addition to normal touch events, not
+// instead of them, so the TouchButton where the swipe started will
+// still see a touch.
+func (l *Loupedeck) BindSwipe(f SwipeFunc) {
+	l.swipe.binding = f
+}
+
+// BindFling sets a callback for flings: touches that are still moving
+// at 600 pixels per second or more when the finger is lifted.  Unlike
+// swipes, flings may go in any direction, and a slow drag that covers
+// a long distance isn't a fling.  A fast horizontal swipe is both a
+// swipe and a fling, and calls both callbacks.
+//
+// As with BindSwipe, only one fling callback can be set at a time.
+func (l *Loupedeck) BindFling(f FlingFunc) {
+	l.swipe.fling = f
+}
+
+// BindDrag sets a callback that is called every time a touch on the
+// touchscreen moves.  Together with BindFling, this is enough to
+// implement momentum scrolling.  Only one drag callback can be set at
+// a time.
+func (l *Loupedeck) BindDrag(f DragFunc) {
+	l.swipe.drag = f
+}
+
+// TouchVelocity returns the velocity of the current touch, in pixels
+// per second.  It returns zeros if nothing is touching the screen.
+func (l *Loupedeck) TouchVelocity() (vx, vy float64) {
+	if !l.swipe.started {
+		return 0, 0
+	}
+	return l.swipe.vx, l.swipe.vy
+}
+
+// trackSwipe feeds a touch event into the gesture recognizers.
+func (l *Loupedeck) trackSwipe(s ButtonStatus, x, y uint16) {
+	t := &l.swipe
+	now := time.Now()
+
+	if s == ButtonDown {
+		if !t.started {
+			t.started = true
+			t.startX, t.startY = x, y
+			t.startTime = now
+			t.lastX, t.lastY = x, y
+			t.lastTime = now
+			t.vx, t.vy = 0, 0
+			return
+		}
+		t.updateVelocity(x, y, now)
+		if t.drag != nil {
+			t.drag(int(x)-int(t.startX), int(y)-int(t.startY), t.vx, t.vy)
+		}
+		return
+	}
+
+	if !t.started {
+		return
+	}
+	t.started = false
+	if now.Sub(t.lastTime) > flingMaxPause {
+		// The finger stopped before it was lifted.
+		t.vx, t.vy = 0, 0
+	} else {
+		t.updateVelocity(x, y, now)
+	}
+
+	if t.fling != nil && t.vx*t.vx+t.vy*t.vy >= flingMinVelocity*flingMinVelocity {
+		f := Fling{VX: t.vx, VY: t.vy}
+		switch {
+		case abs(int(t.vx)) >= abs(int(t.vy)) && t.vx < 0:
+			f.Direction = SwipeLeft
+		case abs(int(t.vx)) >= abs(int(t.vy)):
+			f.Direction = SwipeRight
+		case t.vy < 0:
+			f.Direction = SwipeUp
+		default:
+			f.Direction = SwipeDown
+		}
+		t.fling(f)
+	}
+
+	if t.binding == nil {
+		return
+	}
+	dx := int(x) - int(t.startX)
+	dy := int(y) - int(t.startY)
+	if now.Sub(t.startTime) > swipeMaxDuration {
+		return
+	}
+	if abs(dx) < swipeMinDistance || abs(dx) < 2*abs(dy) {
+		return
+	}
+
+	if dx < 0 {
+		t.binding(SwipeLeft)
+	} else {
+		t.binding(SwipeRight)
+	}
+}
+
+// updateVelocity updates the smoothed touch velocity with a new touch
+// position.
+func (t *swipeState) updateVelocity(x, y uint16, now time.Time) {
+	dt := now.Sub(t.lastTime).Seconds()
+	if dt <= 0 || (x == t.lastX && y == t.lastY) {
+		return
+	}
+	vx := float64(int(x)-int(t.lastX)) / dt
+	vy := float64(int(y)-int(t.lastY)) / dt
+	t.vx = velocitySmoothing*vx + (1-velocitySmoothing)*t.vx
+	t.vy = velocitySmoothing*vy + (1-velocitySmoothing)*t.vy
+	t.lastX, t.lastY = x, y
+	t.lastTime = now
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
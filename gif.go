@@ -0,0 +1,152 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultGIFDelay is used for GIF frames with no delay.  Browsers
+// treat very short delays the same way, since many GIFs in the wild
+// have them.
+const defaultGIFDelay = 100 * time.Millisecond
+
+// GIFPlayer plays an animated GIF on a touch button; see PlayGIF.
+type GIFPlayer struct {
+	loupedeck *Loupedeck
+	region    *Region
+	frames    []*image.RGBA
+	ends      []time.Duration // when each frame stops showing, from the start of a loop
+	loops     int             // number of times to play; 0 means forever
+	mutex     sync.Mutex
+	animation *Animation
+}
+
+// PlayGIF plays an animated GIF on a touch button, scaled to fit the
+// button.  Frames are scaled once up front, and then streamed through
+// the DrawQueue by an Animation (see Animate), so frame delays shorter
+// than the display's animation frame rate are rounded up.  The GIF
+// loops as many times as it asks to, after which the last frame stays
+// on the button.
+//
+// Playback starts right away; use the returned GIFPlayer to stop and
+// restart it.
+func (l *Loupedeck) PlayGIF(b TouchButton, g *gif.GIF) (*GIFPlayer, error) {
+	r := l.CellRegion(b)
+	if r == nil {
+		return nil, fmt.Errorf("no display region for touch button %v", b)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("GIF has no frames")
+	}
+
+	p := &GIFPlayer{
+		loupedeck: l,
+		region:    r,
+	}
+	switch {
+	case g.LoopCount < 0:
+		p.loops = 1
+	case g.LoopCount > 0:
+		p.loops = g.LoopCount + 1
+	}
+
+	bg := l.Theme().Background
+	var end time.Duration
+	for i, im := range composeGIF(g) {
+		p.frames = append(p.frames, scaleImage(im, r.width, r.height, FitContain, bg))
+		delay := defaultGIFDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		end += delay
+		p.ends = append(p.ends, end)
+	}
+
+	p.Start()
+	return p, nil
+}
+
+// Start starts playing the GIF from the beginning, stopping it
+// first if it's already playing.
+func (p *GIFPlayer) Start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.animation != nil {
+		p.animation.Stop()
+	}
+	shown := -1
+	p.animation = p.loupedeck.Animate(p.region, func(_ int, elapsed time.Duration) image.Image {
+		i := p.frameAt(elapsed)
+		if i == shown {
+			return nil
+		}
+		shown = i
+		return p.frames[i]
+	})
+}
+
+// Stop stops playing the GIF, leaving the current frame on the
+// button.
+func (p *GIFPlayer) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.animation != nil {
+		p.animation.Stop()
+		p.animation = nil
+	}
+}
+
+// frameAt returns the index of the frame to show at elapsed into
+// playback.
+func (p *GIFPlayer) frameAt(elapsed time.Duration) int {
+	total := p.ends[len(p.ends)-1]
+	if p.loops > 0 && elapsed >= total*time.Duration(p.loops) {
+		return len(p.frames) - 1
+	}
+	t := elapsed % total
+	return sort.Search(len(p.ends), func(i int) bool { return p.ends[i] > t })
+}
+
+// composeGIF returns the full picture shown for each frame of g.  GIF
+// frames are often just the part of the picture that changed, drawn
+// over what came before according to each frame's disposal method.
+func composeGIF(g *gif.GIF) []*image.RGBA {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		for _, im := range g.Image {
+			bounds = bounds.Union(im.Bounds())
+		}
+	}
+
+	canvas := image.NewRGBA(bounds)
+	var frames []*image.RGBA
+	for i, im := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, im.Bounds(), im, im.Bounds().Min, draw.Over)
+		frame := image.NewRGBA(bounds)
+		copy(frame.Pix, canvas.Pix)
+		frames = append(frames, frame)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, im.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames
+}
@@ -0,0 +1,69 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+// twoFrameGIF returns a 4x4 GIF whose first frame is all red and
+// whose second frame only covers the top left pixel with green.
+func twoFrameGIF(disposal byte) *gif.GIF {
+	p := color.Palette{color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), p)
+	second := image.NewPaletted(image.Rect(0, 0, 1, 1), p)
+	second.Pix[0] = 1
+	return &gif.GIF{
+		Image:    []*image.Paletted{first, second},
+		Delay:    []int{10, 20},
+		Disposal: []byte{disposal, 0},
+		Config:   image.Config{Width: 4, Height: 4},
+	}
+}
+
+func TestComposeGIF(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+
+	frames := composeGIF(twoFrameGIF(gif.DisposalNone))
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if got := frames[1].RGBAAt(0, 0); got != green {
+		t.Errorf("frame 1 at 0,0 is %v, want %v", got, green)
+	}
+	if got := frames[1].RGBAAt(3, 3); got != red {
+		t.Errorf("frame 1 at 3,3 is %v, want %v (kept from frame 0)", got, red)
+	}
+
+	frames = composeGIF(twoFrameGIF(gif.DisposalBackground))
+	if got := frames[1].RGBAAt(3, 3); got.A != 0 {
+		t.Errorf("frame 1 at 3,3 is %v, want transparent", got)
+	}
+}
+
+func TestGIFPlayerFrameAt(t *testing.T) {
+	p := &GIFPlayer{
+		frames: make([]*image.RGBA, 2),
+		ends:   []time.Duration{100 * time.Millisecond, 300 * time.Millisecond},
+		loops:  2,
+	}
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 0},
+		{99 * time.Millisecond, 0},
+		{100 * time.Millisecond, 1},
+		{350 * time.Millisecond, 0},
+		{450 * time.Millisecond, 1},
+		{time.Second, 1}, // finished; stays on the last frame
+	}
+	for _, tc := range tests {
+		if got := p.frameAt(tc.elapsed); got != tc.want {
+			t.Errorf("frameAt(%v) = %d, want %d", tc.elapsed, got, tc.want)
+		}
+	}
+}
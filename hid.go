@@ -0,0 +1,192 @@
+package loupedeck
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// UnsupportedError is returned when the connected device can't do
+// what was asked, like drawing on a Loupedeck+, which has no
+// displays.
+type UnsupportedError struct {
+	Op    string
+	Model string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by %s", e.Op, e.Model)
+}
+
+// loupedeckPlusProfile describes the Loupedeck+, which has buttons
+// and knobs but no displays or touchscreen.
+var loupedeckPlusProfile = &DeviceProfile{
+	Name: "Loupedeck+",
+}
+
+// HID report types, as decoded by hidConn.  The Loupedeck+ report
+// layout isn't documented, and this hasn't been checked against real
+// hardware, which is why HID support is experimental and off unless
+// asked for (see WithExperimentalHID).  It's a guess:
+//
+//	byte 0: report type (hidReportButton or hidReportKnob)
+//	byte 1: control ID, matching the Button and Knob constants
+//	byte 2: for buttons, 1 for pressed and 0 for released; for
+//	        knobs, the signed number of clicks turned
+//
+// If your device reports something different, please send a patch.
+const (
+	hidReportButton = 0x01
+	hidReportKnob   = 0x02
+)
+
+// hidConn reads input reports from a Loupedeck+ via Linux's hidraw
+// interface, and translates them into the same messages that the
+// serial Loupedecks send, so that Listen and all of the Bind
+// functions work unchanged.  It can't send anything, since the
+// Loupedeck+ has nothing for us to control.
+type hidConn struct {
+	file    *os.File
+	logger  *slog.Logger
+	mutex   sync.Mutex
+	pending [][]byte
+}
+
+// ReadMessage returns the next translated input message.
+func (h *hidConn) ReadMessage() (int, []byte, error) {
+	buf := make([]byte, 64)
+	for {
+		h.mutex.Lock()
+		if len(h.pending) > 0 {
+			m := h.pending[0]
+			h.pending = h.pending[1:]
+			h.mutex.Unlock()
+			return binaryMessage, m, nil
+		}
+		h.mutex.Unlock()
+
+		n, err := h.file.Read(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		msgs := decodeHIDReport(buf[:n])
+		if msgs == nil {
+			h.logger.Debug("Ignoring unknown HID report", "report", buf[:n])
+		}
+		h.mutex.Lock()
+		h.pending = append(h.pending, msgs...)
+		h.mutex.Unlock()
+	}
+}
+
+// WriteMessage always fails, since the Loupedeck+ can't be sent
+// anything.
+func (h *hidConn) WriteMessage(int, []byte) error {
+	return &UnsupportedError{Op: "sending commands", Model: loupedeckPlusProfile.Name}
+}
+
+// Close closes the hidraw device.
+func (h *hidConn) Close() error {
+	return h.file.Close()
+}
+
+// decodeHIDReport translates a single HID report into zero or more
+// Loupedeck protocol messages.  Reports it doesn't understand give
+// nil.
+func decodeHIDReport(r []byte) [][]byte {
+	if len(r) < 3 {
+		return nil
+	}
+	switch r[0] {
+	case hidReportButton:
+		status := ButtonStatus(ButtonUp)
+		if r[2] != 0 {
+			status = ButtonDown
+		}
+		return [][]byte{{5, byte(ButtonPress), 0, r[1], byte(status)}}
+	case hidReportKnob:
+		// The delta is signed, just like in KnobRotate messages,
+		// so it can be passed straight through.
+		return [][]byte{{5, byte(KnobRotate), 0, r[1], r[2]}}
+	}
+	return nil
+}
+
+// hidInterface is the USB interface that findHIDDevice expects the
+// Loupedeck+'s input reports on.
+const hidInterface = "input0"
+
+// findHIDDevice looks for a Loupedeck with the specified USB product
+// ID in /sys/class/hidraw, returning the path to the hidraw device for
+// its first HID interface along with its USB vendor and product IDs.
+// Other products and interfaces are skipped, so that other devices
+// from the same vendor aren't mistaken for it.
+func findHIDDevice(want string) (path, vendor, product string, err error) {
+	want = strings.ToLower(want)
+	uevents, err := filepath.Glob("/sys/class/hidraw/*/device/uevent")
+	if err != nil || len(uevents) == 0 {
+		return "", "", "", fmt.Errorf("no hidraw devices found")
+	}
+	for _, u := range uevents {
+		data, err := os.ReadFile(u)
+		if err != nil {
+			continue
+		}
+		var vid, pid, phys string
+		for _, line := range strings.Split(string(data), "\n") {
+			// HID_ID=0003:00002EC2:00000001
+			if id, ok := strings.CutPrefix(line, "HID_ID="); ok {
+				parts := strings.Split(strings.ToLower(id), ":")
+				if len(parts) == 3 && len(parts[1]) >= 4 && len(parts[2]) >= 4 {
+					vid = parts[1][len(parts[1])-4:]
+					pid = parts[2][len(parts[2])-4:]
+				}
+			}
+			// HID_PHYS=usb-0000:00:14.0-1/input0
+			if p, ok := strings.CutPrefix(line, "HID_PHYS="); ok {
+				phys = p
+			}
+		}
+		if vid == "2ec2" && pid == want && strings.HasSuffix(phys, "/"+hidInterface) {
+			name := filepath.Base(filepath.Dir(filepath.Dir(u)))
+			return filepath.Join("/dev", name), vid, pid, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("no Loupedeck HID device with product ID %s found", want)
+}
+
+// ConnectHID connects to a Loupedeck+ through its hidraw device.  The
+// returned Loupedeck delivers button and knob events through the
+// usual Bind functions once Listen is called.  It has no displays,
+// and anything that would send a command to the device returns an
+// UnsupportedError.
+//
+// This is experimental: the report decoding is a guess that hasn't
+// been verified on hardware, so ConnectAuto only tries it when asked
+// to with WithExperimentalHID.  It only works on Linux, and the user
+// needs read access to the hidraw device.
+func ConnectHID(path, vendor, product string) (*Loupedeck, error) {
+	return connectHID(path, vendor, product, newConnectOptions(nil))
+}
+
+func connectHID(path, vendor, product string, o *connectOptions) (*Loupedeck, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open HID device %q: %v", path, err)
+	}
+	h := &hidConn{file: f}
+	l := newLoupedeck(h, nil, vendor, product)
+	l.logger = o.logger
+	h.logger = l.log()
+	l.hid = true
+	l.detectModel()
+	if err := l.SetDefaultFont(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Unable to set default font: %v", err)
+	}
+	l.log().Warn("Found Loupedeck+; HID support is experimental", "path", path, "vendor", vendor, "product", product)
+	return l, nil
+}
@@ -0,0 +1,76 @@
+package loupedeck
+
+import (
+	"sync"
+)
+
+// Direction says which way a message is going.  See AddMessageHook.
+type Direction int
+
+const (
+	// Inbound messages come from the Loupedeck.
+	Inbound Direction = iota
+	// Outbound messages are sent to the Loupedeck.
+	Outbound
+)
+
+// String returns a human-readable name for the Direction.
+func (d Direction) String() string {
+	if d == Outbound {
+		return "outbound"
+	}
+	return "inbound"
+}
+
+// MessageHook is a function signature used for message hooks.  See
+// AddMessageHook.
+type MessageHook func(Direction, *Message)
+
+// hookState holds the message hooks.
+type hookState struct {
+	mutex sync.RWMutex
+	hooks []MessageHook
+}
+
+// AddMessageHook adds a hook that's called with every message sent to
+// or received from the Loupedeck, in the order that hooks were added.
+// Hooks can just watch, for logging message types that the library
+// doesn't know about, or they can change messages with SetType and
+// SetData.  Changes to inbound messages are seen by all of the
+// bindings; changes to outbound messages are what's actually sent.
+//
+// Inbound hooks are called from the Listen goroutine, before the
+// message is dispatched.  Outbound hooks are called from whichever
+// goroutine is sending, so they need to be safe for concurrent use.
+func (l *Loupedeck) AddMessageHook(h MessageHook) {
+	l.hooks.mutex.Lock()
+	defer l.hooks.mutex.Unlock()
+	l.hooks.hooks = append(l.hooks.hooks, h)
+}
+
+// runHooks calls the message hooks for m.  It returns true if there
+// were any, in which case m may have changed.
+func (l *Loupedeck) runHooks(d Direction, m *Message) bool {
+	l.hooks.mutex.RLock()
+	hooks := l.hooks.hooks
+	l.hooks.mutex.RUnlock()
+	for _, h := range hooks {
+		h(d, m)
+	}
+	return len(hooks) > 0
+}
+
+// SetType changes the message's type.
+func (m *Message) SetType(t MessageType) {
+	m.messageType = t
+}
+
+// SetData replaces the message's payload, updating its length.
+func (m *Message) SetData(data []byte) {
+	length := len(data) + 3
+	if length > maxFrameLength {
+		length = maxFrameLength
+	}
+	m.data = data
+	m.length = byte(length)
+}
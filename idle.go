@@ -0,0 +1,209 @@
+package loupedeck
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IdleEvent describes a change in whether the Loupedeck is being
+// used.  See OnIdle.
+type IdleEvent int
+
+const (
+	// IdleStarted means that no input has arrived for the
+	// watcher's timeout.
+	IdleStarted IdleEvent = iota
+	// IdleEnded means that input has arrived again after
+	// IdleStarted.
+	IdleEnded
+)
+
+// String returns a human-readable name for the IdleEvent.
+func (e IdleEvent) String() string {
+	switch e {
+	case IdleStarted:
+		return "idle started"
+	case IdleEnded:
+		return "idle ended"
+	}
+	return fmt.Sprintf("IdleEvent(%d)", int(e))
+}
+
+// IdleFunc is a function signature used for callbacks on idle events.
+type IdleFunc func(IdleEvent)
+
+// idleWatcher is a single callback registered with OnIdle.
+type idleWatcher struct {
+	mutex   sync.Mutex
+	timeout time.Duration
+	f       IdleFunc
+	idle    bool
+	cancel  func()
+}
+
+// OnIdle calls f with IdleStarted once timeout has passed without any
+// input (buttons, knobs, or touches), and with IdleEnded when input
+// arrives again.  This is the building block for screensavers, idle
+// pages, dimming the display, and so on; any number of watchers, with
+// different timeouts, can be registered at once.
+//
+// IdleStarted is called from a background goroutine, and IdleEnded is
+// called from the Listen goroutine before the input that ended the
+// idle period is dispatched.  Calling the returned function removes
+// the watcher.
+func (l *Loupedeck) OnIdle(timeout time.Duration, f IdleFunc) (cancel func()) {
+	w := &idleWatcher{timeout: timeout, f: f}
+	stop := make(chan struct{})
+	var once sync.Once
+	w.cancel = func() {
+		once.Do(func() {
+			close(stop)
+			l.idleMutex.Lock()
+			defer l.idleMutex.Unlock()
+			for i, o := range l.idleWatchers {
+				if o == w {
+					l.idleWatchers = append(l.idleWatchers[:i], l.idleWatchers[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+
+	l.idleMutex.Lock()
+	l.idleWatchers = append(l.idleWatchers, w)
+	l.idleMutex.Unlock()
+	if l.lastInput.Load() == 0 {
+		l.lastInput.Store(time.Now().UnixNano())
+	}
+
+	go func() {
+		check := timeout / 10
+		if check < 100*time.Millisecond {
+			check = 100 * time.Millisecond
+		}
+		ticker := time.NewTicker(check)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				w.mutex.Lock()
+				started := !w.idle && l.idleFor() > timeout
+				if started {
+					w.idle = true
+				}
+				w.mutex.Unlock()
+				if started {
+					f(IdleStarted)
+				}
+			}
+		}
+	}()
+
+	return w.cancel
+}
+
+// stopIdleWatchers removes all of the watchers added by OnIdle.
+func (l *Loupedeck) stopIdleWatchers() {
+	l.idleMutex.Lock()
+	watchers := append([]*idleWatcher{}, l.idleWatchers...)
+	l.idleMutex.Unlock()
+	for _, w := range watchers {
+		w.cancel()
+	}
+}
+
+// Idle returns how long it has been since the last input event.
+func (l *Loupedeck) Idle() time.Duration {
+	return l.idleFor()
+}
+
+// noteInput records that an input event (button, knob, or touch) has
+// arrived, ending any idle periods.  It returns true if the event
+// should be discarded, which happens when the event's only purpose
+// was to unblank a blanked Loupedeck.
+func (l *Loupedeck) noteInput(t MessageType) bool {
+	l.lastInput.Store(time.Now().UnixNano())
+
+	l.idleMutex.Lock()
+	watchers := append([]*idleWatcher{}, l.idleWatchers...)
+	l.idleMutex.Unlock()
+	for _, w := range watchers {
+		w.mutex.Lock()
+		ended := w.idle
+		w.idle = false
+		w.mutex.Unlock()
+		if ended {
+			w.f(IdleEnded)
+		}
+	}
+
+	if l.unblankOnInput() {
+		return true
+	}
+	if l.inputFilter != nil {
+		return l.inputFilter(t)
+	}
+	return false
+}
+
+// idleFor returns how long it has been since the last input event.
+func (l *Loupedeck) idleFor() time.Duration {
+	return time.Since(time.Unix(0, l.lastInput.Load()))
+}
+
+// SetIdlePage makes the Pager switch to page after timeout has passed
+// without any input.  The idle page could show a clock, or what's
+// currently playing, or anything else that's useful at a distance.
+// The next input event (of any kind) switches back to the page that
+// was shown before; that event is otherwise ignored, so waking the
+// Loupedeck up doesn't accidentally trigger anything.
+//
+// Passing a nil page turns off the idle page.
+func (p *Pager) SetIdlePage(page *Page, timeout time.Duration) {
+	l := p.loupedeck
+	if p.idleCancel != nil {
+		p.idleCancel()
+		p.idleCancel = nil
+		l.inputFilter = nil
+	}
+	if page == nil {
+		return
+	}
+
+	l.lastInput.Store(time.Now().UnixNano())
+
+	swallowing := false
+	l.inputFilter = func(t MessageType) bool {
+		if swallowing {
+			// Ignore the rest of the touch that woke us up.
+			if t == TouchEnd || t == TouchEndCT {
+				swallowing = false
+			}
+			return true
+		}
+		if !p.idle {
+			return false
+		}
+		p.idle = false
+		p.show(p.active, SwipeRight)
+		swallowing = t == Touch || t == TouchCT
+		return true
+	}
+
+	p.idleCancel = l.OnIdle(timeout, func(e IdleEvent) {
+		if e != IdleStarted {
+			return
+		}
+		// IdleStarted arrives on a background goroutine, but the
+		// Pager belongs to Listen.
+		l.post(func() {
+			if !p.idle {
+				p.idle = true
+				p.switchTo(page, SwipeLeft, false)
+			}
+		})
+	})
+}
@@ -108,6 +108,9 @@ type KnobFunc func(Knob, int)
 type TouchButton uint16
 
 const (
+	// TouchNone is reported for touches that don't land on any
+	// button, such as the blank margins of the Loupedeck Live S.
+	TouchNone TouchButton = 0
 	// TouchLeft indicates that the left touchscreen area, near the leftmost knobs has been touched.
 	TouchLeft TouchButton = 1
 	// TouchRight indicates that hte right touchscreen area, near the rightmost knobs has been touched.
@@ -124,6 +127,12 @@ const (
 	Touch10    = 12
 	Touch11    = 13
 	Touch12    = 14
+
+	// Touch13 through Touch15 only exist on devices with 5x3 touch
+	// grids, like the Loupedeck Live S.
+	Touch13 = 15
+	Touch14 = 16
+	Touch15 = 17
 )
 
 // TouchFunc is a function signature used for callbacks on TouchButton
@@ -150,21 +159,83 @@ const (
 	DragDone  DragEvent = 2
 )
 
+// liveTouchGrid is the touchscreen layout of the Loupedeck Live and
+// the devices derived from it: a 60 pixel strip on each side, next
+// to the knobs, with a 4x3 grid of buttons in between.
+var liveTouchGrid = TouchGrid{StripWidth: 60, Columns: 4, Rows: 3, CellSize: 90}
+
+// liveSTouchGrid is the touchscreen layout of the Loupedeck Live S:
+// a 5x3 grid of buttons, with a 15 pixel dead margin on each side and
+// no knob strips.
+var liveSTouchGrid = TouchGrid{Margin: 15, Columns: 5, Rows: 3, CellSize: 90}
+
+// touchGrid returns the touch grid of the connected device, or the
+// Loupedeck Live's if no profile has been set.
+func (l *Loupedeck) touchGrid() TouchGrid {
+	if l.profile != nil {
+		return l.profile.Touch
+	}
+	return liveTouchGrid
+}
+
+// GridSize returns the number of columns and rows in the connected
+// device's grid of touch buttons: 4x3 on the Loupedeck Live and CT,
+// and 5x3 on the Live S.
+func (l *Loupedeck) GridSize() (cols, rows int) {
+	g := l.touchGrid()
+	return g.Columns, g.Rows
+}
+
+// TouchButtons returns the TouchButtons in the connected device's
+// touch grid, in order, not including TouchLeft and TouchRight.
+func (l *Loupedeck) TouchButtons() []TouchButton {
+	cols, rows := l.GridSize()
+	buttons := make([]TouchButton, cols*rows)
+	for i := range buttons {
+		buttons[i] = TouchButton(int(Touch1) + i)
+	}
+	return buttons
+}
+
 // touchCoordToButton translates an x,y coordinate on the
-// touchscreen to a TouchButton.
-func touchCoordToButton(x, y uint16) TouchButton {
+// touchscreen to a TouchButton, using the device profile's touch
+// grid (or the Loupedeck Live's, if no profile has been set).
+// Coordinates outside of the grid and strips return TouchNone.
+func (l *Loupedeck) touchCoordToButton(x, y uint16) TouchButton {
+	g := l.touchGrid()
+	left := g.StripWidth + g.Margin
+	right := left + g.Columns*g.CellSize
+	px, py := int(x), int(y)
+
 	switch {
-	case x < 60:
+	case g.StripWidth > 0 && px < g.StripWidth:
 		return TouchLeft
-	case x >= 420:
+	case g.StripWidth > 0 && px >= right:
 		return TouchRight
+	case px < left || px >= right || py >= g.Rows*g.CellSize:
+		return TouchNone
 	}
 
-	x -= 60
-	x /= 90
-	y /= 90
+	return l.gridButton((px-left)/g.CellSize, py/g.CellSize)
+}
+
+// cellXY returns the upper left corner of a TouchButton's cell,
+// relative to the main display.
+func (l *Loupedeck) cellXY(b TouchButton) (int, int) {
+	cols, rows := l.GridSize()
+	size := l.touchGrid().CellSize
+	i := int(b) - int(Touch1)
+	if i < 0 || i >= cols*rows {
+		return 0, 0
+	}
+	return (i % cols) * size, (i / cols) * size
+}
 
-	return TouchButton(uint16(Touch1) + x + 4*y)
+// gridButton returns the TouchButton at a given column and row of the
+// grid.
+func (l *Loupedeck) gridButton(col, row int) TouchButton {
+	cols, _ := l.GridSize()
+	return TouchButton(int(Touch1) + row*cols + col)
 }
 
 // BindButton sets a callback for actions on a specific
@@ -206,3 +277,75 @@ func (l *Loupedeck) BindTouchUp(b TouchButton, f TouchFunc) {
 func (l *Loupedeck) BindTouchCT(f TouchDKFunc) {
 	l.touchDKBindings = f
 }
+
+// savedBindings holds a complete set of input bindings, so that they
+// can be temporarily replaced and later restored.
+type savedBindings struct {
+	buttons     map[Button]ButtonFunc
+	buttonsUp   map[Button]ButtonFunc
+	knobs       map[Knob]KnobFunc
+	knobEvents  map[Knob]KnobEventFunc
+	touches     map[TouchButton]TouchFunc
+	touchesUp   map[TouchButton]TouchFunc
+	touchDK     TouchDKFunc
+	touchEvents []TouchEventFunc
+	mappings    map[Control]Mapping
+	swipe       swipeState
+	vibration   VibrationPattern
+	inputFilter func(MessageType) bool
+}
+
+// suspendBindings removes all input bindings, returning them so that
+// they can be put back later with restoreBindings.  This includes
+// everything that reacts to input: control mappings, swipe, fling,
+// and drag callbacks (along with any touch that's being tracked for
+// them), touch event callbacks, the touch vibration, and the idle
+// page's input filter.
+func (l *Loupedeck) suspendBindings() savedBindings {
+	saved := savedBindings{
+		buttons:     l.buttonBindings,
+		buttonsUp:   l.buttonUpBindings,
+		knobs:       l.knobBindings,
+		knobEvents:  l.knobEventBindings,
+		touches:     l.touchBindings,
+		touchesUp:   l.touchUpBindings,
+		touchDK:     l.touchDKBindings,
+		touchEvents: l.touchEventBindings,
+		mappings:    l.suspendMappings(),
+		swipe:       l.swipe,
+		vibration:   l.touchVibration,
+		inputFilter: l.inputFilter,
+	}
+	l.buttonBindings = make(map[Button]ButtonFunc)
+	l.buttonUpBindings = make(map[Button]ButtonFunc)
+	l.knobBindings = make(map[Knob]KnobFunc)
+	l.knobEventBindings = nil
+	l.touchBindings = make(map[TouchButton]TouchFunc)
+	l.touchUpBindings = make(map[TouchButton]TouchFunc)
+	l.touchDKBindings = nil
+	l.touchEventBindings = nil
+	l.swipe = swipeState{}
+	l.touchVibration = VibrationNone
+	l.inputFilter = nil
+	return saved
+}
+
+// restoreBindings replaces all input bindings with a set previously
+// returned by suspendBindings.
+func (l *Loupedeck) restoreBindings(saved savedBindings) {
+	l.buttonBindings = saved.buttons
+	l.buttonUpBindings = saved.buttonsUp
+	l.knobBindings = saved.knobs
+	l.knobEventBindings = saved.knobEvents
+	l.touchBindings = saved.touches
+	l.touchUpBindings = saved.touchesUp
+	l.touchDKBindings = saved.touchDK
+	l.touchEventBindings = saved.touchEvents
+	l.restoreMappings(saved.mappings)
+	// Drop whatever touch was in progress when the modal closed;
+	// it belongs to the modal, not to the restored bindings.
+	l.swipe = saved.swipe
+	l.swipe.started = false
+	l.touchVibration = saved.vibration
+	l.inputFilter = saved.inputFilter
+}
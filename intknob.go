@@ -16,6 +16,17 @@
 
 package loupedeck
 
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+)
+
+// resetConfirmDuration is how long the confirmation toast is shown
+// after a hold-to-reset.
+const resetConfirmDuration = time.Second
+
 // IntKnob is an abstraction over the Loupedeck Live's Knobs.
 // The IntKnob turns left/right dial actions into incrememnting and
 // decrementing an integer within a specified range.  In addition, the
@@ -25,8 +36,33 @@ type IntKnob struct {
 	watchedint *WatchedInt
 	min        int
 	max        int
+	loupedeck  *Loupedeck
+	coarse     int
+	fine       int
+	fineMode   bool
+	resetTimer *time.Timer
+	bindings   *BindingSet
+	def        int
+	muted      bool
+	unmuted    int // value to restore when unmuting
 }
 
+// KnobPressAction selects what happens when an IntKnob is clicked.
+type KnobPressAction int
+
+const (
+	// PressZero sets the value to 0.  This is the default.
+	PressZero KnobPressAction = iota
+	// PressNothing ignores clicks.
+	PressNothing
+	// PressDefault sets the value to the default set with
+	// SetDefault.
+	PressDefault
+	// PressMute toggles between the minimum value and whatever the
+	// value was before muting.
+	PressMute
+)
+
 // Get returns the current value of the IntKnob.
 func (k *IntKnob) Get() int {
 	return k.watchedint.Get()
@@ -35,12 +71,6 @@ func (k *IntKnob) Get() int {
 // Set sets the current value of the IntKnob, triggering any
 // callbacks set on the WatchedInt that underlies the IntKnob.
 func (k *IntKnob) Set(v int) {
-	if v < k.min {
-		v = k.min
-	}
-	if v > k.max {
-		v = k.max
-	}
 	k.watchedint.Set(v)
 }
 
@@ -48,22 +78,15 @@ func (k *IntKnob) Set(v int) {
 // IntKnob by a specified amount.  This triggers a callback on the
 // WatchedInt that underlies the IntKnob.
 func (k *IntKnob) Inc(v int) {
-	x := k.watchedint.Get()
-	x += v
-	if x < k.min {
-		x = k.min
-	}
-	if x > k.max {
-		x = k.max
-	}
-	k.watchedint.Set(x)
+	k.watchedint.inc(v)
 }
 
 // IntKnob implements a generic dial knob using the specified
 // Loupedeck Knob.  It binds the dial function of the knob to
 // increase/decrease the IntKnob's value and binds the button function
 // of the knob to reset the value to 0.  Basically, spin the dial and
-// it changes, and click and it resets.
+// it changes, and click and it resets.  See SetHoldToReset for a
+// safer alternative.
 func (l *Loupedeck) IntKnob(k Knob, min int, max int, watchedint *WatchedInt) *IntKnob {
 	i8k := &IntKnob{
 		knob:       k,
@@ -71,13 +94,178 @@ func (l *Loupedeck) IntKnob(k Knob, min int, max int, watchedint *WatchedInt) *I
 		min:        min,
 		max:        max,
 	}
-	l.BindKnob(k, func(k Knob, v int) {
-		i8k.Inc(v)
+	watchedint.SetRange(min, max)
+	i8k.loupedeck = l
+	i8k.bindings = l.NewBindingSet()
+	i8k.bindings.BindKnob(k, func(k Knob, v int) {
+		i8k.Inc(v * i8k.step())
 	})
-	l.BindButton(Button(k), func(b Button, s ButtonStatus) {
+	i8k.bindings.BindButton(Button(k), func(b Button, s ButtonStatus) {
 		if s == ButtonDown {
 			i8k.Set(0)
 		}
 	})
+	i8k.bindings.Apply()
 	return i8k
 }
+
+// SetDefault sets the value used by PressDefault.
+func (k *IntKnob) SetDefault(v int) {
+	k.def = v
+}
+
+// SetPressAction changes what happens when the knob is clicked.  Zero
+// is a poor choice for things like color temperature, where
+// PressDefault or PressNothing make more sense.
+//
+// This replaces the knob's button binding, so it can't be combined
+// with SetFineCoarse or SetHoldToReset.
+func (k *IntKnob) SetPressAction(a KnobPressAction) {
+	k.muted = false
+	k.bindings.BindButtonUp(Button(k.knob), nil)
+	k.bindings.BindButton(Button(k.knob), func(b Button, s ButtonStatus) {
+		switch a {
+		case PressZero:
+			k.Set(0)
+		case PressDefault:
+			k.Set(k.def)
+		case PressMute:
+			k.toggleMute()
+		}
+	})
+}
+
+// toggleMute switches between the minimum value and the value from
+// before muting.  If the value has changed since muting, then the
+// knob is considered to already be unmuted.
+func (k *IntKnob) toggleMute() {
+	if k.muted && k.Get() == k.min {
+		k.muted = false
+		k.Set(k.unmuted)
+		return
+	}
+	k.muted = true
+	k.unmuted = k.Get()
+	k.Set(k.min)
+}
+
+// Destroy removes the IntKnob's bindings from the knob, so that it
+// can be replaced by something else.
+func (k *IntKnob) Destroy() {
+	k.bindings.Remove()
+	if k.resetTimer != nil {
+		k.resetTimer.Stop()
+		k.resetTimer = nil
+	}
+}
+
+// step returns the amount that the IntKnob changes for each click of
+// rotation.
+func (k *IntKnob) step() int {
+	switch {
+	case k.fineMode && k.fine != 0:
+		return k.fine
+	case !k.fineMode && k.coarse != 0:
+		return k.coarse
+	}
+	return 1
+}
+
+// SetFineCoarse switches the IntKnob into fine/coarse mode.  Each
+// click of rotation changes the value by coarse, until the knob is
+// pressed, after which it changes by fine.  Pressing the knob again
+// switches back to coarse.  While in fine mode, a small dot is shown
+// in the upper left corner of the display next to the knob.
+//
+// This replaces the default click-to-zero behavior, which is
+// dangerous for things like live lighting levels.
+func (k *IntKnob) SetFineCoarse(coarse, fine int) {
+	k.coarse = coarse
+	k.fine = fine
+	k.fineMode = false
+	k.bindings.BindButton(Button(k.knob), func(b Button, s ButtonStatus) {
+		if s == ButtonDown {
+			k.fineMode = !k.fineMode
+			k.drawIndicator()
+		}
+	})
+}
+
+// SetHoldToReset makes the IntKnob only reset its value to 0 when
+// the knob is held down for at least hold, instead of as soon as it's
+// clicked.  A short toast confirms the reset.  Releasing the knob
+// early does nothing, so accidental clicks are harmless.  A hold of 0
+// restores the default click-to-zero behavior.
+//
+// This replaces the knob's button binding, so it can't be combined
+// with SetFineCoarse.
+func (k *IntKnob) SetHoldToReset(hold time.Duration) {
+	l := k.loupedeck
+	if hold <= 0 {
+		k.bindings.BindButton(Button(k.knob), func(b Button, s ButtonStatus) {
+			k.Set(0)
+		})
+		k.bindings.BindButtonUp(Button(k.knob), nil)
+		return
+	}
+
+	k.bindings.BindButton(Button(k.knob), func(b Button, s ButtonStatus) {
+		if k.resetTimer != nil {
+			k.resetTimer.Stop()
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(hold, func() {
+			// Knob events arrive on the Listen goroutine, so
+			// reset from there too.
+			l.post(func() {
+				if k.resetTimer != timer {
+					// Released (or pressed again) in
+					// the meantime.
+					return
+				}
+				k.resetTimer = nil
+				k.Set(0)
+				_ = l.ShowToast(fmt.Sprintf("Knob %d reset", k.knob), resetConfirmDuration, ToastInfo)
+			})
+		})
+		k.resetTimer = timer
+	})
+	k.bindings.BindButtonUp(Button(k.knob), func(b Button, s ButtonStatus) {
+		if k.resetTimer != nil {
+			k.resetTimer.Stop()
+			k.resetTimer = nil
+		}
+	})
+}
+
+// Fine returns true if the IntKnob is currently in fine mode.
+func (k *IntKnob) Fine() bool {
+	return k.fineMode
+}
+
+// drawIndicator draws (or clears) the fine mode indicator next to the
+// knob.
+func (k *IntKnob) drawIndicator() {
+	r := k.loupedeck.KnobRegion(k.knob)
+	if r == nil {
+		return
+	}
+	im := image.NewRGBA(image.Rect(0, 0, fineIndicatorSize, fineIndicatorSize))
+	theme := k.loupedeck.Theme()
+	draw.Draw(im, im.Bounds(), &image.Uniform{theme.Background}, image.Point{}, draw.Src)
+	if k.fineMode {
+		drawFineIndicator(im, theme, 0, 0)
+	}
+	r.display.Draw(im, r.x, r.y)
+}
+
+// fineIndicatorSize is the size (in pixels) of the area used for the
+// fine mode indicator.
+const fineIndicatorSize = 8
+
+// drawFineIndicator draws the fine mode indicator into an image with
+// its upper left corner at x,y.
+func drawFineIndicator(im draw.Image, t *Theme, x, y int) {
+	r := image.Rect(x+2, y+2, x+fineIndicatorSize-2, y+fineIndicatorSize-2)
+	draw.Draw(im, r, &image.Uniform{t.Active}, image.Point{}, draw.Src)
+}
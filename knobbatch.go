@@ -0,0 +1,117 @@
+package loupedeck
+
+import (
+	"sync"
+	"time"
+)
+
+// knobBatch holds knob rotation events that haven't been delivered
+// yet.  See SetKnobBatching.
+type knobBatch struct {
+	mutex   sync.Mutex
+	window  time.Duration
+	payload bool // coalescing the messages from one read; see Listen
+	pending bool
+	knob    Knob
+	delta   int
+	timer   *time.Timer
+}
+
+// SetKnobBatching merges consecutive KnobRotate events for the same
+// knob that arrive within window of the first one, and delivers them
+// to the knob's KnobFunc as a single call with the summed delta.
+// When a knob is spun quickly, the Loupedeck sends dozens of events
+// per second, and batching them cuts down on redraws.  Windows of
+// 10-20ms work well; a window of 0 (the default) turns batching off.
+//
+// A batch is delivered when the window expires, or earlier if any
+// other event arrives, so events are never reordered.  Batches that
+// are delivered because the window expired are delivered from a
+// timer goroutine rather than from Listen.
+//
+// Even without batching, rotations of the same knob that arrive
+// together in a single read from the Loupedeck are always merged,
+// which is what happens when a knob is spun faster than the
+// Loupedeck can send events.
+func (l *Loupedeck) SetKnobBatching(window time.Duration) {
+	l.flushKnobBatch()
+	l.knobBatch.mutex.Lock()
+	defer l.knobBatch.mutex.Unlock()
+	l.knobBatch.window = window
+}
+
+// dispatchKnob delivers a knob rotation to its binding, batching it
+// if batching is enabled.
+func (l *Loupedeck) dispatchKnob(k Knob, v int) {
+	b := &l.knobBatch
+	b.mutex.Lock()
+	if b.window <= 0 && !b.payload {
+		b.mutex.Unlock()
+		l.callKnob(k, v)
+		return
+	}
+	if b.pending && b.knob == k {
+		b.delta += v
+		b.mutex.Unlock()
+		return
+	}
+	b.mutex.Unlock()
+
+	// A different knob moved, so whatever we were holding for the
+	// previous knob needs to go out first.
+	l.flushKnobBatch()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pending = true
+	b.knob = k
+	b.delta = v
+	if b.window > 0 {
+		b.timer = time.AfterFunc(b.window, l.flushKnobBatch)
+	}
+}
+
+// coalesceKnobs turns merging of knob rotations on or off while
+// Listen handles the messages from a single read.  Turning it off
+// delivers whatever was merged, unless SetKnobBatching's timer is
+// going to deliver it anyway.
+func (l *Loupedeck) coalesceKnobs(on bool) {
+	b := &l.knobBatch
+	b.mutex.Lock()
+	b.payload = on
+	flush := !on && b.window <= 0
+	b.mutex.Unlock()
+	if flush {
+		l.flushKnobBatch()
+	}
+}
+
+// flushKnobBatch delivers any pending batched knob rotation.
+func (l *Loupedeck) flushKnobBatch() {
+	b := &l.knobBatch
+	b.mutex.Lock()
+	if !b.pending {
+		b.mutex.Unlock()
+		return
+	}
+	k, v := b.knob, b.delta
+	b.pending = false
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mutex.Unlock()
+
+	l.callKnob(k, v)
+}
+
+// callKnob calls the KnobFunc bound to a knob.
+func (l *Loupedeck) callKnob(k Knob, v int) {
+	if f := l.knobBindings[k]; f != nil {
+		l.call(KnobControl(k), func() { f(k, v) })
+	}
+	if f := l.knobEventBindings[k]; f != nil {
+		e := KnobEvent{Knob: k, Delta: v, Velocity: l.KnobVelocity(k)}
+		l.call(KnobControl(k), func() { f(e) })
+	}
+}
@@ -0,0 +1,35 @@
+package loupedeck
+
+// SetKnobInverted reverses the direction of a knob, so that turning
+// it clockwise reports negative values and counter-clockwise reports
+// positive ones.  This is handy when the Loupedeck is mounted upside
+// down, or for parameters where clockwise-decreases is expected.  It
+// works for the Loupedeck CT's wheel (CTKnob) too.
+//
+// Inversion happens before anything else sees the rotation, so it
+// applies to bindings, mapped actions, and knob batching alike.
+func (l *Loupedeck) SetKnobInverted(k Knob, inverted bool) {
+	if inverted {
+		if l.invertedKnobs == nil {
+			l.invertedKnobs = map[Knob]bool{}
+		}
+		l.invertedKnobs[k] = true
+	} else {
+		delete(l.invertedKnobs, k)
+	}
+}
+
+// KnobInverted returns true if a knob's direction has been reversed
+// with SetKnobInverted.
+func (l *Loupedeck) KnobInverted(k Knob) bool {
+	return l.invertedKnobs[k]
+}
+
+// knobDirection applies any inversion set with SetKnobInverted to a
+// rotation of v clicks.
+func (l *Loupedeck) knobDirection(k Knob, v int) int {
+	if l.invertedKnobs[k] {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package loupedeck
+
+import (
+	"sync"
+	"time"
+)
+
+// knobIdleGap is the longest gap between detents for a knob to still
+// be counted as turning.  After a longer pause, the knob's velocity
+// starts again from scratch.
+const knobIdleGap = 250 * time.Millisecond
+
+// KnobEvent describes a knob rotation, along with how fast the knob is
+// turning.  See BindKnobEvent.
+type KnobEvent struct {
+	Knob Knob
+	// Delta is the number of detents turned, positive for
+	// clockwise.
+	Delta int
+	// Velocity is the knob's recent speed, in detents per second,
+	// positive for clockwise.  It's smoothed over the last few
+	// detents, so a single quick click doesn't look like a fast
+	// spin.
+	Velocity float64
+}
+
+// KnobEventFunc is a function signature used for callbacks on knob
+// events.
+type KnobEventFunc func(KnobEvent)
+
+// knobMotion tracks how fast each knob is turning.
+type knobMotion struct {
+	mutex    sync.Mutex
+	last     map[Knob]time.Time
+	velocity map[Knob]float64
+}
+
+// BindKnobEvent sets a callback for a knob that's called with the
+// rotation velocity as well as the delta, for applications that want
+// to implement their own acceleration or gestures.  It's called in
+// addition to any binding set with BindKnob, and after it.
+func (l *Loupedeck) BindKnobEvent(k Knob, f KnobEventFunc) {
+	if l.knobEventBindings == nil {
+		l.knobEventBindings = map[Knob]KnobEventFunc{}
+	}
+	l.knobEventBindings[k] = f
+}
+
+// KnobVelocity returns how fast a knob is currently turning, in
+// detents per second, positive for clockwise.  It's 0 once the knob
+// has stopped.
+func (l *Loupedeck) KnobVelocity(k Knob) float64 {
+	m := &l.knobMotion
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if time.Since(m.last[k]) > knobIdleGap {
+		return 0
+	}
+	return m.velocity[k]
+}
+
+// trackKnob updates a knob's velocity for a rotation of v detents.
+func (l *Loupedeck) trackKnob(k Knob, v int) {
+	m := &l.knobMotion
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.last == nil {
+		m.last = map[Knob]time.Time{}
+		m.velocity = map[Knob]float64{}
+	}
+
+	now := time.Now()
+	dt := now.Sub(m.last[k])
+	idle := dt > knobIdleGap
+	if idle {
+		dt = knobIdleGap
+	}
+	if dt < time.Millisecond {
+		// Several rotations arrived together.
+		dt = time.Millisecond
+	}
+	instant := float64(v) / dt.Seconds()
+	if idle || (instant > 0) != (m.velocity[k] > 0) {
+		// Starting, or changing direction.
+		m.velocity[k] = instant
+	} else {
+		m.velocity[k] = velocitySmoothing*instant + (1-velocitySmoothing)*m.velocity[k]
+	}
+	m.last[k] = now
+}
@@ -2,114 +2,274 @@ package loupedeck
 
 import (
 	"encoding/binary"
-	"log/slog"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// minMessageLength lists the shortest valid length for each type of
+// input message, so that truncated messages can be dropped instead of
+// crashing the dispatcher.
+var minMessageLength = map[MessageType]int{
+	ButtonPress: 5,
+	KnobRotate:  5,
+	Touch:       9,
+	TouchEnd:    9,
+	TouchCT:     9,
+	TouchEndCT:  9,
+}
+
+// readResult is a single message (or error) read from the Loupedeck.
+type readResult struct {
+	websocketMsgType int
+	payload          []byte
+	err              error
+}
+
 // Listen waits for events from the Loupedeck and calls
-// callbacks as configured.
+// callbacks as configured.  It returns when the connection to the
+// Loupedeck fails; the error is delivered via Errors.  See
+// SetAutoReconnect for surviving the Loupedeck being unplugged.
+//
+// Panics in bindings are recovered and reported via Errors as a
+// PanicError, so one broken binding doesn't stop the rest.  See
+// SetAsync for running slow bindings off the Listen goroutine.
 func (l *Loupedeck) Listen() {
-	slog.Info("Listening")
+	l.log().Info("Listening")
+	done := make(chan struct{})
+	l.listenDone.Store(&done)
+	reads := make(chan readResult)
+	resume := make(chan bool)
+	go l.reader(reads, resume)
+	l.listening.Store(true)
+	defer func() {
+		l.listening.Store(false)
+		close(resume)
+		close(done)
+	}()
+
+	posted := l.postQueue()
 	for {
-		websocketMsgType, message, err := l.conn.ReadMessage()
+		var r readResult
+		select {
+		case r = <-reads:
+		case f := <-posted:
+			l.safeCall("", f)
+			continue
+		}
+		websocketMsgType, payload, err := r.websocketMsgType, r.payload, r.err
 
 		if err != nil {
-			slog.Warn("Read error, exiting", "error", err)
-			// TODO(scottlaird): make this shut down cleanly.
-			panic("Websocket connection failed")
+			if l.closed.Load() {
+				l.log().Info("Connection closed, exiting")
+				return
+			}
+			l.setDisconnected(true, err)
+			if l.reconnect() {
+				l.setDisconnected(false, nil)
+				resume <- true
+				continue
+			}
+			l.log().Warn("Read error, exiting", "error", err)
+			l.reportError(fmt.Errorf("read failed: %v", err))
+			return
 		}
 
-		if len(message) == 0 {
-			slog.Warn("Received a 0-byte message.  Skipping")
+		l.lastReceive.Store(time.Now().UnixNano())
+
+		if len(payload) == 0 {
+			l.log().Warn("Received a 0-byte message.  Skipping")
 			continue
 		}
 
 		if websocketMsgType != websocket.BinaryMessage {
-			slog.Warn("Unknown websocket message type received", "type", websocketMsgType)
+			l.log().Warn("Unknown websocket message type received", "type", websocketMsgType)
+		}
+
+		frames := splitFrames(payload)
+		if len(frames) > 1 {
+			l.coalesceKnobs(true)
+		}
+		for _, message := range frames {
+			l.safeCall("", func() { l.handleMessage(message) })
+		}
+		if len(frames) > 1 {
+			l.coalesceKnobs(false)
 		}
+	}
+}
+
+// reader reads messages from the Loupedeck and hands them to Listen,
+// so that Listen can also run functions posted from other goroutines
+// (see post) while waiting for input.  After an error, it waits to be
+// told whether Listen has reconnected, and keeps reading from the new
+// connection if so.
+func (l *Loupedeck) reader(reads chan<- readResult, resume <-chan bool) {
+	for {
+		conn, _ := l.connection()
+		var r readResult
+		r.websocketMsgType, r.payload, r.err = conn.ReadMessage()
+		reads <- r
+		if r.err != nil {
+			if ok := <-resume; !ok {
+				return
+			}
+		}
+	}
+}
 
-		m, _ := l.ParseMessage(message)
-		slog.Info("Read", "message", m.String())
-
-		if m.transactionID != 0 {
-			if c := l.transactionCallbacks[m.transactionID]; c != nil {
-				slog.Info("Callback found, calling")
-				c(m)
-				l.transactionCallbacks[m.transactionID] = nil
-			}
-		} else {
-
-			switch m.messageType {
-			// Status messages in response to previous commands?
-
-			case ButtonPress:
-				button := Button(binary.BigEndian.Uint16(message[2:]))
-				upDown := ButtonStatus(message[4])
-				if upDown == ButtonDown && l.buttonBindings[button] != nil {
-					l.buttonBindings[button](button, upDown)
-				} else if upDown == ButtonUp && l.buttonUpBindings[button] != nil {
-					l.buttonUpBindings[button](button, upDown)
-				} else {
-					slog.Info("Received uncaught button press message", "button", button, "upDown", upDown, "message", message)
-				}
-			case KnobRotate:
-				knob := Knob(binary.BigEndian.Uint16(message[2:]))
-				value := int(message[4])
-				if l.knobBindings[knob] != nil {
-					v := value
-					if value == 255 {
-						v = -1
-					}
-					l.knobBindings[knob](knob, v)
-				} else {
-					slog.Debug("Received knob rotate message", "knob", knob, "value", value, "message", message)
-				}
-			case Touch:
-				x := binary.BigEndian.Uint16(message[4:])
-				y := binary.BigEndian.Uint16(message[6:])
-				id := message[8] // Not sure what this is for
-				b := touchCoordToButton(x, y)
-
-				if l.touchBindings[b] != nil {
-					l.touchBindings[b](b, ButtonDown, x, y)
-				} else {
-					slog.Debug("Received touch message", "x", x, "y", y, "id", id, "b", b, "message", message)
-				}
-
-			case TouchEnd:
-				x := binary.BigEndian.Uint16(message[4:])
-				y := binary.BigEndian.Uint16(message[6:])
-				id := message[8] // Not sure what this is for
-				b := touchCoordToButton(x, y)
-
-				if l.touchUpBindings[b] != nil {
-					l.touchUpBindings[b](b, ButtonUp, x, y)
-				} else {
-					slog.Debug("Received touch end message", "x", x, "y", y, "id", id, "b", b, "message", message)
-				}
-			case TouchCT:
-				x := binary.BigEndian.Uint16(message[4:])
-				y := binary.BigEndian.Uint16(message[6:])
-				id := message[8] // Not sure what this is for
-				slog.Debug("Received CT touch message", "x", x, "y", y, "id", id, "message", message)
-
-				if l.touchDKBindings != nil {
-					l.touchDKBindings(ButtonDown, x, y)
-				}
-			case TouchEndCT:
-				x := binary.BigEndian.Uint16(message[4:])
-				y := binary.BigEndian.Uint16(message[6:])
-				id := message[8] // Not sure what this is for
-				slog.Debug("Received CT touch message", "x", x, "y", y, "id", id, "message", message)
-
-				if l.touchDKBindings != nil {
-					l.touchDKBindings(ButtonUp, x, y)
-				}
-			default:
-				slog.Info("Received unknown message", "message", m.String())
+// handleMessage decodes a single message from the Loupedeck and
+// dispatches it to the matching transaction callback or binding.
+func (l *Loupedeck) handleMessage(message []byte) {
+	l.captureMessage(Inbound, message)
+	m, err := l.ParseMessage(message)
+	if err != nil {
+		l.reportError(fmt.Errorf("unable to parse message: %v", err))
+		return
+	}
+	if l.runHooks(Inbound, m) {
+		// Dispatch whatever the hooks left, not what arrived.
+		message = m.asBytes()
+	}
+	l.log().Info("Read", "message", m.String())
+	l.sniff("received", message)
+	l.sendRawMessage(m)
+	l.countReceived(m.messageType, len(message))
 
+	if m.transactionID != 0 {
+		l.noteAck(m)
+		if c := l.takeCallback(m.transactionID); c != nil {
+			l.log().Info("Callback found, calling")
+			c(m)
+		}
+	} else {
+		if n := minMessageLength[m.messageType]; len(message) < n {
+			l.reportError(fmt.Errorf("dropping truncated message of type 0x%02x: got %d bytes, want at least %d", byte(m.messageType), len(message), n))
+			return
+		}
+
+		switch m.messageType {
+		case ButtonPress, KnobRotate, Touch, TouchEnd, TouchCT, TouchEndCT:
+			if l.noteInput(m.messageType) {
+				return
+			}
+		}
+
+		if m.messageType != KnobRotate {
+			l.flushKnobBatch()
+		}
+
+		switch m.messageType {
+		case Touch, TouchEnd, TouchCT, TouchEndCT:
+			l.sendTouchEvent(m.messageType, message)
+		}
+
+		switch m.messageType {
+		// Status messages in response to previous commands?
+
+		case ButtonPress:
+			button := l.mapButton(Button(binary.BigEndian.Uint16(message[2:])))
+			upDown := ButtonStatus(message[4])
+			if l.dispatchAction(ActionEvent{Control: ButtonControl(button), Status: upDown}) {
+				return
+			}
+			if f := l.buttonBindings[button]; upDown == ButtonDown && f != nil {
+				l.call(ButtonControl(button), func() { f(button, upDown) })
+			} else if f := l.buttonUpBindings[button]; upDown == ButtonUp && f != nil {
+				l.call(ButtonControl(button), func() { f(button, upDown) })
+			} else if l.profile != nil && !slices.Contains(l.profile.Buttons, button) {
+				l.noteUnknown("button", m.messageType, int(button), message)
+			} else {
+				l.log().Debug("Received uncaught button press message", "button", button, "upDown", upDown, "message", message)
+			}
+		case KnobRotate:
+			knob := l.mapKnob(Knob(binary.BigEndian.Uint16(message[2:])))
+			// The delta is signed; it's usually 1 or -1 (255),
+			// but can be larger when the knob is spun quickly.
+			value := int(message[4])
+			v := int(int8(message[4]))
+			v = l.knobDirection(knob, v)
+			l.trackKnob(knob, v)
+			if l.dispatchAction(ActionEvent{Control: KnobControl(knob), Delta: v}) {
+				return
+			}
+			if l.knobBindings[knob] != nil || l.knobEventBindings[knob] != nil {
+				l.dispatchKnob(knob, v)
+			} else if l.profile != nil && !slices.Contains(l.profile.Knobs, knob) {
+				l.noteUnknown("knob", m.messageType, int(knob), message)
+			} else {
+				l.log().Debug("Received knob rotate message", "knob", knob, "value", value, "message", message)
+			}
+		case Touch:
+			x := binary.BigEndian.Uint16(message[4:])
+			y := binary.BigEndian.Uint16(message[6:])
+			id := message[8] // Touch ID; see TouchEvent
+			b := l.touchCoordToButton(x, y)
+
+			if l.touchDebug != nil {
+				l.drawTouchDebug(b, ButtonDown, x, y)
+			}
+			if !l.swipe.started {
+				l.touchVibrate(b)
+			}
+			l.trackSwipe(ButtonDown, x, y)
+
+			if l.dispatchAction(ActionEvent{Control: TouchControl(b), Status: ButtonDown, X: x, Y: y}) {
+				return
+			}
+			if f := l.touchBindings[b]; f != nil {
+				l.call(TouchControl(b), func() { f(b, ButtonDown, x, y) })
+			} else {
+				l.log().Debug("Received touch message", "x", x, "y", y, "id", id, "b", b, "message", message)
+			}
+
+		case TouchEnd:
+			x := binary.BigEndian.Uint16(message[4:])
+			y := binary.BigEndian.Uint16(message[6:])
+			id := message[8] // Touch ID; see TouchEvent
+			b := l.touchCoordToButton(x, y)
+
+			if l.touchDebug != nil {
+				l.drawTouchDebug(b, ButtonUp, x, y)
+			}
+			l.trackSwipe(ButtonUp, x, y)
+
+			if l.dispatchAction(ActionEvent{Control: TouchControl(b), Status: ButtonUp, X: x, Y: y}) {
+				return
+			}
+			if f := l.touchUpBindings[b]; f != nil {
+				l.call(TouchControl(b), func() { f(b, ButtonUp, x, y) })
+			} else {
+				l.log().Debug("Received touch end message", "x", x, "y", y, "id", id, "b", b, "message", message)
+			}
+		case TouchCT:
+			x := binary.BigEndian.Uint16(message[4:])
+			y := binary.BigEndian.Uint16(message[6:])
+			id := message[8] // Touch ID; see TouchEvent
+			l.log().Debug("Received CT touch message", "x", x, "y", y, "id", id, "message", message)
+
+			if l.touchDKBindings != nil {
+				l.touchDKBindings(ButtonDown, x, y)
+			}
+		case TouchEndCT:
+			x := binary.BigEndian.Uint16(message[4:])
+			y := binary.BigEndian.Uint16(message[6:])
+			id := message[8] // Touch ID; see TouchEvent
+			l.log().Debug("Received CT touch message", "x", x, "y", y, "id", id, "message", message)
+
+			if l.touchDKBindings != nil {
+				l.touchDKBindings(ButtonUp, x, y)
+			}
+		default:
+			if knownMessageType(m.messageType) {
+				// A reply to a command that we know, but
+				// without a transaction ID.
+				l.log().Debug("Received unsolicited reply", "type", m.messageType, "message", m.String())
+				return
 			}
+			l.noteUnknown("message type", m.messageType, 0, message)
 		}
 	}
 }
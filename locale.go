@@ -0,0 +1,134 @@
+package loupedeck
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale describes how numbers are written in a particular place:
+// which characters separate decimals and thousands, and where units
+// go.  Widgets use the Loupedeck's Locale (see SetLocale) when they
+// show values.
+type Locale struct {
+	Name             string
+	DecimalSeparator string
+	GroupSeparator   string
+	// UnitSeparator goes between the number and its unit, like
+	// the space in "50 %".
+	UnitSeparator string
+	// UnitBefore puts the unit before the number instead of
+	// after it.
+	UnitBefore bool
+}
+
+// Locales holds the built-in locales, keyed by BCP 47 language tag.
+// Applications may add their own.
+var Locales = map[string]*Locale{
+	"en-US": {Name: "en-US", DecimalSeparator: ".", GroupSeparator: ","},
+	"en-GB": {Name: "en-GB", DecimalSeparator: ".", GroupSeparator: ","},
+	"de-DE": {Name: "de-DE", DecimalSeparator: ",", GroupSeparator: ".", UnitSeparator: " "},
+	"fr-FR": {Name: "fr-FR", DecimalSeparator: ",", GroupSeparator: " ", UnitSeparator: " "},
+	"es-ES": {Name: "es-ES", DecimalSeparator: ",", GroupSeparator: ".", UnitSeparator: " "},
+	"it-IT": {Name: "it-IT", DecimalSeparator: ",", GroupSeparator: ".", UnitSeparator: " "},
+	"nl-NL": {Name: "nl-NL", DecimalSeparator: ",", GroupSeparator: ".", UnitSeparator: " "},
+	"pt-BR": {Name: "pt-BR", DecimalSeparator: ",", GroupSeparator: ".", UnitSeparator: " "},
+	"sv-SE": {Name: "sv-SE", DecimalSeparator: ",", GroupSeparator: " ", UnitSeparator: " "},
+	"de-CH": {Name: "de-CH", DecimalSeparator: ".", GroupSeparator: "'", UnitSeparator: " "},
+	"ja-JP": {Name: "ja-JP", DecimalSeparator: ".", GroupSeparator: ","},
+}
+
+// defaultLocale is used until SetLocale is called.
+var defaultLocale = Locales["en-US"]
+
+// ValueFormat describes how a widget shows its value.
+type ValueFormat struct {
+	// Decimals is the number of decimal places in the value.
+	// Widget values are ints, so a Decimals of 1 shows a value
+	// of 215 as "21.5", for things like temperatures.
+	Decimals int
+	// Unit, if set, is shown next to the value, like "%" or "K".
+	Unit string
+	// Group, if true, separates groups of thousands with the
+	// locale's GroupSeparator.  It's off by default, since the
+	// extra characters often don't fit on the Loupedeck's narrow
+	// strips.
+	Group bool
+}
+
+// SetLocale selects one of the Locales by language tag, like "de-DE".
+// Widgets that are drawn afterwards format their values for that
+// locale.
+func (l *Loupedeck) SetLocale(name string) error {
+	loc := Locales[name]
+	if loc == nil {
+		names := make([]string, 0, len(Locales))
+		for n := range Locales {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown locale %q, want one of %v", name, names)
+	}
+	l.locale = loc
+	return nil
+}
+
+// Locale returns the Locale in use.
+func (l *Loupedeck) Locale() *Locale {
+	if l.locale == nil {
+		return defaultLocale
+	}
+	return l.locale
+}
+
+// FormatValue formats a widget value using the Loupedeck's Locale.
+func (l *Loupedeck) FormatValue(v int, f ValueFormat) string {
+	return l.Locale().Format(v, f)
+}
+
+// Format formats v, which is in units of 10^-f.Decimals, with f's
+// unit (if any).
+func (loc *Locale) Format(v int, f ValueFormat) string {
+	// Work on the magnitude as unsigned, since -v overflows for
+	// math.MinInt.
+	neg := v < 0
+	u := uint64(v)
+	if neg {
+		u = -u
+	}
+	digits := strconv.FormatUint(u, 10)
+
+	frac := ""
+	if f.Decimals > 0 {
+		for len(digits) <= f.Decimals {
+			digits = "0" + digits
+		}
+		frac = digits[len(digits)-f.Decimals:]
+		digits = digits[:len(digits)-f.Decimals]
+	}
+
+	var b strings.Builder
+	if neg {
+		b.WriteString("-")
+	}
+	for i, d := range digits {
+		if f.Group && i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(loc.GroupSeparator)
+		}
+		b.WriteRune(d)
+	}
+	if frac != "" {
+		b.WriteString(loc.DecimalSeparator)
+		b.WriteString(frac)
+	}
+	s := b.String()
+
+	switch {
+	case f.Unit == "":
+		return s
+	case loc.UnitBefore:
+		return f.Unit + loc.UnitSeparator + s
+	}
+	return s + loc.UnitSeparator + f.Unit
+}
@@ -0,0 +1,50 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrDeviceInUse is returned when connecting to a Loupedeck that
+// another process is already connected to.  Two processes talking
+// to the same serial device corrupt each other's messages, which
+// leaves both of them half-connected, so we refuse up front instead.
+var ErrDeviceInUse = errors.New("device is in use by another process")
+
+// deviceLock is an advisory lock on a serial device, held for as long
+// as we're connected to it.  It's a flock on the device node itself,
+// where that's available, so every path to the same device (like the
+// links in /dev/serial/by-id) shares one lock, and it goes away on its
+// own if the process dies.
+type deviceLock struct {
+	file *os.File
+}
+
+// lockDevice takes the advisory lock for a serial device.  If another
+// process holds it, it returns an error wrapping ErrDeviceInUse.
+func lockDevice(device string) (*deviceLock, error) {
+	f, err := openDevice(device)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		// No locking on this platform.
+		return &deviceLock{}, nil
+	}
+	if err := flock(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%w: %q is locked (%v)", ErrDeviceInUse, device, err)
+	}
+	return &deviceLock{file: f}, nil
+}
+
+// unlock releases the lock.  It's safe to call on a nil lock.
+func (d *deviceLock) unlock() {
+	if d == nil || d.file == nil {
+		return
+	}
+	_ = funlock(d.file)
+	d.file.Close()
+	d.file = nil
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openDevice opens a serial device so that it can be locked, without
+// making it our controlling terminal or waiting for carrier.  Once a
+// process has the serial port open, it's in exclusive mode
+// (TIOCEXCL), and opening it again fails with EBUSY, which also means
+// that it's in use.
+func openDevice(device string) (*os.File, error) {
+	f, err := os.OpenFile(device, os.O_RDONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.EBUSY) {
+		return nil, fmt.Errorf("%w: %q is open in exclusive mode", ErrDeviceInUse, device)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %q for locking: %v", device, err)
+	}
+	return f, nil
+}
+
+// flock takes an exclusive lock on f without blocking.
+func flock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// funlock releases a lock taken by flock.
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,24 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package loupedeck
+
+import (
+	"os"
+)
+
+// openDevice returns nil on platforms without flock, which means no
+// locking.  Windows already refuses to open a COM port that's in use,
+// so it doesn't need it.
+func openDevice(device string) (*os.File, error) {
+	return nil, nil
+}
+
+// flock is a no-op on platforms without flock.
+func flock(f *os.File) error {
+	return nil
+}
+
+// funlock is a no-op on platforms without flock.
+func funlock(f *os.File) error {
+	return nil
+}
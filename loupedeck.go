@@ -20,7 +20,8 @@
 // The Loupedeck Live with firmware 1.x appeared as a USB network
 // device that we talked to via HTTP+websockets, but newer firmware
 // looks like a serial device that talks a mutant version of the
-// Websocket protocol.
+// Websocket protocol.  Both are supported; see ConnectPath and
+// ConnectURL, or Connect for either.
 //
 // See https://github.com/foxxyz/loupedeck for Javascript code for
 // talking to the Loupedeck Live; it supports more of the Loupedeck's
@@ -38,46 +39,188 @@ import (
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 
-	//	"log/slog"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type transactionCallback func(m *Message)
 
+// messageConn is the connection to the Loupedeck, which sends and
+// receives whole protocol messages.  It's normally a websocket
+// connection (running over the serial port), but HID devices use
+// their own implementation.
+type messageConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// binaryMessage is the websocket message type used for all Loupedeck
+// messages.
+const binaryMessage = websocket.BinaryMessage
+
 // Loupedeck describes a Loupedeck device.
 type Loupedeck struct {
 	Vendor               string
 	Product              string
-	Model                string
+	Model                Model
 	Version              string
 	SerialNo             string
+	serialReceived       chan struct{} // closed once SerialNo is set
 	font                 *opentype.Font
 	face                 font.Face
 	fontdrawer           *font.Drawer
 	serial               *SerialWebSockConn
-	conn                 *websocket.Conn
+	logger               *slog.Logger
+	conn                 messageConn // changes when reconnecting; see connection
+	connMutex            sync.Mutex  // guards conn and serial
+	hid                  bool        // true for HID-only devices like the Loupedeck+
 	buttonBindings       map[Button]ButtonFunc
 	buttonUpBindings     map[Button]ButtonFunc
 	knobBindings         map[Knob]KnobFunc
+	knobEventBindings    map[Knob]KnobEventFunc
+	knobMotion           knobMotion
+	knobBatch            knobBatch
+	invertedKnobs        map[Knob]bool
+	actions              actionTable
 	touchBindings        map[TouchButton]TouchFunc
 	touchUpBindings      map[TouchButton]TouchFunc
 	touchDKBindings      TouchDKFunc
+	touchEventBindings   []TouchEventFunc
 	dragDKBinding        DragDisplayKnobFunc
 	transactionID        uint8
 	transactionMutex     sync.Mutex
 	transactionCallbacks map[byte]transactionCallback
+	transactionDeadlines map[byte]time.Time
+	callbackTimeout      time.Duration
 	displays             map[string]*Display
+	displayAliases       map[string]string
+	regions              map[string]*Region
+	profile              *DeviceProfile
+	safeMode             bool
+	sniffing             bool
+	rawBindings          []RawMessageFunc
+	mirroring            atomic.Bool
+	theme                Theme
+	colorPreset          atomic.Pointer[ColorPreset] // nil means no adjustment
+	locale               *Locale                     // nil means defaultLocale
+	mirrors              map[byte]*image.RGBA
+	holdDraws            atomic.Bool
+	drawMutex            sync.Mutex // held while a framebuffer update is sent
+	buffer               drawBuffer
+	asyncDraws           asyncDrawState
+	shown                shownState
+	mirrorMutex          sync.Mutex // guards mirrors
+	ledMutex             sync.Mutex // guards buttonColors
+	blanked              atomic.Bool
+	badges               map[TouchButton]badge
+	initFuncs            []InitFunc
+	cache                *imageCache
+	frameClock           *FrameClock
+	frameClockOnce       sync.Once
+	drawQueue            *DrawQueue
+	drawQueueOnce        sync.Once
+	animations           animationState
+	touchDebug           *touchDebugState
+	toastMutex           sync.Mutex
+	toast                *toast
+	swipe                swipeState
+	touchVibration       VibrationPattern
 	dragDKStarted        bool
 	dragDKStartX         uint16
 	dragDKStartY         uint16
 	dragDKStartTime      time.Time
+	brightness           int
+	buttonColors         map[Button]color.RGBA
+	splash               image.Image
+	shutdownScreen       bool
+	shutdownImage        image.Image
+	lastSend             atomic.Int64 // UnixNano
+	lastReceive          atomic.Int64 // UnixNano
+	lastInput            atomic.Int64 // UnixNano
+	inputFilter          func(MessageType) bool
+	idleMutex            sync.Mutex
+	idleWatchers         []*idleWatcher
+	watchdogStop         chan struct{}
+	reconnectRetry       time.Duration
+	redrawStrategy       RedrawStrategy
+	redraw               func()
+	connectionBindings   []ConnectionFunc
+	closed               atomic.Bool
+	listening            atomic.Bool
+	listenDone           atomic.Pointer[chan struct{}]
+	errors               chan error
+	status               statusState
+	statsState           statsState
+	namespaceState       namespaceState
+	warmup               warmupState
+	acks                 ackState
+	dispatch             dispatchState
+	hooks                hookState
+	sendQueue            sendQueue
+	capture              captureState
+	unknown              unknownState
+	uiState              UIState
+	stateFile            string
+	stateBindings        []StateFunc
 }
 
-// Close closes the connection to the Loupedeck.
+// closeTimeout bounds how long Close waits for outstanding
+// transactions to be answered, and for Listen to return.
+const closeTimeout = 500 * time.Millisecond
+
+// Close closes the connection to the Loupedeck, leaving it in a tidy
+// state.  It stops background work (the watchdog, status polling,
+// animations, and idle timers), resets the Loupedeck (or shows the
+// shutdown screen; see SetShutdownScreen), waits briefly for any
+// outstanding transactions to be answered, and then closes the
+// connection and the serial port.  Listen returns once the connection
+// is closed, without reporting an error.
+//
+// Calling Close more than once is harmless.
 func (l *Loupedeck) Close() {
-	l.conn.Close()
-	l.serial.Close()
+	if !l.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	l.StopWatchdog()
+	l.StopStatusPolling()
+	l.stopIdleWatchers()
+	l.FrameClock().stopAll()
+	l.flushKnobBatch()
+	l.stopAsync()
+
+	if l.shutdownScreen {
+		l.showShutdownScreen()
+	} else if !l.hid {
+		if err := l.Send(l.NewMessage(Reset, []byte{})); err != nil {
+			l.log().Warn("Unable to reset Loupedeck while closing", "err", err)
+		}
+	}
+
+	deadline := time.Now().Add(closeTimeout)
+	if l.listening.Load() {
+		for l.pendingCallbacks() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	conn, serial := l.connection()
+	conn.Close()
+	l.stopWriter()
+	if serial != nil {
+		serial.Close()
+	}
+
+	if done := l.listenDone.Load(); done != nil {
+		select {
+		case <-*done:
+		case <-time.After(time.Until(deadline) + closeTimeout):
+			l.log().Warn("Listen didn't return after closing")
+		}
+	}
 }
 
 // FontDrawer returns a font.Drawer object configured to
@@ -97,7 +240,8 @@ func (l *Loupedeck) Face() font.Face {
 
 // TextInBox writes a specified string into a x,y pixel
 // image.Image, using the specified foreground and background colors.
-// The font size used will be chosen to maximize the size of the text.
+// The font size used will be chosen to maximize the size of the text,
+// but won't go below the theme's MinFontSize.
 func (l *Loupedeck) TextInBox(x, y int, s string, fg, bg color.Color) (image.Image, error) {
 	im := image.NewRGBA(image.Rect(0, 0, x, y))
 	draw.Draw(im, im.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
@@ -107,6 +251,9 @@ func (l *Loupedeck) TextInBox(x, y int, s string, fg, bg color.Color) (image.Ima
 	fd.Dst = im
 
 	size := 12.0
+	if l.theme.FontSize > size {
+		size = l.theme.FontSize
+	}
 	x26 := fixed.I(x)
 	y26 := fixed.I(y)
 
@@ -129,8 +276,11 @@ func (l *Loupedeck) TextInBox(x, y int, s string, fg, bg color.Color) (image.Ima
 		width := bounds.Max.X - bounds.Min.X
 		height := bounds.Max.Y - bounds.Min.Y
 
-		if width > mx26 || height > my26 {
+		if (width > mx26 || height > my26) && size > l.theme.MinFontSize {
 			size = size * 0.8
+			if size < l.theme.MinFontSize {
+				size = l.theme.MinFontSize
+			}
 			//fmt.Printf("Reducing font size to %f\n", size)
 			continue
 		}
@@ -156,27 +306,55 @@ func (l *Loupedeck) SetDefaultFont() error {
 		return err
 	}
 	l.font = f
-
-	l.face, err = opentype.NewFace(l.font, &opentype.FaceOptions{
-		Size: 12,
-		DPI:  150,
-	})
-	if err != nil {
-		return err
-	}
-
-	l.fontdrawer = &font.Drawer{
-		Src:  &image.Uniform{color.RGBA{255, 255, 255, 255}},
-		Face: l.face,
-	}
-	return nil
+	return l.updateFace()
 }
 
-// SetBrightness sets the overall brightness of the Loupedeck display.
+// MaxBrightness is the highest brightness level supported by
+// SetBrightness.
+const MaxBrightness = 10
+
+// SetBrightness sets the overall brightness of the Loupedeck display,
+// between 0 and MaxBrightness.
 func (l *Loupedeck) SetBrightness(b int) error {
+	if b < 0 {
+		b = 0
+	}
+	if b > MaxBrightness {
+		b = MaxBrightness
+	}
+	if l.blanked.Load() {
+		// Applied by Unblank.
+		l.brightness = b
+		return nil
+	}
 	data := make([]byte, 1)
 	data[0] = byte(b)
 	m := l.NewMessage(SetBrightness, data)
+	err := l.Send(m)
+	if err == nil {
+		l.brightness = b
+	}
+	return err
+}
+
+// GetBrightness returns the most recently set brightness.  The
+// Loupedeck can't be asked for its current brightness, so this is
+// only as accurate as our record of what we've sent.
+func (l *Loupedeck) GetBrightness() int {
+	return l.brightness
+}
+
+// StepBrightness increases (or, for negative values, decreases) the
+// brightness by delta steps.
+func (l *Loupedeck) StepBrightness(delta int) error {
+	return l.SetBrightness(l.brightness + delta)
+}
+
+// vibrate asks the Loupedeck to play a haptic pattern.  Only some
+// devices (like the Loupedeck CT) have a vibration motor; others
+// ignore this.
+func (l *Loupedeck) vibrate(pattern byte) error {
+	m := l.NewMessage(SetVibration, []byte{pattern})
 	return l.Send(m)
 }
 
@@ -186,11 +364,76 @@ func (l *Loupedeck) SetBrightness(b int) error {
 // overridden to show the status of the Loupedeck Live's connection to
 // the host.
 func (l *Loupedeck) SetButtonColor(b Button, c color.RGBA) error {
+	if l.setButtonColorBlanked(b, c) {
+		return nil
+	}
 	data := make([]byte, 4)
 	data[0] = byte(b)
 	data[1] = c.R
 	data[2] = c.G
 	data[3] = c.B
 	m := l.NewMessage(SetColor, data)
-	return l.Send(m)
+	err := l.Send(m)
+	if err == nil {
+		l.ledMutex.Lock()
+		l.buttonColors[b] = c
+		l.ledMutex.Unlock()
+	}
+	return err
+}
+
+// GetButtonColor returns the most recently set color of a Button.  As
+// with GetBrightness, the Loupedeck can't be asked, so this is only
+// what we've sent.  The second return value is false if the button's
+// color hasn't been set since connecting.
+func (l *Loupedeck) GetButtonColor(b Button) (color.RGBA, bool) {
+	l.ledMutex.Lock()
+	defer l.ledMutex.Unlock()
+	c, ok := l.buttonColors[b]
+	return c, ok
+}
+
+// ButtonColors returns the most recently set colors of all of the
+// Buttons whose colors have been set since connecting.
+func (l *Loupedeck) ButtonColors() map[Button]color.RGBA {
+	l.ledMutex.Lock()
+	defer l.ledMutex.Unlock()
+	colors := make(map[Button]color.RGBA, len(l.buttonColors))
+	for b, c := range l.buttonColors {
+		colors[b] = c
+	}
+	return colors
+}
+
+// restoreLEDs resends the last-set brightness and button colors, for
+// use after the Loupedeck has been reset.
+func (l *Loupedeck) restoreLEDs() error {
+	if err := l.SetBrightness(l.brightness); err != nil {
+		return err
+	}
+	for b, c := range l.ButtonColors() {
+		if err := l.SetButtonColor(b, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ledButtons returns the buttons that can have lights: every button
+// in the device's profile except the knob presses, or Circle through
+// Button7 if there's no profile.
+func (l *Loupedeck) ledButtons() []Button {
+	var buttons []Button
+	if l.profile == nil {
+		for b := Circle; b <= Button7; b++ {
+			buttons = append(buttons, b)
+		}
+		return buttons
+	}
+	for _, b := range l.profile.Buttons {
+		if b < KnobPress1 || b > KnobPress6 {
+			buttons = append(buttons, b)
+		}
+	}
+	return buttons
 }
@@ -0,0 +1,141 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// marqueeGap is the space (in pixels) between the end of the text
+// and the start of its next repetition while scrolling.
+const marqueeGap = 30
+
+// Marquee displays a text label in a Region.  If the label is too
+// wide to fit, then it scrolls horizontally, wrapping around
+// continuously, until Stop is called.  Labels that fit are simply
+// drawn centered.  Scrolling is driven by the Loupedeck's FrameClock.
+//
+// The Marquee has its own copy of the Loupedeck's font face, made
+// when it's created, since it draws from the FrameClock's goroutine.
+type Marquee struct {
+	// Step is the number of pixels that the text moves on each frame.
+	Step int
+
+	loupedeck *Loupedeck
+	region    *Region
+	fg, bg    color.Color
+	face      font.Face // guarded by mutex
+	mutex     sync.Mutex
+	text      string
+	offset    int
+	cancel    func()
+}
+
+// NewMarquee creates a new Marquee showing text in the specified
+// Region, using the specified foreground and background colors.  The
+// Marquee is drawn once, but doesn't start scrolling until Start is
+// called.
+func (l *Loupedeck) NewMarquee(r *Region, text string, fg, bg color.Color) *Marquee {
+	m := &Marquee{
+		Step:      2,
+		loupedeck: l,
+		region:    r,
+		fg:        fg,
+		bg:        bg,
+		text:      text,
+		face:      l.Face(),
+	}
+	if face, err := l.newFace(); err == nil {
+		m.face = face
+	}
+	m.Draw()
+	return m
+}
+
+// SetText changes the text shown by the Marquee and redraws it from
+// the beginning.
+func (m *Marquee) SetText(s string) {
+	m.mutex.Lock()
+	m.text = s
+	m.offset = 0
+	m.mutex.Unlock()
+	m.Draw()
+}
+
+// Start starts scrolling the Marquee.  Calling Start on a Marquee
+// that is already running has no effect.
+func (m *Marquee) Start() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancel != nil {
+		return
+	}
+	m.cancel = m.loupedeck.FrameClock().Subscribe(m.tick)
+}
+
+// Stop stops scrolling the Marquee, leaving the current frame on the
+// display.  This should be called whenever the Marquee's page loses
+// focus, so it doesn't keep drawing over other content.
+func (m *Marquee) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+// tick advances the Marquee by one step and redraws it, if the text
+// is long enough to need scrolling.
+func (m *Marquee) tick(frame uint64) bool {
+	m.mutex.Lock()
+	width := m.textWidth()
+	if width <= m.region.width {
+		m.mutex.Unlock()
+		return false
+	}
+	m.offset = (m.offset + m.Step) % (width + marqueeGap)
+	m.mutex.Unlock()
+	m.Draw()
+	return true
+}
+
+// textWidth returns the width of the Marquee's text in pixels.  The
+// mutex must be held.
+func (m *Marquee) textWidth() int {
+	return font.MeasureString(m.face, m.text).Ceil()
+}
+
+// Draw draws the current frame of the Marquee.
+func (m *Marquee) Draw() {
+	m.mutex.Lock()
+	im := image.NewRGBA(image.Rect(0, 0, m.region.width, m.region.height))
+	draw.Draw(im, im.Bounds(), &image.Uniform{m.bg}, image.Point{}, draw.Src)
+
+	fd := font.Drawer{
+		Src:  &image.Uniform{m.fg},
+		Dst:  im,
+		Face: m.face,
+	}
+
+	metrics := fd.Face.Metrics()
+	baseline := (fixed.I(m.region.height) + metrics.Ascent - metrics.Descent) / 2
+	width := m.textWidth()
+
+	if width <= m.region.width {
+		fd.Dot = fixed.Point26_6{X: fixed.I((m.region.width - width) / 2), Y: baseline}
+		fd.DrawString(m.text)
+	} else {
+		for _, x := range []int{-m.offset, width + marqueeGap - m.offset} {
+			fd.Dot = fixed.Point26_6{X: fixed.I(x), Y: baseline}
+			fd.DrawString(m.text)
+		}
+	}
+	m.mutex.Unlock()
+
+	m.region.Draw(im)
+}
@@ -2,8 +2,6 @@ package loupedeck
 
 import (
 	"fmt"
-	"github.com/gorilla/websocket"
-	"log/slog"
 	"time"
 )
 
@@ -44,13 +42,24 @@ type Message struct {
 	data          []byte
 }
 
+// maxFrameLength is the largest length that fits in a message's
+// length byte.  Messages longer than this have their length byte
+// saturated at 255, so the real length has to come from the transport
+// instead.  This is how the protocol marks long messages, in both
+// directions; the official software does the same.  Each message is
+// always sent as exactly one transport frame, so the frame's length
+// is the message's length.  Framebuffer writes, which are by far the
+// longest messages, are also split into pieces that every firmware
+// revision can handle; see writeFramebuffer.
+const maxFrameLength = 255
+
 // NewMessage creates a new low-level Loupedeck message with
 // a specified type and data.  This isn't generally needed for
 // end-use.
 func (l *Loupedeck) NewMessage(messageType MessageType, data []byte) *Message {
 	length := len(data) + 3
-	if length > 255 {
-		length = 255
+	if length > maxFrameLength {
+		length = maxFrameLength
 	}
 
 	m := Message{
@@ -67,6 +76,9 @@ func (l *Loupedeck) NewMessage(messageType MessageType, data []byte) *Message {
 // bytes.  This is used to decode incoming messages from a Loupedeck,
 // and shouldn't generally be needed outside of this library.
 func (l *Loupedeck) ParseMessage(b []byte) (*Message, error) {
+	if len(b) < 3 {
+		return nil, fmt.Errorf("message too short (%d bytes): %v", len(b), b)
+	}
 	m := Message{
 		length:        b[0],
 		messageType:   MessageType(b[1]),
@@ -76,6 +88,31 @@ func (l *Loupedeck) ParseMessage(b []byte) (*Message, error) {
 	return &m, nil
 }
 
+// splitFrames splits a payload read from the Loupedeck into
+// individual messages, using each message's length byte.  Usually a
+// payload holds exactly one message, but small messages can arrive
+// back to back in a single payload.
+//
+// A length byte of 255 is saturated rather than exact, so a message
+// with a saturated length runs to the end of the payload; this keeps
+// long responses (like serial numbers and future bulk responses)
+// intact instead of cutting them off at 255 bytes.  Malformed or
+// truncated messages are passed along as-is, so that the caller can
+// report them.
+func splitFrames(b []byte) [][]byte {
+	var frames [][]byte
+	for len(b) > 0 {
+		n := int(b[0])
+		if n == maxFrameLength || n < 3 || n >= len(b) {
+			frames = append(frames, b)
+			break
+		}
+		frames = append(frames, b[:n])
+		b = b[n:]
+	}
+	return frames
+}
+
 // function asBytes() returns the wire-format form of the message.
 func (m *Message) asBytes() []byte {
 	b := make([]byte, 3)
@@ -87,6 +124,28 @@ func (m *Message) asBytes() []byte {
 	return b
 }
 
+// Type returns the message's type.
+func (m *Message) Type() MessageType {
+	return m.messageType
+}
+
+// TransactionID returns the message's transaction ID, which is 0 for
+// input events.
+func (m *Message) TransactionID() byte {
+	return m.transactionID
+}
+
+// Data returns the message's payload, after the length, type, and
+// transaction ID.
+func (m *Message) Data() []byte {
+	return m.data
+}
+
+// Bytes returns the message in wire format.
+func (m *Message) Bytes() []byte {
+	return m.asBytes()
+}
+
 // function String() returns a human-readable form of the message for
 // debugging use.
 func (m *Message) String() string {
@@ -94,86 +153,218 @@ func (m *Message) String() string {
 
 	if len(d) > 16 {
 		d = d[0:16]
-		return fmt.Sprintf("{len: %d, type: %02x, txn: %02x, data: %v..., actual_len: %d}", m.length, m.messageType, m.transactionID, d, len(m.data))
+		return fmt.Sprintf("{len: %d, type: %02x, txn: %02x, data: %v..., actual_len: %d}", m.length, byte(m.messageType), m.transactionID, d, len(m.data))
 	}
-	return fmt.Sprintf("{len: %d, type: %02x, txn: %02x, data: %v}", m.length, m.messageType, m.transactionID, d)
+	return fmt.Sprintf("{len: %d, type: %02x, txn: %02x, data: %v}", m.length, byte(m.messageType), m.transactionID, d)
+}
+
+// defaultCallbackTimeout is how long a transaction callback waits for
+// a response before it's thrown away.  See SetCallbackTimeout.
+const defaultCallbackTimeout = 10 * time.Second
+
+// SetCallbackTimeout sets how long callbacks registered with
+// SendWithCallback wait for a response.  If the Loupedeck doesn't
+// answer in time, the callback is discarded without being called, so
+// that it can't leak or be called for an unrelated message once the
+// transaction IDs wrap around.  The default is 10 seconds.
+func (l *Loupedeck) SetCallbackTimeout(d time.Duration) {
+	l.transactionMutex.Lock()
+	defer l.transactionMutex.Unlock()
+	l.callbackTimeout = d
 }
 
 // newTransactionId picks the next 8-bit transaction ID
 // number.  This is used as part of the Loupedeck protocol and used to
 // match results with specific queries.  The transaction ID
 // incrememnts per call and rolls over back to 1 (not 0).
+//
+// IDs that are still waiting for a response are skipped, so a late
+// response can never be matched to a newer transaction.  If all 255
+// are waiting, the next one is reused anyway, and its old callback is
+// discarded.
 func (l *Loupedeck) newTransactionID() uint8 {
 	l.transactionMutex.Lock()
+	defer l.transactionMutex.Unlock()
+	l.expireCallbacks(time.Now())
+
 	t := l.transactionID
-	t++
-	if t == 0 {
-		t = 1
+	for i := 0; i < 255; i++ {
+		t++
+		if t == 0 {
+			t = 1
+		}
+		if l.transactionCallbacks[t] == nil {
+			break
+		}
+	}
+	if l.transactionCallbacks[t] != nil {
+		l.dropCallback(t)
 	}
 	l.transactionID = t
-	l.transactionMutex.Unlock()
 
 	return t
 }
 
+// expireCallbacks discards callbacks whose deadlines have passed.
+// The transactionMutex must be held.
+func (l *Loupedeck) expireCallbacks(now time.Time) {
+	for id, deadline := range l.transactionDeadlines {
+		if now.After(deadline) {
+			l.log().Debug("Transaction timed out", "transaction", id)
+			l.dropCallback(id)
+		}
+	}
+}
+
+// dropCallback discards the callback for a transaction ID that never
+// got a response.  The transactionMutex must be held.
+func (l *Loupedeck) dropCallback(id byte) {
+	if l.transactionCallbacks[id] != nil {
+		l.updateStats(func(s *Stats) { s.DroppedCallbacks++ })
+	}
+	delete(l.transactionCallbacks, id)
+	delete(l.transactionDeadlines, id)
+}
+
+// setCallback sets (or, if c is nil, clears) the callback for a
+// transaction ID.  The callback is discarded if no response arrives
+// within timeout; 0 means the default (see SetCallbackTimeout).
+func (l *Loupedeck) setCallback(id byte, c transactionCallback, timeout time.Duration) {
+	l.transactionMutex.Lock()
+	defer l.transactionMutex.Unlock()
+	l.dropCallback(id)
+	if c == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = l.callbackTimeout
+	}
+	if timeout <= 0 {
+		timeout = defaultCallbackTimeout
+	}
+	if l.transactionCallbacks == nil {
+		l.transactionCallbacks = map[byte]transactionCallback{}
+	}
+	if l.transactionDeadlines == nil {
+		l.transactionDeadlines = map[byte]time.Time{}
+	}
+	l.transactionCallbacks[id] = c
+	l.transactionDeadlines[id] = time.Now().Add(timeout)
+}
+
+// takeCallback returns and clears the callback for a transaction ID,
+// or returns nil if there isn't one.
+func (l *Loupedeck) takeCallback(id byte) transactionCallback {
+	l.transactionMutex.Lock()
+	defer l.transactionMutex.Unlock()
+	c := l.transactionCallbacks[id]
+	if deadline, ok := l.transactionDeadlines[id]; ok && time.Now().After(deadline) {
+		// Too late; this is probably a response to something
+		// else.
+		l.dropCallback(id)
+		return nil
+	}
+	delete(l.transactionCallbacks, id)
+	delete(l.transactionDeadlines, id)
+	return c
+}
+
+// pendingCallbacks returns the number of transactions that are still
+// waiting for a response.
+func (l *Loupedeck) pendingCallbacks() int {
+	l.transactionMutex.Lock()
+	defer l.transactionMutex.Unlock()
+	return len(l.transactionCallbacks)
+}
+
 // Send sends a message to the specified device.
 func (l *Loupedeck) Send(m *Message) error {
-	slog.Info("Sending", "message", m.String())
-	l.transactionCallbacks[m.transactionID] = nil
+	l.log().Info("Sending", "message", m.String())
+	l.setCallback(m.transactionID, nil, 0)
 
 	return l.send(m)
 }
 
-// send sends a message to the specified device.
+// send sends a message to the specified device.  Messages are written
+// by a single writer goroutine, with everything else ahead of
+// drawing; see sendQueue.
 func (l *Loupedeck) send(m *Message) error {
+	l.runHooks(Outbound, m)
+	if m.messageType == Reset {
+		// The displays are about to be cleared.
+		l.forgetAllShown()
+		l.cache.forgetCurrent()
+	}
 	b := m.asBytes()
-	return l.conn.WriteMessage(websocket.BinaryMessage, b)
+	l.lastSend.Store(time.Now().UnixNano())
+	l.sniff("sent", b)
+	l.captureMessage(Outbound, b)
+	l.noteSent(m)
+	if err := l.write(m.messageType, b); err != nil {
+		// There won't be an answer, so don't wait for one.
+		l.noteAck(m)
+		return err
+	}
+	l.countSent(m.messageType, len(b))
+	return nil
 }
 
 // SendWithCallback sends a message to the specified device
 // and registers a callback.  When (or if) the Loupedeck sends a
 // response to the message, the callback function will be called and
-// provided with the response message.
+// provided with the response message.  Callbacks that don't get a
+// response in time are discarded; see SetCallbackTimeout.
 func (l *Loupedeck) SendWithCallback(m *Message, c transactionCallback) error {
-	slog.Info("Setting callback", "message", m.String())
-	l.transactionCallbacks[m.transactionID] = c
+	return l.sendWithCallback(m, c, 0)
+}
+
+// sendWithCallback is SendWithCallback, with a timeout for the
+// callback (0 for the default).
+func (l *Loupedeck) sendWithCallback(m *Message, c transactionCallback, timeout time.Duration) error {
+	l.log().Info("Setting callback", "message", m.String())
+	l.setCallback(m.transactionID, c, timeout)
 
 	return l.send(m)
 }
 
-// SendAndWait sends a message and then waits for a response, returning the response message.
+// SendAndWait sends a message and then waits for a response,
+// returning the response message.  If no response arrives within
+// timeout, it returns ErrTimeout.  Responses are matched by
+// transaction ID, so it doesn't matter if responses to other messages
+// arrive first.  Listen needs to be running.
+//
+// Some messages, like Draw, aren't answered until the next message
+// is sent, so if a response is slow to arrive SendAndWait sends a
+// harmless query to shake it loose.
 func (l *Loupedeck) SendAndWait(m *Message, timeout time.Duration) (*Message, error) {
-	ch := make(chan *Message)
-	defer close(ch)
-	// TODO(scottlaird): actually implement the timeout.
-	err := l.SendWithCallback(m, func(m2 *Message) {
-		defer func() {
-			_ = recover()
-		}()
-		slog.Info("sendAndWait callback received, sending to channel")
-		ch <- m2
-	})
+	// Buffered, so that a response that arrives after we've given
+	// up doesn't block Listen.
+	ch := make(chan *Message, 1)
+	err := l.sendWithCallback(m, func(m2 *Message) {
+		select {
+		case ch <- m2:
+		default:
+		}
+	}, timeout)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to send: %v", err)
 	}
 
-	// Trying SendAndWait with Draw() usually fails, because it
-	// doesn't get a response back until after the following
-	// message has been sent.  Trying to figure out if this is a
-	// weird Loupedeck thing or a protocol issue or what.  Try
-	// sending a ping over WS, just to see if anything shakes
-	// loose.
-
-	//	slog.Info("Sending ping.")
-	//	err = l.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(time.Second))
-	//	slog.Info("Ping send", "err", err)
-
-	select {
-	case resp := <-ch:
-		slog.Info("sendAndWait received ok")
-		return resp, nil
-	case <-time.After(timeout):
-		slog.Warn("sendAndWait timeout")
-		return nil, fmt.Errorf("Timeout waiting for response")
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	nudge := time.NewTicker(ackNudgeInterval)
+	defer nudge.Stop()
+	for {
+		select {
+		case resp := <-ch:
+			l.log().Info("sendAndWait received ok")
+			return resp, nil
+		case <-nudge.C:
+			l.nudge()
+		case <-deadline.C:
+			l.log().Warn("sendAndWait timeout")
+			l.setCallback(m.transactionID, nil, 0)
+			return nil, ErrTimeout
+		}
 	}
 }
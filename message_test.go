@@ -0,0 +1,188 @@
+package loupedeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestSplitFrames(t *testing.T) {
+	long := make([]byte, 300)
+	long[0] = 255
+	long[1] = byte(Serial)
+	long[2] = 7
+	for i := 3; i < len(long); i++ {
+		long[i] = byte(i)
+	}
+
+	exact := make([]byte, 255)
+	exact[0] = 255
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    [][]byte
+	}{
+		{
+			name:    "single message",
+			payload: []byte{5, 0, 0, 1, 1},
+			want:    [][]byte{{5, 0, 0, 1, 1}},
+		},
+		{
+			name:    "back to back messages",
+			payload: []byte{5, 0, 0, 1, 1, 5, 0, 0, 1, 0},
+			want:    [][]byte{{5, 0, 0, 1, 1}, {5, 0, 0, 1, 0}},
+		},
+		{
+			name:    "saturated length runs to end of payload",
+			payload: long,
+			want:    [][]byte{long},
+		},
+		{
+			name:    "saturated length at exactly 255 bytes",
+			payload: exact,
+			want:    [][]byte{exact},
+		},
+		{
+			name:    "short message followed by saturated message",
+			payload: append([]byte{4, 0, 0, 9}, long...),
+			want:    [][]byte{{4, 0, 0, 9}, long},
+		},
+		{
+			name:    "truncated message is passed along",
+			payload: []byte{9, 0x4d, 0, 1},
+			want:    [][]byte{{9, 0x4d, 0, 1}},
+		},
+		{
+			name:    "malformed length is passed along",
+			payload: []byte{1, 0, 0, 5},
+			want:    [][]byte{{1, 0, 0, 5}},
+		},
+		{
+			name:    "empty payload",
+			payload: nil,
+			want:    nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := splitFrames(tc.payload)
+			if len(got) != len(tc.want) {
+				t.Fatalf("splitFrames() returned %d frames, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tc.want[i]) {
+					t.Errorf("frame %d = %v, want %v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSaturatedMessageReachesCallback(t *testing.T) {
+	payload := make([]byte, 300)
+	payload[0] = 255
+	payload[1] = byte(Serial)
+	payload[2] = 7
+	copy(payload[3:], bytes.Repeat([]byte("X"), len(payload)-3))
+
+	l := &Loupedeck{transactionCallbacks: map[byte]transactionCallback{}}
+	var got *Message
+	l.transactionCallbacks[7] = func(m *Message) {
+		got = m
+	}
+
+	for _, message := range splitFrames(payload) {
+		l.handleMessage(message)
+	}
+
+	if got == nil {
+		t.Fatal("callback wasn't called")
+	}
+	if len(got.data) != len(payload)-3 {
+		t.Errorf("callback got %d bytes of data, want %d", len(got.data), len(payload)-3)
+	}
+}
+
+func TestNewMessageSaturatesLength(t *testing.T) {
+	l := &Loupedeck{}
+	m := l.NewMessage(Serial, make([]byte, 400))
+	b := m.asBytes()
+	if b[0] != 255 {
+		t.Errorf("length byte = %d, want 255", b[0])
+	}
+	if len(b) != 403 {
+		t.Errorf("message is %d bytes, want 403", len(b))
+	}
+}
+
+func TestSplitFramebuffer(t *testing.T) {
+	d := &Display{id: 0x4d}
+	width, height := 480, 270
+	data := d.framebufferHeader(0, 10, width, height)
+	for i := 0; i < width*height; i++ {
+		data = append(data, byte(i), byte(i>>8))
+	}
+
+	parts := splitFramebuffer(data, maxFramebufferPayload)
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts, want several", len(parts))
+	}
+	y, pixels := 10, 0
+	for i, p := range parts {
+		if len(p) > maxFramebufferPayload {
+			t.Errorf("part %d is %d bytes, want at most %d", i, len(p), maxFramebufferPayload)
+		}
+		if got := int(binary.BigEndian.Uint16(p[4:])); got != y {
+			t.Errorf("part %d starts at y=%d, want %d", i, got, y)
+		}
+		rows := int(binary.BigEndian.Uint16(p[8:]))
+		if !bytes.Equal(p[framebufferHeaderLength:], data[framebufferHeaderLength+pixels:framebufferHeaderLength+pixels+rows*width*2]) {
+			t.Errorf("part %d has the wrong pixels", i)
+		}
+		y += rows
+		pixels += rows * width * 2
+	}
+	if y != 10+height {
+		t.Errorf("parts cover up to y=%d, want %d", y, 10+height)
+	}
+
+	small := d.framebufferHeader(0, 0, 90, 90)
+	small = append(small, make([]byte, 90*90*2)...)
+	if parts := splitFramebuffer(small, maxFramebufferPayload); len(parts) != 1 {
+		t.Errorf("90x90 write was split into %d parts, want 1", len(parts))
+	}
+}
+
+func TestTransactionIDsSkipPendingCallbacks(t *testing.T) {
+	l := &Loupedeck{}
+	first := l.newTransactionID()
+	l.setCallback(first, func(*Message) {}, time.Hour)
+
+	for i := 0; i < 300; i++ {
+		if id := l.newTransactionID(); id == first || id == 0 {
+			t.Fatalf("newTransactionID returned %d, which is pending or invalid", id)
+		}
+	}
+}
+
+func TestExpiredCallbacksAreDropped(t *testing.T) {
+	l := &Loupedeck{}
+	id := l.newTransactionID()
+	called := false
+	l.setCallback(id, func(*Message) { called = true }, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	l.handleMessage([]byte{4, byte(Version), id, 1})
+	if called {
+		t.Error("expired callback was called")
+	}
+	if n := l.pendingCallbacks(); n != 0 {
+		t.Errorf("%d callbacks still pending, want 0", n)
+	}
+	if n := l.Stats().DroppedCallbacks; n != 1 {
+		t.Errorf("DroppedCallbacks = %d, want 1", n)
+	}
+}
@@ -0,0 +1,175 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// Meter is a bar graph widget that shows a rapidly-changing level,
+// such as an audio meter or a DMX output, in a Region.
+//
+// Applications can push levels into the Meter as quickly as they
+// like, either by calling Set or by sending to the channel returned
+// by Feed.  The Meter only redraws on ticks of the Loupedeck's
+// FrameClock, showing the highest level received since the previous
+// frame, so short peaks remain visible without flooding the Loupedeck
+// with draws.  Redraws go through the Loupedeck's DrawQueue at
+// DrawMeter priority, so they never delay feedback from other
+// widgets.
+//
+// Meters in regions that are taller than they are wide fill from the
+// bottom up; otherwise they fill from left to right.
+type Meter struct {
+	Min, Max   float64
+	Foreground color.Color
+	Background color.Color
+
+	loupedeck *Loupedeck
+	region    *Region
+	mutex     sync.Mutex
+	level     float64 // highest level since the last frame
+	pending   bool    // true if a level has arrived since the last frame
+	shown     float64 // level currently on the display
+	feed      chan float64
+	stop      chan struct{}
+	cancel    func()
+}
+
+// NewMeter creates a new Meter in a Region, showing values between
+// min and max.  The Meter doesn't update the display until Start is
+// called.
+func (l *Loupedeck) NewMeter(r *Region, min, max float64) *Meter {
+	return &Meter{
+		Min:        min,
+		Max:        max,
+		Foreground: l.theme.Active,
+		Background: l.theme.Background,
+		loupedeck:  l,
+		region:     r,
+		level:      min,
+		shown:      min,
+	}
+}
+
+// Set pushes a new level into the Meter.  It's safe to call Set from
+// any goroutine, and at any rate.
+func (m *Meter) Set(v float64) {
+	m.mutex.Lock()
+	if !m.pending || v > m.level {
+		m.level = v
+	}
+	m.pending = true
+	m.mutex.Unlock()
+}
+
+// Feed returns a channel that can be used to stream levels into the
+// Meter; each value sent is handled as if passed to Set.  The channel
+// is only read while the Meter is running.
+func (m *Meter) Feed() chan<- float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.feed == nil {
+		m.feed = make(chan float64, 64)
+	}
+	return m.feed
+}
+
+// Start starts updating the display with new levels.
+func (m *Meter) Start() {
+	m.mutex.Lock()
+	if m.stop != nil {
+		m.mutex.Unlock()
+		return
+	}
+	if m.feed == nil {
+		m.feed = make(chan float64, 64)
+	}
+	m.stop = make(chan struct{})
+	go m.run(m.stop, m.feed)
+	m.cancel = m.loupedeck.FrameClock().Subscribe(m.frame)
+	m.mutex.Unlock()
+
+	m.Draw()
+}
+
+// Stop stops updating the display.  Levels pushed via Set are still
+// recorded, and will be shown once the Meter is started again.
+func (m *Meter) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+// run copies levels from the feed channel until stopped.
+func (m *Meter) run(stop chan struct{}, feed chan float64) {
+	for {
+		select {
+		case <-stop:
+			return
+		case v := <-feed:
+			m.Set(v)
+		}
+	}
+}
+
+// frame redraws the Meter if any level arrived since the last frame
+// and it differs from what is already shown.
+func (m *Meter) frame(_ uint64) bool {
+	m.mutex.Lock()
+	if !m.pending {
+		m.mutex.Unlock()
+		return false
+	}
+	m.pending = false
+	changed := m.level != m.shown
+	m.shown = m.level
+	m.mutex.Unlock()
+
+	if changed {
+		m.loupedeck.DrawQueue().QueueRegion(fmt.Sprintf("meter:%p", m), DrawMeter, m.region, m.render())
+	}
+	// The actual draw happens in the DrawQueue, which has its own
+	// budget.
+	return false
+}
+
+// Draw draws the Meter at its most recently shown level.
+func (m *Meter) Draw() {
+	m.region.Draw(m.render())
+}
+
+// render returns an image of the Meter at its most recently shown
+// level.
+func (m *Meter) render() image.Image {
+	m.mutex.Lock()
+	fraction := (m.shown - m.Min) / (m.Max - m.Min)
+	m.mutex.Unlock()
+
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	w, h := m.region.width, m.region.height
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(im, im.Bounds(), &image.Uniform{m.Background}, image.Point{}, draw.Src)
+
+	var bar image.Rectangle
+	if h > w {
+		bar = image.Rect(0, h-int(float64(h)*fraction), w, h)
+	} else {
+		bar = image.Rect(0, 0, int(float64(w)*fraction), h)
+	}
+	draw.Draw(im, bar, &image.Uniform{m.Foreground}, image.Point{}, draw.Src)
+	return im
+}
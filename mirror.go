@@ -0,0 +1,122 @@
+package loupedeck
+
+import (
+	"image"
+	"image/draw"
+)
+
+// SetMirroring enables or disables the client-side framebuffer
+// mirror.  The Loupedeck can't be asked what's currently on its
+// screens, so when mirroring is enabled we keep a copy of every image
+// drawn, which can then be retrieved with Snapshot.  Disabling
+// mirroring discards the saved images.
+func (l *Loupedeck) SetMirroring(enabled bool) {
+	l.mirrorMutex.Lock()
+	defer l.mirrorMutex.Unlock()
+	l.mirroring.Store(enabled)
+	if !enabled {
+		l.mirrors = nil
+	}
+}
+
+// Snapshot returns a copy of what is currently shown on the specified
+// display, as far as the client-side mirror knows.  Areas that have
+// never been drawn are black.  If mirroring is disabled, then
+// Snapshot returns nil.
+func (l *Loupedeck) Snapshot(d *Display) image.Image {
+	if !l.mirroring.Load() || d == nil {
+		return nil
+	}
+
+	im := image.NewRGBA(image.Rect(0, 0, d.width, d.height))
+	draw.Draw(im, im.Bounds(), image.Black, image.Point{}, draw.Src)
+	l.mirrorMutex.Lock()
+	defer l.mirrorMutex.Unlock()
+	if m := l.mirrors[d.id]; m != nil {
+		draw.Draw(im, im.Bounds(), m, image.Pt(d.offsetx, d.offsety), draw.Src)
+	}
+	return im
+}
+
+// hasMirror returns true if the framebuffer identified by id has a
+// client-side mirror.
+func (l *Loupedeck) hasMirror(id byte) bool {
+	l.mirrorMutex.Lock()
+	defer l.mirrorMutex.Unlock()
+	return l.mirrors[id] != nil
+}
+
+// surfaceBounds returns the size of the physical framebuffer
+// identified by id.  Several Displays may share the same framebuffer
+// at different offsets, so this is the union of all of them.
+func (l *Loupedeck) surfaceBounds(id byte) image.Rectangle {
+	r := image.Rectangle{}
+	for _, d := range l.displays {
+		if d.id == id {
+			r = r.Union(image.Rect(d.offsetx, d.offsety, d.offsetx+d.width, d.offsety+d.height))
+		}
+	}
+	return r
+}
+
+// updateMirror copies an image into the client-side mirror at x,y
+// (in framebuffer coordinates, including the display's offset).
+// Draws hold the drawMutex while calling this, so that the mirror is
+// updated in the same order as the device.
+func (d *Display) updateMirror(im image.Image, x, y int) {
+	l := d.loupedeck
+	l.mirrorMutex.Lock()
+	defer l.mirrorMutex.Unlock()
+	if !l.mirroring.Load() {
+		return
+	}
+	if l.mirrors == nil {
+		l.mirrors = map[byte]*image.RGBA{}
+	}
+
+	m := l.mirrors[d.id]
+	if m == nil {
+		m = image.NewRGBA(l.surfaceBounds(d.id))
+		draw.Draw(m, m.Bounds(), image.Black, image.Point{}, draw.Src)
+		l.mirrors[d.id] = m
+	}
+
+	b := im.Bounds()
+	r := image.Rect(x, y, x+b.Dx(), y+b.Dy())
+	draw.Draw(m, r, im, b.Min, draw.Src)
+}
+
+// replayMirrors redraws every framebuffer from the client-side
+// mirror, restoring the displays after the Loupedeck has lost its
+// contents.  It does nothing if mirroring is disabled.
+func (l *Loupedeck) replayMirrors() {
+	// Work from copies, so that the mirrors can be drawn on while
+	// they're being replayed.
+	l.mirrorMutex.Lock()
+	mirrors := make(map[byte]*image.RGBA, len(l.mirrors))
+	for id, m := range l.mirrors {
+		c := image.NewRGBA(m.Bounds())
+		copy(c.Pix, m.Pix)
+		mirrors[id] = c
+	}
+	l.mirrorMutex.Unlock()
+
+	for id, m := range mirrors {
+		var format PixelFormat
+		for _, d := range l.displays {
+			if d.id == id {
+				format = d.format
+				break
+			}
+		}
+		b := m.Bounds()
+		d := &Display{
+			loupedeck: l,
+			id:        id,
+			width:     b.Dx(),
+			height:    b.Dy(),
+			format:    format,
+		}
+		d.Draw(m, b.Min.X, b.Min.Y)
+	}
+}
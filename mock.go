@@ -0,0 +1,249 @@
+package loupedeck
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// MockDevice is an in-memory stand-in for a Loupedeck, for running
+// the whole library in tests and CI without any hardware.  It
+// answers Version, Serial, and MCU queries, acknowledges every other
+// message that expects an answer, records everything that it's sent,
+// and can inject button, knob, and touch events as if a user were
+// using it.
+//
+//	d := loupedeck.NewMockDevice()
+//	l, err := loupedeck.ConnectMock(d)
+//	...
+//	go l.Listen()
+//	d.PressButton(loupedeck.Circle)
+type MockDevice struct {
+	// Product is the USB product ID to emulate, which selects the
+	// device profile.  The default is "0004", the Loupedeck Live.
+	Product string
+	// Version and SerialNo are returned in response to queries.
+	Version  [3]byte
+	SerialNo string
+	// MCU is returned in response to MCU queries.
+	MCU []byte
+
+	mutex    sync.Mutex
+	sent     []*Message
+	incoming [][]byte      // waiting for ReadMessage
+	ready    chan struct{} // signalled when incoming is added to
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// errMockClosed is returned by a MockDevice after Close.
+var errMockClosed = errors.New("mock device closed")
+
+// NewMockDevice creates a MockDevice that looks like a Loupedeck Live.
+func NewMockDevice() *MockDevice {
+	return &MockDevice{
+		Product:  "0004",
+		Version:  [3]byte{1, 0, 0},
+		SerialNo: "MOCK0000000001",
+		MCU:      []byte{0, 0, 0, 0},
+		ready:    make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+}
+
+// ConnectMock connects to a MockDevice, exactly as ConnectPath would
+// connect to real hardware, and with the same options as Connect.
+func ConnectMock(d *MockDevice, opts ...Option) (*Loupedeck, error) {
+	o := newConnectOptions(opts)
+	l := newLoupedeck(d, nil, "2ec2", d.Product)
+	l.logger = o.logger
+	if err := l.initialize(o); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ReadMessage returns the next response or event from the device.
+func (d *MockDevice) ReadMessage() (int, []byte, error) {
+	for {
+		d.mutex.Lock()
+		if len(d.incoming) > 0 {
+			m := d.incoming[0]
+			d.incoming = d.incoming[1:]
+			d.mutex.Unlock()
+			return binaryMessage, m, nil
+		}
+		d.mutex.Unlock()
+
+		select {
+		case <-d.ready:
+		case <-d.closed:
+			return 0, nil, errMockClosed
+		}
+	}
+}
+
+// WriteMessage records a message sent to the device, and answers it
+// if it expects an answer.
+func (d *MockDevice) WriteMessage(_ int, data []byte) error {
+	select {
+	case <-d.closed:
+		return errMockClosed
+	default:
+	}
+	if len(data) < 3 {
+		return errors.New("message too short")
+	}
+
+	m := &Message{
+		length:        data[0],
+		messageType:   MessageType(data[1]),
+		transactionID: data[2],
+		data:          append([]byte{}, data[3:]...),
+	}
+	d.mutex.Lock()
+	d.sent = append(d.sent, m)
+	d.mutex.Unlock()
+
+	if m.transactionID == 0 {
+		return nil
+	}
+	var reply []byte
+	switch m.messageType {
+	case Version:
+		reply = d.Version[:]
+	case Serial:
+		reply = []byte(d.SerialNo)
+	case MCU:
+		reply = d.MCU
+	default:
+		reply = []byte{1}
+	}
+	d.inject(m.messageType, m.transactionID, reply)
+	return nil
+}
+
+// Close closes the connection.  ReadMessage returns an error once
+// it's closed, which makes Listen return.
+func (d *MockDevice) Close() error {
+	d.once.Do(func() { close(d.closed) })
+	return nil
+}
+
+// inject queues a message from the device.  Messages are queued
+// without limit, so the device never blocks, even if Listen isn't
+// running.
+func (d *MockDevice) inject(t MessageType, txn byte, data []byte) {
+	d.injectPayload(mockFrame(t, txn, data))
+}
+
+// mockFrame builds a single message from the device.
+func mockFrame(t MessageType, txn byte, data []byte) []byte {
+	length := len(data) + 3
+	if length > maxFrameLength {
+		length = maxFrameLength
+	}
+	return append([]byte{byte(length), byte(t), txn}, data...)
+}
+
+// injectPayload queues a payload from the device, which can hold
+// several messages back to back.
+func (d *MockDevice) injectPayload(b []byte) {
+	d.mutex.Lock()
+	d.incoming = append(d.incoming, b)
+	d.mutex.Unlock()
+	select {
+	case d.ready <- struct{}{}:
+	default:
+	}
+}
+
+// injectControl queues a button or knob event.  These have no
+// transaction ID; the control's 16-bit ID starts where the
+// transaction ID would be.
+func (d *MockDevice) injectControl(t MessageType, id uint16, value byte) {
+	d.inject(t, byte(id>>8), []byte{byte(id), value})
+}
+
+// Sent returns every message sent to the device so far.
+func (d *MockDevice) Sent() []*Message {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]*Message{}, d.sent...)
+}
+
+// SentOfType returns the messages of type t sent to the device so
+// far.
+func (d *MockDevice) SentOfType(t MessageType) []*Message {
+	var out []*Message
+	for _, m := range d.Sent() {
+		if m.messageType == t {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// PressButton sends a button press event.
+func (d *MockDevice) PressButton(b Button) {
+	d.sendButton(b, ButtonDown)
+}
+
+// ReleaseButton sends a button release event.
+func (d *MockDevice) ReleaseButton(b Button) {
+	d.sendButton(b, ButtonUp)
+}
+
+// sendButton sends a button event.
+func (d *MockDevice) sendButton(b Button, s ButtonStatus) {
+	d.injectControl(ButtonPress, uint16(b), byte(s))
+}
+
+// TurnKnob sends knob events for turning k by delta clicks, one event
+// per click.
+func (d *MockDevice) TurnKnob(k Knob, delta int) {
+	v := byte(1)
+	if delta < 0 {
+		v = 255
+		delta = -delta
+	}
+	for i := 0; i < delta; i++ {
+		d.injectControl(KnobRotate, uint16(k), v)
+	}
+}
+
+// SpinKnob sends delta single-detent rotations of a knob all at once,
+// in a single read, the way a real Loupedeck does when a knob is spun
+// quickly.
+func (d *MockDevice) SpinKnob(k Knob, delta int) {
+	v := byte(1)
+	if delta < 0 {
+		v = 255
+		delta = -delta
+	}
+	var b []byte
+	for i := 0; i < delta; i++ {
+		b = append(b, mockFrame(KnobRotate, byte(k>>8), []byte{byte(k), v})...)
+	}
+	d.injectPayload(b)
+}
+
+// Touch sends a touch event at x, y on the main touchscreen, using
+// touch ID id.  Sending more touches with the same id moves the
+// touch.
+func (d *MockDevice) Touch(x, y uint16, id byte) {
+	d.sendTouch(Touch, x, y, id)
+}
+
+// TouchEnd sends the end of a touch.
+func (d *MockDevice) TouchEnd(x, y uint16, id byte) {
+	d.sendTouch(TouchEnd, x, y, id)
+}
+
+// sendTouch sends a touch event.
+func (d *MockDevice) sendTouch(t MessageType, x, y uint16, id byte) {
+	data := []byte{0}
+	data = binary.BigEndian.AppendUint16(data, x)
+	data = binary.BigEndian.AppendUint16(data, y)
+	d.inject(t, 0, append(data, id))
+}
@@ -0,0 +1,546 @@
+package loupedeck
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestLoupedeck connects to a new MockDevice, sets up its displays,
+// and starts Listen.  The connection is closed when the test ends.
+func newTestLoupedeck(t *testing.T) (*Loupedeck, *MockDevice) {
+	t.Helper()
+	d := NewMockDevice()
+	l, err := ConnectMock(d)
+	if err != nil {
+		t.Fatalf("ConnectMock: %v", err)
+	}
+	t.Cleanup(l.Close)
+	if err := l.SetDisplays(); err != nil {
+		t.Fatalf("SetDisplays: %v", err)
+	}
+	go l.Listen()
+	return l, d
+}
+
+func TestMockDevice(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	select {
+	case <-l.serialReceived:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for serial number")
+	}
+	if l.SerialNo != d.SerialNo {
+		t.Errorf("SerialNo = %q, want %q", l.SerialNo, d.SerialNo)
+	}
+	if l.Version != "1.0.0" {
+		t.Errorf("Version = %q, want 1.0.0", l.Version)
+	}
+	if l.Model != ModelLive {
+		t.Errorf("Model = %v, want %v", l.Model, ModelLive)
+	}
+
+	pressed := make(chan Button, 1)
+	l.BindButton(Circle, func(b Button, _ ButtonStatus) { pressed <- b })
+	d.PressButton(Circle)
+	select {
+	case b := <-pressed:
+		if b != Circle {
+			t.Errorf("got button %v, want %v", b, Circle)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("button binding wasn't called")
+	}
+
+	if _, err := l.SendAndWait(l.NewMessage(MCU, []byte{}), time.Second); err != nil {
+		t.Errorf("SendAndWait: %v", err)
+	}
+
+	l.GetDisplay("main").Draw(blankImage(90, 90), 0, 0)
+	if n := len(d.SentOfType(WriteFramebuff)); n != 1 {
+		t.Errorf("sent %d framebuffer writes, want 1", n)
+	}
+	if err := l.WaitForAck(Draw, time.Second); err != nil {
+		t.Errorf("WaitForAck(Draw): %v", err)
+	}
+}
+
+func TestCaptureReplay(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+	var capture bytes.Buffer
+	l.StartCapture(&capture)
+
+	pressed := make(chan struct{}, 1)
+	l.BindButton(Button3, func(Button, ButtonStatus) { pressed <- struct{}{} })
+	d.PressButton(Button3)
+	select {
+	case <-pressed:
+	case <-time.After(time.Second):
+		t.Fatal("button binding wasn't called")
+	}
+	if err := l.StopCapture(); err != nil {
+		t.Fatalf("StopCapture: %v", err)
+	}
+
+	replayed := 0
+	l2, _ := newTestLoupedeck(t)
+	l2.BindButton(Button3, func(Button, ButtonStatus) { replayed++ })
+	if err := l2.Replay(&capture, false); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed != 1 {
+		t.Errorf("binding called %d times during replay, want 1", replayed)
+	}
+}
+
+func TestFastSpinIsCoalesced(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	deltas := make(chan int, 10)
+	l.BindKnob(Knob2, func(_ Knob, v int) { deltas <- v })
+	d.SpinKnob(Knob2, -7)
+	d.TurnKnob(Knob2, 1)
+
+	for _, want := range []int{-7, 1} {
+		select {
+		case v := <-deltas:
+			if v != want {
+				t.Errorf("got delta %d, want %d", v, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("knob binding wasn't called for delta %d", want)
+		}
+	}
+}
+
+func TestBlankUnblanksOnInput(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	pressed := make(chan Button, 2)
+	l.BindButton(Circle, func(b Button, _ ButtonStatus) { pressed <- b })
+	colors := len(d.SentOfType(SetColor))
+	if err := l.Blank(); err != nil {
+		t.Fatalf("Blank: %v", err)
+	}
+	if !l.Blanked() {
+		t.Fatal("not blanked after Blank")
+	}
+	if n, want := len(d.SentOfType(SetColor))-colors, len(l.ledButtons()); n != want {
+		t.Errorf("Blank turned off %d button lights, want %d", n, want)
+	}
+
+	d.PressButton(Circle) // unblanks, and is swallowed
+	d.PressButton(Circle)
+	select {
+	case <-pressed:
+	case <-time.After(time.Second):
+		t.Fatal("button binding wasn't called after unblanking")
+	}
+	if l.Blanked() {
+		t.Error("still blanked after input")
+	}
+	select {
+	case <-pressed:
+		t.Error("the input that unblanked the Loupedeck was dispatched")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConcurrentDraws(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+	l.SetMirroring(true)
+	// The draws are identical, so they'd be skipped otherwise.
+	l.SetDirtyTracking(false)
+
+	main := l.GetDisplay("main")
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 25; j++ {
+				main.Draw(blankImage(45, 45), 45*i, 0)
+			}
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+
+	// Every framebuffer write must be followed directly by its Draw.
+	var last MessageType
+	for _, m := range d.Sent() {
+		if m.Type() == WriteFramebuff && last == WriteFramebuff {
+			t.Fatal("framebuffer writes from different draws were interleaved")
+		}
+		if m.Type() == WriteFramebuff || m.Type() == Draw {
+			last = m.Type()
+		}
+	}
+	if n := len(d.SentOfType(Draw)); n != 200 {
+		t.Errorf("sent %d draws, want 200", n)
+	}
+}
+
+func TestDrawAsyncDropsSupersededDraws(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+	l.SetMirroring(true)
+
+	main := l.GetDisplay("main")
+	for i := 0; i < 100; i++ {
+		main.DrawAsync(blankImage(90, 90), 0, 0)
+	}
+	white := image.NewUniform(color.White)
+	last := image.NewRGBA(image.Rect(0, 0, 90, 90))
+	draw.Draw(last, last.Bounds(), white, image.Point{}, draw.Src)
+	main.DrawAsync(last, 0, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if r, _, _, _ := l.Snapshot(main).At(10, 10).RGBA(); r == 0xffff {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("last DrawAsync was never drawn")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(d.SentOfType(Draw)); n > 10 {
+		t.Errorf("sent %d draws for 101 overlapping DrawAsync calls", n)
+	}
+}
+
+func TestDirtyTrackingSendsOnlyChanges(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	main := l.GetDisplay("main")
+	im := image.NewRGBA(image.Rect(0, 0, 90, 90))
+	draw.Draw(im, im.Bounds(), image.Black, image.Point{}, draw.Src)
+	main.Draw(im, 0, 0)
+	main.Draw(im, 0, 0)
+	if n := len(d.SentOfType(WriteFramebuff)); n != 1 {
+		t.Fatalf("sent %d framebuffer writes for two identical draws, want 1", n)
+	}
+
+	im.Set(20, 30, color.White)
+	im.Set(22, 31, color.White)
+	main.Draw(im, 0, 0)
+	writes := d.SentOfType(WriteFramebuff)
+	if n := len(writes); n != 2 {
+		t.Fatalf("sent %d framebuffer writes, want 2", n)
+	}
+	header := writes[1].Data()[:framebufferHeaderLength]
+	want := main.framebufferHeader(main.offsetx+20, main.offsety+30, 3, 2)
+	if !bytes.Equal(header, want) {
+		t.Errorf("got header % x, want % x", header, want)
+	}
+}
+
+func TestIdenticalDrawAfterOverdrawIsSent(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	main := l.GetDisplay("main")
+	a := image.NewRGBA(image.Rect(0, 0, 90, 90))
+	draw.Draw(a, a.Bounds(), image.White, image.Point{}, draw.Src)
+	main.Draw(a, 0, 0)
+	main.Draw(a, 0, 0)
+	main.Draw(blankImage(20, 20), 40, 40)
+	main.Draw(a, 0, 0)
+	if n := len(d.SentOfType(WriteFramebuff)); n != 3 {
+		t.Errorf("sent %d framebuffer writes, want 3", n)
+	}
+}
+
+func TestDrawBufferingSendsOneDrawPerDisplay(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	if err := l.SetDrawBuffering(true); err != nil {
+		t.Fatalf("SetDrawBuffering: %v", err)
+	}
+	main := l.GetDisplay("main")
+	for i := 0; i < 3; i++ {
+		main.Draw(blankImage(90, 90), 90*i, 0)
+	}
+	l.GetDisplay("left").Draw(blankImage(60, 60), 0, 0)
+	if n := len(d.SentOfType(WriteFramebuff)) + len(d.SentOfType(Draw)); n != 0 {
+		t.Fatalf("sent %d messages before Flush, want 0", n)
+	}
+
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := len(d.SentOfType(WriteFramebuff)); n != 4 {
+		t.Errorf("sent %d framebuffer writes, want 4", n)
+	}
+	ids := map[string]bool{}
+	for _, m := range d.SentOfType(Draw) {
+		ids[string(m.Data())] = true
+	}
+	if n := len(d.SentOfType(Draw)); n != len(ids) {
+		t.Errorf("sent %d draws for %d displays", n, len(ids))
+	}
+}
+
+func TestAnimationFrameRateIsCapped(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+	// A FrameClock that looks like it's already running, so that
+	// subscribing doesn't start its ticker; the frames are driven by
+	// advanceAnimations below instead.
+	l.frameClock = NewFrameClock(DefaultFrameRate)
+	l.frameClock.stop = make(chan struct{})
+	l.SetDirtyTracking(false)
+
+	r := l.CellRegion(Touch1)
+	l.SetAnimationFrameRate(r.display, 5)
+	calls := 0
+	a := l.Animate(r, func(frame int, _ time.Duration) image.Image {
+		calls++
+		im := image.NewRGBA(image.Rect(0, 0, 90, 90))
+		im.Pix[0] = byte(frame) // so that no two frames are identical
+		return im
+	})
+
+	// A second of frames at the default 20fps, capped at 5fps.
+	start := time.Now()
+	for i := 0; i < DefaultFrameRate; i++ {
+		l.advanceAnimations(start.Add(time.Duration(i) * time.Second / DefaultFrameRate))
+		l.DrawQueue().frame(0)
+	}
+	if calls != 5 {
+		t.Errorf("animation rendered %d frames in a second, want 5", calls)
+	}
+	if n := len(d.SentOfType(WriteFramebuff)); n != 5 {
+		t.Errorf("sent %d framebuffer writes, want 5", n)
+	}
+
+	a.Stop()
+	l.advanceAnimations(start.Add(2 * time.Second))
+	if calls != 5 {
+		t.Errorf("animation rendered %d frames after Stop", calls-5)
+	}
+}
+
+func TestSetMirroringDuringDraws(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+	l.SetDirtyTracking(false)
+
+	main := l.GetDisplay("main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			main.Draw(blankImage(90, 90), 0, 0)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		l.SetMirroring(i%2 == 0)
+		l.Snapshot(main)
+	}
+	<-done
+}
+
+func TestConfirmSuspendsMappingsAndGestures(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+
+	var acted, swiped atomic.Int32
+	l.RegisterAction("act", func(ActionEvent) { acted.Add(1) })
+	if err := l.MapControl(TouchControl(Touch12), "act", nil); err != nil {
+		t.Fatalf("MapControl: %v", err)
+	}
+	l.BindSwipe(func(SwipeDirection) { swiped.Add(1) })
+
+	confirmed := make(chan bool, 1)
+	if err := l.ShowConfirm("Really?", func(ok bool) { confirmed <- ok }); err != nil {
+		t.Fatalf("ShowConfirm: %v", err)
+	}
+
+	// A swipe that ends on Confirm.
+	d.Touch(100, 225, 1)
+	d.Touch(250, 225, 1)
+	d.TouchEnd(375, 225, 1)
+	select {
+	case ok := <-confirmed:
+		if !ok {
+			t.Error("dialog was cancelled, want confirmed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dialog wasn't dismissed")
+	}
+	if n := acted.Load(); n != 0 {
+		t.Errorf("mapped action ran %d times while the dialog was open", n)
+	}
+	if n := swiped.Load(); n != 0 {
+		t.Errorf("swipe binding ran %d times while the dialog was open", n)
+	}
+	if _, ok := l.GetMapping(TouchControl(Touch12)); !ok {
+		t.Error("mapping wasn't restored after the dialog closed")
+	}
+}
+
+func TestIdlePageIsShownFromListen(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+
+	pages := []*Page{{Name: "one"}, {Name: "two"}}
+	idle := &Page{Name: "idle"}
+	p := l.NewPager(pages)
+	p.SetIdlePage(idle, 50*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		active := make(chan *Page, 1)
+		l.post(func() { active <- p.ActivePage() })
+		if <-active == idle {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("idle page was never shown")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestNamespaceSwitchRestoresButtonColors(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	a := l.Namespace("a")
+	a.SetPages([]*Page{{ButtonColors: map[Button]color.RGBA{Button1: red}}})
+	_ = a.SetButtonColor(Button2, green)
+	l.Namespace("b")
+
+	if err := l.Focus("a"); err != nil {
+		t.Fatalf("Focus: %v", err)
+	}
+	if c, _ := l.GetButtonColor(Button1); c != red {
+		t.Errorf("Button1 is %v with a focused, want the page's %v", c, red)
+	}
+	if c, _ := l.GetButtonColor(Button2); c != green {
+		t.Errorf("Button2 is %v with a focused, want %v", c, green)
+	}
+
+	if err := l.Focus("b"); err != nil {
+		t.Fatalf("Focus: %v", err)
+	}
+	for _, b := range []Button{Button1, Button2} {
+		if c, _ := l.GetButtonColor(b); c != (color.RGBA{}) {
+			t.Errorf("button %d is %v after switching away, want it restored", b, c)
+		}
+	}
+}
+
+func TestImageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+	l.SetImageCacheSize(2)
+
+	r := l.CellRegion(Touch1)
+	renders := map[string]int{}
+	draw := func(id string) {
+		l.DrawCached(r, id, func() image.Image {
+			renders[id]++
+			return blankImage(90, 90)
+		})
+	}
+	for _, id := range []string{"a", "b", "a", "c", "a", "b"} {
+		draw(id)
+	}
+	// "b" was the least recently used image when "c" was cached.
+	want := map[string]int{"a": 1, "b": 2, "c": 1}
+	for id, n := range want {
+		if renders[id] != n {
+			t.Errorf("%q rendered %d times, want %d", id, renders[id], n)
+		}
+	}
+}
+
+func TestDrawCachedRedrawsAfterReset(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+
+	r := l.CellRegion(Touch1)
+	renders := 0
+	render := func() image.Image {
+		renders++
+		return blankImage(90, 90)
+	}
+	l.DrawCached(r, "a", render)
+	if err := l.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if !l.DrawCached(r, "a", render) {
+		t.Error("DrawCached didn't draw after Reset cleared the display")
+	}
+	if renders != 1 {
+		t.Errorf("rendered %d times, want the cached image reused", renders)
+	}
+}
+
+func TestWatchdogReinitDuringDraws(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+	l.SetMirroring(true)
+	l.SetDirtyTracking(false)
+
+	main := l.GetDisplay("main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			main.Draw(blankImage(90, 90), 0, 0)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		l.reinit()
+	}
+	<-done
+	if l.GetDisplay("main") != main {
+		t.Error("reinit replaced the main Display")
+	}
+}
+
+func TestDrawQueueIsCreatedOnce(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+
+	queues := make(chan *DrawQueue, 8)
+	for i := 0; i < cap(queues); i++ {
+		go func() { queues <- l.DrawQueue() }()
+	}
+	first := <-queues
+	for i := 1; i < cap(queues); i++ {
+		if q := <-queues; q != first {
+			t.Fatal("concurrent DrawQueue calls created more than one DrawQueue")
+		}
+	}
+}
+
+func TestFrameClockIsCreatedOnce(t *testing.T) {
+	l, _ := newTestLoupedeck(t)
+
+	clocks := make(chan *FrameClock, 8)
+	for i := 0; i < cap(clocks); i++ {
+		go func() { clocks <- l.FrameClock() }()
+	}
+	first := <-clocks
+	for i := 1; i < cap(clocks); i++ {
+		if c := <-clocks; c != first {
+			t.Fatal("concurrent FrameClock calls created more than one FrameClock")
+		}
+	}
+}
+
+func TestFailedBlankIsRolledBack(t *testing.T) {
+	l, d := newTestLoupedeck(t)
+	d.Close()
+
+	if err := l.Blank(); err == nil {
+		t.Fatal("Blank succeeded on a closed device")
+	}
+	if l.Blanked() {
+		t.Error("still blanked after Blank failed")
+	}
+	if l.holdDraws.Load() {
+		t.Error("draws are still held after Blank failed")
+	}
+}
@@ -0,0 +1,72 @@
+package loupedeck
+
+import (
+	"fmt"
+)
+
+// Model identifies which kind of Loupedeck is connected.
+type Model int
+
+const (
+	ModelUnknown Model = iota
+	ModelLive
+	ModelLiveS
+	ModelCT
+	ModelCTv2
+	ModelRazer
+	ModelRazerX
+	ModelPlus
+)
+
+// String returns the product name of the Model.
+func (m Model) String() string {
+	switch m {
+	case ModelLive:
+		return "Loupedeck Live"
+	case ModelLiveS:
+		return "Loupedeck Live S"
+	case ModelCT:
+		return "Loupedeck CT v1"
+	case ModelCTv2:
+		return "Loupedeck CT v2"
+	case ModelRazer:
+		return "Razer Stream Controller"
+	case ModelRazerX:
+		return "Razer Stream Controller X"
+	case ModelPlus:
+		return "Loupedeck+"
+	}
+	return fmt.Sprintf("Model(%d)", int(m))
+}
+
+// productModels maps USB product IDs to Models.
+var productModels = map[string]Model{
+	"0003": ModelCT,
+	"0004": ModelLive,
+	"0006": ModelLiveS,
+	"0007": ModelCTv2,
+	"0d06": ModelRazer,
+	"0d09": ModelRazerX,
+}
+
+// detectModel works out which Model is connected, from the USB
+// product ID.  Unknown products stay ModelUnknown, rather than being
+// guessed at, so that SetDisplays puts them in safe mode (see
+// SafeMode).  detectModel is called again once the device answers our
+// Version and Serial queries, so that the unknown device is reported
+// along with what it says about itself.
+func (l *Loupedeck) detectModel() {
+	m := ModelUnknown
+	switch {
+	case l.hid:
+		m = ModelPlus
+	case productModels[l.Product] != ModelUnknown:
+		m = productModels[l.Product]
+	case l.Version != "" && l.SerialNo != "":
+		l.log().Warn("Unknown Loupedeck product", "product", l.Product, "version", l.Version, "serial", l.SerialNo)
+	}
+	if m != l.Model {
+		l.log().Info("Detected Loupedeck model", "model", m)
+		l.Model = m
+	}
+}
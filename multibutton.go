@@ -28,9 +28,11 @@ import (
 type MultiButton struct {
 	loupedeck *Loupedeck
 	display   *Display
+	region    *Region
 	images    []image.Image
 	values    []int
 	value     *WatchedInt
+	button    TouchButton
 	x, y      int
 }
 
@@ -73,16 +75,18 @@ func touchToXYMain(b TouchButton) (int, int) {
 // Additional images and values can be added via the Add function.
 func (l *Loupedeck) NewMultiButton(watchedint *WatchedInt, b TouchButton, im image.Image, val int) *MultiButton {
 	display := l.GetDisplay("main")
-	x, y := touchToXYMain(b)
+	x, y := l.cellXY(b)
 
 	m := &MultiButton{
 		loupedeck: l,
 		images:    []image.Image{im},
 		values:    []int{val},
 		value:     watchedint,
+		button:    b,
 		x:         x,
 		y:         y,
 		display:   display,
+		region:    l.CellRegion(b),
 	}
 
 	watchedint.AddWatcher(func(i int) {
@@ -104,9 +108,19 @@ func (m *MultiButton) Add(im image.Image, value int) {
 	m.values = append(m.values, value)
 }
 
-// Draw redraws the MultiButton on the Loupedeck live.
+// Draw redraws the MultiButton on the Loupedeck live.  If the
+// button's cell already shows the current image, then nothing is
+// sent.
 func (m *MultiButton) Draw() {
-	m.display.Draw(m.images[m.GetCur()], m.x, m.y)
+	cur := m.GetCur()
+	if m.region == nil {
+		m.display.Draw(m.images[cur], m.x, m.y)
+		return
+	}
+	id := fmt.Sprintf("multibutton:%p:%d", m, cur)
+	m.loupedeck.DrawCached(m.region, id, func() image.Image {
+		return m.images[cur]
+	})
 }
 
 // GetCur gets the current value of the MultiButton.  The
@@ -0,0 +1,238 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"sync"
+)
+
+// Namespace holds one client's bindings, button colors, and pages, so
+// that several applications can share a single Loupedeck without
+// overwriting each other's controls.  This is meant for programs that
+// drive the Loupedeck on behalf of other applications, such as a
+// daemon that applications register layouts with.
+//
+// Only the focused Namespace's bindings are installed, and only its
+// pages and button colors are shown.  Clients can add bindings and
+// change pages at any time; changes to a Namespace that doesn't have
+// focus are kept, and take effect when it's focused.  See
+// Loupedeck.Focus.
+type Namespace struct {
+	Name string
+
+	// Bindings holds the Namespace's input bindings.  Use it
+	// instead of the Loupedeck's Bind functions.
+	Bindings *BindingSet
+
+	loupedeck    *Loupedeck
+	pages        []*Page
+	page         int
+	pager        *Pager // shows pages; not bound to any gestures
+	buttonColors map[Button]color.RGBA
+	baseColors   map[Button]color.RGBA // button colors from before the Namespace was focused
+}
+
+// namespaceState holds the Loupedeck's Namespaces.
+type namespaceState struct {
+	mutex      sync.Mutex
+	namespaces map[string]*Namespace
+	focus      []string // focus stack; the last entry has focus
+}
+
+// Namespace returns the Namespace with the specified name, creating
+// it if it doesn't exist yet.  New Namespaces don't have focus.
+func (l *Loupedeck) Namespace(name string) *Namespace {
+	s := &l.namespaceState
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.namespaces == nil {
+		s.namespaces = map[string]*Namespace{}
+	}
+	if ns := s.namespaces[name]; ns != nil {
+		return ns
+	}
+	ns := &Namespace{
+		Name:         name,
+		Bindings:     l.NewBindingSet(),
+		loupedeck:    l,
+		pager:        &Pager{loupedeck: l, baseColors: map[Button]color.RGBA{}},
+		buttonColors: map[Button]color.RGBA{},
+		baseColors:   map[Button]color.RGBA{},
+	}
+	s.namespaces[name] = ns
+	return ns
+}
+
+// Namespaces returns the names of all Namespaces, sorted.
+func (l *Loupedeck) Namespaces() []string {
+	s := &l.namespaceState
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make([]string, 0, len(s.namespaces))
+	for n := range s.namespaces {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FocusedNamespace returns the Namespace that currently has focus, or
+// nil if none does.
+func (l *Loupedeck) FocusedNamespace() *Namespace {
+	s := &l.namespaceState
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.focused()
+}
+
+// focused returns the focused Namespace.  The mutex must be held.
+func (s *namespaceState) focused() *Namespace {
+	if len(s.focus) == 0 {
+		return nil
+	}
+	return s.namespaces[s.focus[len(s.focus)-1]]
+}
+
+// Focus gives focus to the named Namespace: the previously focused
+// Namespace's bindings are removed and its page is deactivated, and
+// then the new Namespace's bindings, button colors, and current page
+// are installed.  The previous Namespace is remembered, and gets
+// focus back when the new one calls Release.
+func (l *Loupedeck) Focus(name string) error {
+	s := &l.namespaceState
+	s.mutex.Lock()
+	ns := s.namespaces[name]
+	if ns == nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("unknown namespace %q", name)
+	}
+	old := s.focused()
+	s.focus = append(removeName(s.focus, name), name)
+	s.mutex.Unlock()
+
+	l.switchNamespace(old, ns)
+	return nil
+}
+
+// Release gives up focus for the named Namespace, returning focus to
+// whichever Namespace had it before.  If the Namespace doesn't have
+// focus, it's just removed from the focus history.
+func (l *Loupedeck) Release(name string) {
+	s := &l.namespaceState
+	s.mutex.Lock()
+	old := s.focused()
+	s.focus = removeName(s.focus, name)
+	ns := s.focused()
+	s.mutex.Unlock()
+
+	if old != nil && old.Name == name {
+		l.switchNamespace(old, ns)
+	}
+}
+
+// RemoveNamespace releases and forgets the named Namespace.
+func (l *Loupedeck) RemoveNamespace(name string) {
+	l.Release(name)
+	s := &l.namespaceState
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.namespaces, name)
+}
+
+// removeName returns names without name.
+func removeName(names []string, name string) []string {
+	out := names[:0]
+	for _, n := range names {
+		if n != name {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// switchNamespace hands the Loupedeck over from one Namespace to
+// another.  Either may be nil.  The old Namespace's page is hidden and
+// the button colors that it set are put back the way they were before
+// it was focused.
+func (l *Loupedeck) switchNamespace(from, to *Namespace) {
+	if from == to {
+		return
+	}
+	if from != nil {
+		from.Bindings.Remove()
+		from.pager.hide()
+		for b := range from.buttonColors {
+			_ = l.SetButtonColor(b, from.baseColors[b])
+		}
+	}
+	if to == nil {
+		return
+	}
+	to.Bindings.Apply()
+	to.baseColors = l.ButtonColors()
+	for b, c := range to.buttonColors {
+		_ = l.SetButtonColor(b, c)
+	}
+	to.activatePage()
+}
+
+// focused returns true if the Namespace has focus.
+func (ns *Namespace) focused() bool {
+	return ns.loupedeck.FocusedNamespace() == ns
+}
+
+// SetButtonColor sets the color of a button while the Namespace has
+// focus.
+func (ns *Namespace) SetButtonColor(b Button, c color.RGBA) error {
+	ns.buttonColors[b] = c
+	if ns.focused() {
+		if _, ok := ns.baseColors[b]; !ok {
+			ns.baseColors[b], _ = ns.loupedeck.GetButtonColor(b)
+		}
+		return ns.loupedeck.SetButtonColor(b, c)
+	}
+	return nil
+}
+
+// SetPages sets the Namespace's pages, and shows the first one if the
+// Namespace has focus.
+func (ns *Namespace) SetPages(pages []*Page) {
+	ns.pages = pages
+	ns.page = 0
+	if ns.focused() {
+		ns.activatePage()
+	}
+}
+
+// ShowPage switches the Namespace to page i.  If the Namespace
+// doesn't have focus, the page is shown once it does.
+func (ns *Namespace) ShowPage(i int) {
+	if i < 0 || i >= len(ns.pages) {
+		return
+	}
+	ns.page = i
+	if ns.focused() {
+		ns.activatePage()
+	}
+}
+
+// currentPage returns the Namespace's current page, or nil.
+func (ns *Namespace) currentPage() *Page {
+	if ns.page < len(ns.pages) {
+		return ns.pages[ns.page]
+	}
+	return nil
+}
+
+// activatePage shows the current page, the same way that a Pager
+// does, including its Images and ButtonColors.  If there's no current
+// page, whatever page was shown before is hidden.
+func (ns *Namespace) activatePage() {
+	page := ns.currentPage()
+	if page == nil {
+		ns.pager.hide()
+		return
+	}
+	ns.pager.switchTo(page, SwipeLeft, false)
+}
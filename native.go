@@ -0,0 +1,171 @@
+package loupedeck
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// Websocket frame opcodes used by nativeConn.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// nativeConn speaks the Loupedeck's serial protocol directly, without
+// going through Gorilla's websocket client.  The protocol is just
+// websocket frames, so this is a minimal implementation of websocket
+// framing, with no HTTP upgrade handshake.  See WithNativeFraming.
+type nativeConn struct {
+	rw         io.ReadWriteCloser
+	r          *bufio.Reader
+	writeMutex sync.Mutex
+}
+
+// newNativeConn returns a nativeConn that talks over rw.
+func newNativeConn(rw io.ReadWriteCloser) *nativeConn {
+	return &nativeConn{
+		rw: rw,
+		r:  bufio.NewReader(rw),
+	}
+}
+
+// ReadMessage returns the next complete message, reassembling
+// fragmented messages and answering pings along the way.
+func (n *nativeConn) ReadMessage() (int, []byte, error) {
+	var msgType int
+	var msg []byte
+	for {
+		fin, op, payload, err := n.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := n.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, fmt.Errorf("connection closed by Loupedeck")
+		case opContinuation:
+			if msgType == 0 {
+				return 0, nil, fmt.Errorf("unexpected continuation frame")
+			}
+		case opText, opBinary:
+			msgType = int(op)
+			msg = msg[:0]
+		default:
+			return 0, nil, fmt.Errorf("unknown websocket opcode 0x%x", op)
+		}
+
+		msg = append(msg, payload...)
+		if fin {
+			return msgType, msg, nil
+		}
+	}
+}
+
+// readFrame reads a single websocket frame.
+func (n *nativeConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(n.r, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	op = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(n.r, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(n.r, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > 1<<24 {
+		err = fmt.Errorf("websocket frame too large: %d bytes", length)
+		return
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err = io.ReadFull(n.r, key[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(n.r, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(key, payload)
+	}
+	return
+}
+
+// WriteMessage sends data as a single masked frame.
+func (n *nativeConn) WriteMessage(messageType int, data []byte) error {
+	return n.writeFrame(byte(messageType), data)
+}
+
+// writeFrame sends a single, final, masked frame.
+func (n *nativeConn) writeFrame(op byte, data []byte) error {
+	frame := make([]byte, 0, len(data)+14)
+	frame = append(frame, 0x80|op)
+	switch {
+	case len(data) < 126:
+		frame = append(frame, 0x80|byte(len(data)))
+	case len(data) <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(data)))
+	}
+	// Clients are required to mask their frames with an
+	// unpredictable key.  The Loupedeck doesn't check, but a fresh
+	// key per frame costs next to nothing.
+	var key [4]byte
+	binary.BigEndian.PutUint32(key[:], rand.Uint32())
+	frame = append(frame, key[:]...)
+	start := len(frame)
+	frame = append(frame, data...)
+	maskBytes(key, frame[start:])
+
+	n.writeMutex.Lock()
+	defer n.writeMutex.Unlock()
+	_, err := n.rw.Write(frame)
+	return err
+}
+
+// Close closes the underlying connection.
+func (n *nativeConn) Close() error {
+	return n.rw.Close()
+}
+
+// maskBytes applies (or removes) a websocket masking key.
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
@@ -0,0 +1,155 @@
+package loupedeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// pipeRWC is an io.ReadWriteCloser that reads from in and records
+// writes in out.
+type pipeRWC struct {
+	in  *bytes.Buffer
+	out bytes.Buffer
+}
+
+func (p *pipeRWC) Read(b []byte) (int, error)  { return p.in.Read(b) }
+func (p *pipeRWC) Write(b []byte) (int, error) { return p.out.Write(b) }
+func (p *pipeRWC) Close() error                { return nil }
+
+// newTestNativeConn returns a nativeConn that reads the given frames.
+func newTestNativeConn(frames ...[]byte) (*nativeConn, *pipeRWC) {
+	p := &pipeRWC{in: bytes.NewBuffer(bytes.Join(frames, nil))}
+	return newNativeConn(p), p
+}
+
+// serverFrame builds an unmasked frame, as the Loupedeck sends them.
+func serverFrame(fin bool, op byte, payload []byte) []byte {
+	b := op
+	if fin {
+		b |= 0x80
+	}
+	frame := []byte{b}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	return append(frame, payload...)
+}
+
+func TestNativeRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 5, 125, 126, 200, 0xffff, 0x10000, 70000} {
+		data := bytes.Repeat([]byte{0xa5, 0x01, 0x7f}, size/3+1)[:size]
+
+		w, wp := newTestNativeConn()
+		if err := w.WriteMessage(opBinary, data); err != nil {
+			t.Fatalf("WriteMessage(%d bytes): %v", size, err)
+		}
+		frame := wp.out.Bytes()
+		wantLen := byte(size)
+		switch {
+		case size > 0xffff:
+			wantLen = 127
+		case size >= 126:
+			wantLen = 126
+		}
+		if got := frame[1] & 0x7f; got != wantLen {
+			t.Errorf("%d bytes: length field is %d, want %d", size, got, wantLen)
+		}
+
+		r, _ := newTestNativeConn(frame)
+		op, got, err := r.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage(%d bytes): %v", size, err)
+		}
+		if op != opBinary || !bytes.Equal(got, data) {
+			t.Errorf("%d bytes: round trip gave opcode %d and %d bytes", size, op, len(got))
+		}
+	}
+}
+
+func TestNativeFramesAreMasked(t *testing.T) {
+	data := []byte("hello, loupedeck")
+	w, wp := newTestNativeConn()
+	if err := w.WriteMessage(opBinary, data); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	frame := wp.out.Bytes()
+	if frame[0] != 0x80|opBinary {
+		t.Errorf("first byte is 0x%02x, want FIN and opBinary", frame[0])
+	}
+	if frame[1]&0x80 == 0 {
+		t.Fatal("mask bit isn't set")
+	}
+	var key [4]byte
+	copy(key[:], frame[2:6])
+	payload := append([]byte{}, frame[6:]...)
+	maskBytes(key, payload)
+	if !bytes.Equal(payload, data) {
+		t.Errorf("unmasked payload is %q, want %q", payload, data)
+	}
+}
+
+func TestNativeReassemblesFragments(t *testing.T) {
+	r, _ := newTestNativeConn(
+		serverFrame(false, opBinary, []byte("one ")),
+		serverFrame(false, opContinuation, []byte("two ")),
+		serverFrame(true, opContinuation, []byte("three")),
+	)
+	op, got, err := r.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if op != opBinary || string(got) != "one two three" {
+		t.Errorf("got opcode %d and %q, want %d and %q", op, got, opBinary, "one two three")
+	}
+
+	r, _ = newTestNativeConn(serverFrame(true, opContinuation, []byte("stray")))
+	if _, _, err := r.ReadMessage(); err == nil {
+		t.Error("a continuation frame with nothing to continue was accepted")
+	}
+}
+
+func TestNativeAnswersPings(t *testing.T) {
+	r, p := newTestNativeConn(
+		serverFrame(true, opPing, []byte("ping!")),
+		serverFrame(true, opPong, nil),
+		serverFrame(true, opBinary, []byte{1, 2, 3}),
+	)
+	op, got, err := r.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if op != opBinary || !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("got opcode %d and % x, want the binary message", op, got)
+	}
+
+	pong, _ := newTestNativeConn(p.out.Bytes())
+	_, op2, payload, err := pong.readFrame()
+	if err != nil {
+		t.Fatalf("reading the pong: %v", err)
+	}
+	if op2 != opPong || string(payload) != "ping!" {
+		t.Errorf("answered with opcode %d and %q, want a pong echoing %q", op2, payload, "ping!")
+	}
+}
+
+func TestNativeClose(t *testing.T) {
+	r, _ := newTestNativeConn(serverFrame(true, opClose, nil))
+	_, _, err := r.ReadMessage()
+	if err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("ReadMessage after a close frame returned %v, want a closed error", err)
+	}
+
+	r, _ = newTestNativeConn(serverFrame(true, opBinary, []byte("trunc"))[:4])
+	if _, _, err := r.ReadMessage(); err == nil {
+		t.Error("a truncated frame was accepted")
+	}
+}
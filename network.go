@@ -0,0 +1,65 @@
+package loupedeck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// networkDialTimeout is how long ConnectURL waits for the websocket
+// handshake.
+const networkDialTimeout = 5 * time.Second
+
+// ConnectURL connects to a Loupedeck Live running 1.x firmware, which
+// shows up as a USB network device and speaks the websocket protocol
+// over the network instead of over a serial port, for example
+// "ws://100.127.1.1/".  Apart from how it connects, it works just like
+// a Loupedeck connected with ConnectPath.
+//
+// 1.x firmware only ever shipped on the Loupedeck Live, so that's what
+// the device is assumed to be; this matters for SetDisplays.
+// Reconnection (see SetAutoReconnect) isn't supported for network
+// connections.
+func ConnectURL(u string) (*Loupedeck, error) {
+	return ConnectURLContext(context.Background(), u)
+}
+
+// ConnectURLContext is like ConnectURL, but gives up and returns ctx's
+// error if ctx is cancelled or its deadline passes before the
+// websocket handshake completes.
+func ConnectURLContext(ctx context.Context, u string) (*Loupedeck, error) {
+	return connectURL(ctx, u, newConnectOptions(nil))
+}
+
+func connectURL(ctx context.Context, u string, o *connectOptions) (*Loupedeck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	timeout := o.handshakeTimeout
+	if timeout <= 0 {
+		timeout = networkDialTimeout
+	}
+	dialer := websocket.Dialer{
+		HandshakeTimeout: timeout,
+	}
+
+	o.log().Info("Attempting to open network websocket connection", "url", u)
+	conn, resp, err := dialer.DialContext(ctx, u, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("unable to connect to %q: %v", u, err)
+	}
+	o.log().Info("Connect successful", "resp", resp)
+
+	l := newLoupedeck(conn, nil, "2ec2", "0004")
+	l.logger = o.logger
+	if err := l.initialize(o); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return l, nil
+}
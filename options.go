@@ -0,0 +1,188 @@
+package loupedeck
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+const (
+	// defaultHandshakeTimeout is how long each websocket handshake
+	// attempt over the serial port gets before it's retried.  See
+	// tryConnect.
+	defaultHandshakeTimeout = 2 * time.Second
+
+	// defaultRetries is the number of times that the handshake is
+	// retried after timing out.
+	defaultRetries = 1
+
+	// defaultBrightness is the brightness set when connecting.
+	defaultBrightness = 9
+)
+
+// Option configures how Connect connects to a Loupedeck.
+type Option func(*connectOptions)
+
+// connectOptions holds the settings made by Options.
+type connectOptions struct {
+	target           string
+	handshakeTimeout time.Duration
+	retries          int
+	logger           *slog.Logger
+	brightness       int
+	reset            bool
+	portReset        bool
+	native           bool
+	hidProduct       string
+}
+
+// newConnectOptions returns the default options, with opts applied.
+func newConnectOptions(opts []Option) *connectOptions {
+	o := &connectOptions{
+		retries:    defaultRetries,
+		brightness: defaultBrightness,
+		reset:      true,
+		portReset:  true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// log returns the logger to use while connecting.
+func (o *connectOptions) log() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return slog.Default()
+}
+
+// WithTarget makes Connect connect to a specific Loupedeck, given
+// either the path to its serial device (see ConnectPath) or a ws://
+// URL for devices running 1.x firmware (see ConnectURL).  Without it,
+// Connect finds a Loupedeck automatically, like ConnectAuto.
+func WithTarget(target string) Option {
+	return func(o *connectOptions) {
+		o.target = target
+	}
+}
+
+// WithHandshakeTimeout sets how long each attempt at the websocket
+// handshake gets before it's retried.  The default is 2 seconds.  For
+// ws:// URLs, it bounds the only attempt.
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(o *connectOptions) {
+		o.handshakeTimeout = d
+	}
+}
+
+// WithRetries sets how many times the websocket handshake is retried
+// after timing out.  The default is 1.  The last attempt isn't timed
+// out, so use ConnectContext with a deadline to bound the total time.
+func WithRetries(n int) Option {
+	return func(o *connectOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.retries = n
+	}
+}
+
+// WithLogger sets the logger used by the Loupedeck, instead of the
+// default slog logger.  This is handy for tagging messages from
+// different devices; see DeviceManager.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *connectOptions) {
+		o.logger = logger
+	}
+}
+
+// WithInitialBrightness sets the brightness set when connecting,
+// between 0 and MaxBrightness.  The default is 9.
+func WithInitialBrightness(b int) Option {
+	return func(o *connectOptions) {
+		o.brightness = b
+	}
+}
+
+// WithoutReset skips resetting the Loupedeck when connecting, so that
+// whatever is on its displays stays there until it's redrawn.
+func WithoutReset() Option {
+	return func(o *connectOptions) {
+		o.reset = false
+	}
+}
+
+// WithoutPortReset skips toggling DTR and RTS and flushing the serial
+// port's buffers before each websocket handshake.  See
+// SerialWebSockConn.ResetLines.
+func WithoutPortReset() Option {
+	return func(o *connectOptions) {
+		o.portReset = false
+	}
+}
+
+// WithNativeFraming talks to serial Loupedecks using a built-in
+// implementation of the protocol's framing, instead of running the
+// Gorilla websocket library over the serial port.  This skips the
+// fake HTTP upgrade handshake, which is where most connection
+// failures happen, and avoids some overhead on every message.  It has
+// no effect on ws:// URLs or HID devices.
+func WithNativeFraming() Option {
+	return func(o *connectOptions) {
+		o.native = true
+	}
+}
+
+// WithExperimentalHID makes Connect (when it's finding a Loupedeck
+// automatically) fall back to a Loupedeck+ with the specified USB
+// product ID (as shown by lsusb), connected through its HID interface,
+// if no serial Loupedeck is found.  Support for the Loupedeck+ is
+// experimental, since it hasn't been verified on hardware; see
+// ConnectHID.
+func WithExperimentalHID(product string) Option {
+	return func(o *connectOptions) {
+		o.hidProduct = product
+	}
+}
+
+// Connect connects to a Loupedeck, configured by opts.  With no
+// options, it's the same as ConnectAuto.
+//
+//	l, err := loupedeck.Connect(
+//		loupedeck.WithTarget("/dev/ttyACM0"),
+//		loupedeck.WithInitialBrightness(5),
+//	)
+func Connect(opts ...Option) (*Loupedeck, error) {
+	return ConnectContext(context.Background(), opts...)
+}
+
+// ConnectContext is like Connect, but gives up and returns ctx's error
+// if ctx is cancelled or its deadline passes before the connection is
+// made.
+func ConnectContext(ctx context.Context, opts ...Option) (*Loupedeck, error) {
+	o := newConnectOptions(opts)
+	switch {
+	case o.target == "":
+		return connectAuto(ctx, o)
+	case isURL(o.target):
+		return connectURL(ctx, o.target, o)
+	}
+	return connectPath(ctx, o.target, o)
+}
+
+// isURL returns true if target is a ws:// or wss:// URL.
+func isURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && (u.Scheme == "ws" || u.Scheme == "wss")
+}
+
+// log returns the Loupedeck's logger.  See WithLogger.
+func (l *Loupedeck) log() *slog.Logger {
+	if l.logger != nil {
+		return l.logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,316 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/jphsd/graphics2d"
+)
+
+// pageSlideFrames is the number of frames used for the slide
+// animation when switching pages.
+const pageSlideFrames = 6
+
+// Page is one screen's worth of content for the main display.
+type Page struct {
+	Name string
+
+	// Activate is called whenever the page is shown.  It should
+	// draw the page onto the main display and bind any touch
+	// buttons that the page uses.  The main display is cleared
+	// and all main-display touch bindings are removed before
+	// Activate is called.
+	Activate func(*Loupedeck)
+
+	// Deactivate, if set, is called when the page is hidden.  It
+	// should stop any animations or other background drawing.
+	Deactivate func(*Loupedeck)
+
+	// Images, if set, renders the image for each touch button on
+	// the page.  They're drawn just before Activate is called,
+	// and can be rendered ahead of time with Warmup, which calls
+	// them from a background goroutine.
+	Images map[TouchButton]func() image.Image
+
+	// ButtonColors sets the colors of the hardware buttons while
+	// the page is shown.  Buttons that aren't listed keep
+	// whatever color they had before any page changed them.
+	ButtonColors map[Button]color.RGBA
+}
+
+// Pager switches the main display between a set of Pages.  Swiping
+// left on the touchscreen moves to the next page, and swiping right
+// moves to the previous page, wrapping around at the ends.  This is
+// the main-display equivalent of WidgetHolder.
+type Pager struct {
+	// Animate makes pages slide in from the side when switching.
+	// This needs the client-side mirror, so setting Animate
+	// enables mirroring when the next page is shown.  For the old
+	// page to slide out correctly, mirroring needs to be enabled
+	// before it's drawn.
+	Animate bool
+
+	// RequireFling makes the Pager only switch pages on a fling
+	// (see BindFling), rather than on any swipe.  This avoids
+	// switching pages by accident while dragging things on the
+	// page.
+	RequireFling bool
+
+	// ShowIndicator draws a row of dots along the bottom edge of
+	// the main display, highlighting the active page.
+	ShowIndicator bool
+
+	loupedeck  *Loupedeck
+	pages      []*Page
+	active     int
+	current    *Page // usually pages[active], but may be the idle page
+	cancel     func()
+	idle       bool
+	idleCancel func()
+	baseColors map[Button]color.RGBA // button colors from before any page changed them
+}
+
+// NewPager creates a new Pager for the specified Pages, binds swipe
+// and fling gestures to it, and shows the first page (or the page from
+// the saved UIState, if there is one; see SetStateDir).
+func (l *Loupedeck) NewPager(pages []*Page) *Pager {
+	p := &Pager{
+		loupedeck:  l,
+		pages:      pages,
+		baseColors: map[Button]color.RGBA{},
+	}
+
+	l.BindSwipe(func(dir SwipeDirection) {
+		if !p.RequireFling {
+			p.swiped(dir)
+		}
+	})
+	l.BindFling(func(f Fling) {
+		if p.RequireFling {
+			p.swiped(f.Direction)
+		}
+	})
+
+	if len(pages) > 0 {
+		i := l.uiState.Page
+		if i < 0 || i >= len(pages) {
+			i = 0
+		}
+		p.show(i, SwipeLeft)
+	}
+	return p
+}
+
+// swiped switches pages in response to a horizontal gesture.
+func (p *Pager) swiped(dir SwipeDirection) {
+	switch dir {
+	case SwipeLeft:
+		p.Next()
+	case SwipeRight:
+		p.Prev()
+	}
+}
+
+// Active returns the index of the page that is currently shown.
+func (p *Pager) Active() int {
+	return p.active
+}
+
+// ActivePage returns the Page that is currently shown.  While the
+// idle page is shown (see SetIdlePage), that's the idle page.
+func (p *Pager) ActivePage() *Page {
+	return p.current
+}
+
+// Show shows the page with the specified index.
+func (p *Pager) Show(i int) {
+	if i < 0 || i >= len(p.pages) || len(p.pages) == 0 {
+		return
+	}
+	dir := SwipeLeft
+	if i < p.active {
+		dir = SwipeRight
+	}
+	p.show(i, dir)
+}
+
+// Next shows the next page, wrapping around after the last one.
+func (p *Pager) Next() {
+	if len(p.pages) == 0 {
+		return
+	}
+	p.show((p.active+1)%len(p.pages), SwipeLeft)
+}
+
+// Prev shows the previous page, wrapping around before the first one.
+func (p *Pager) Prev() {
+	if len(p.pages) == 0 {
+		return
+	}
+	p.show((p.active-1+len(p.pages))%len(p.pages), SwipeRight)
+}
+
+// show switches to page i.  dir is the direction that the pages
+// should slide, if animation is enabled.
+func (p *Pager) show(i int, dir SwipeDirection) {
+	p.active = i
+	p.switchTo(p.pages[i], dir, p.ShowIndicator)
+	p.loupedeck.updateState(func(s *UIState) { s.Page = i })
+
+	// Get the neighboring pages ready, since they're the likely
+	// next ones.
+	n := len(p.pages)
+	p.loupedeck.Warmup(p.pages[(i+1)%n])
+	p.loupedeck.Warmup(p.pages[(i+n-1)%n])
+}
+
+// switchTo replaces the current page with another one.
+func (p *Pager) switchTo(page *Page, dir SwipeDirection, indicator bool) {
+	l := p.loupedeck
+	d := l.GetDisplay("main")
+	if d == nil {
+		return
+	}
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+
+	old := p.current
+	if old == nil {
+		old = page
+	} else if old.Deactivate != nil {
+		old.Deactivate(l)
+	}
+	p.applyButtonColors(old, page)
+
+	for _, b := range l.TouchButtons() {
+		delete(l.touchBindings, b)
+		delete(l.touchUpBindings, b)
+	}
+
+	if p.Animate && !l.mirroring.Load() {
+		l.SetMirroring(true)
+	}
+	// Don't animate if drawing is already being held, for example
+	// by Boot; nobody would see it.
+	animate := p.Animate && page != old && !l.holdDraws.Load()
+
+	var before image.Image
+	if animate {
+		before = l.Snapshot(d)
+		// Build the new page in the mirror only; it'll be sent
+		// to the Loupedeck one frame at a time below.
+		l.holdDraws.Store(true)
+	}
+
+	p.current = page
+	d.Draw(blankImage(d.Width(), d.Height()), 0, 0)
+	l.drawPageImages(page)
+	if page.Activate != nil {
+		page.Activate(l)
+	}
+	if indicator {
+		p.drawIndicator(d)
+	}
+
+	if animate {
+		l.holdDraws.Store(false)
+		p.slide(d, before, l.Snapshot(d), dir)
+	}
+}
+
+// hide deactivates the current page, removes its touch bindings, and
+// puts back the button colors that it changed, leaving the Pager
+// without a current page.  The display is left as it is, for whatever
+// is shown next.
+func (p *Pager) hide() {
+	l := p.loupedeck
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	old := p.current
+	if old == nil {
+		return
+	}
+	if old.Deactivate != nil {
+		old.Deactivate(l)
+	}
+	p.applyButtonColors(old, &Page{})
+	p.baseColors = map[Button]color.RGBA{}
+	for _, b := range l.TouchButtons() {
+		delete(l.touchBindings, b)
+		delete(l.touchUpBindings, b)
+	}
+	p.current = nil
+}
+
+// applyButtonColors sets the hardware button colors for page to, and
+// restores the original colors of any buttons that were changed by
+// page from but aren't set by page to.
+func (p *Pager) applyButtonColors(from, to *Page) {
+	l := p.loupedeck
+	for b := range from.ButtonColors {
+		if _, ok := to.ButtonColors[b]; !ok && from != to {
+			_ = l.SetButtonColor(b, p.baseColors[b])
+		}
+	}
+	for b, c := range to.ButtonColors {
+		if _, ok := p.baseColors[b]; !ok {
+			p.baseColors[b], _ = l.GetButtonColor(b)
+		}
+		_ = l.SetButtonColor(b, c)
+	}
+}
+
+// slide animates the transition from one page image to another.
+func (p *Pager) slide(d *Display, before, after image.Image, dir SwipeDirection) {
+	w, h := d.Width(), d.Height()
+	step := 0
+
+	p.cancel = p.loupedeck.FrameClock().Subscribe(func(uint64) bool {
+		step++
+		if step >= pageSlideFrames {
+			p.cancel()
+			p.cancel = nil
+			d.Draw(after, 0, 0)
+			return true
+		}
+
+		offset := w * step / pageSlideFrames
+		im := image.NewRGBA(image.Rect(0, 0, w, h))
+		if dir == SwipeLeft {
+			draw.Draw(im, im.Bounds(), before, image.Pt(offset, 0), draw.Src)
+			draw.Draw(im, image.Rect(w-offset, 0, w, h), after, image.Point{}, draw.Src)
+		} else {
+			draw.Draw(im, image.Rect(offset, 0, w, h), before, image.Point{}, draw.Src)
+			draw.Draw(im, image.Rect(0, 0, offset, h), after, image.Pt(w-offset, 0), draw.Src)
+		}
+		d.Draw(im, 0, 0)
+		return true
+	})
+}
+
+// drawIndicator draws one dot per page along the bottom edge of the
+// main display, with the active page's dot highlighted.
+func (p *Pager) drawIndicator(d *Display) {
+	const height = 8
+	w := d.Width()
+	theme := p.loupedeck.Theme()
+	im := image.NewRGBA(image.Rect(0, 0, w, height))
+	draw.Draw(im, im.Bounds(), &image.Uniform{theme.Background}, image.Point{}, draw.Src)
+
+	spacing := 16
+	left := (w - spacing*(len(p.pages)-1)) / 2
+	for i := range p.pages {
+		pen := graphics2d.NewPen(theme.Inactive, theme.LineWidth(4))
+		if i == p.active {
+			pen = graphics2d.NewPen(theme.Active, theme.LineWidth(6))
+		}
+		graphics2d.DrawPoint(im, []float64{float64(left + i*spacing), height / 2}, pen)
+	}
+	d.Draw(im, 0, d.Height()-height)
+}
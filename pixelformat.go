@@ -0,0 +1,81 @@
+package loupedeck
+
+// PixelFormat describes how a Display expects pixel data to be
+// encoded.
+type PixelFormat int
+
+const (
+	// RGB565LE is 16-bit RGB565, least significant byte first.
+	// Most Loupedeck displays use this.
+	RGB565LE PixelFormat = iota
+	// RGB565BE is 16-bit RGB565, most significant byte first.
+	// The Loupedeck CT's dial display uses this.
+	RGB565BE
+)
+
+// String returns the name of the PixelFormat.
+func (f PixelFormat) String() string {
+	switch f {
+	case RGB565LE:
+		return "RGB565LE"
+	case RGB565BE:
+		return "RGB565BE"
+	}
+	return "unknown"
+}
+
+// PixelFormat returns the pixel format used when drawing on the
+// Display.
+func (d *Display) PixelFormat() PixelFormat {
+	return d.format
+}
+
+// SetPixelFormat overrides the pixel format used when drawing on the
+// Display.
+func (d *Display) SetPixelFormat(f PixelFormat) {
+	d.format = f
+}
+
+// ProbePixelFormat works out a Display's pixel format with help from
+// the user.  For each known format, it fills the display with pure
+// red encoded in that format and calls looksRed; the wrong byte order
+// shows up as a dark blue instead.  The first format for which
+// looksRed returns true is set on the Display and returned.  If none
+// match, the Display is left unchanged and its current format is
+// returned.
+//
+// looksRed will usually need to wait for the user to answer, via a
+// button press or similar.  Since button events are delivered by
+// Listen, ProbePixelFormat must not be called from the Listen
+// goroutine.
+func (l *Loupedeck) ProbePixelFormat(d *Display, looksRed func(PixelFormat) bool) PixelFormat {
+	for _, f := range []PixelFormat{RGB565LE, RGB565BE} {
+		d.fillRaw(0xf800, f)
+		if looksRed(f) {
+			d.format = f
+			return f
+		}
+	}
+	return d.format
+}
+
+// fillRaw fills the whole display with a single RGB565 value, encoded
+// using the specified format.
+func (d *Display) fillRaw(pixel uint16, f PixelFormat) {
+	data := d.framebufferHeader(d.offsetx, d.offsety, d.width, d.height)
+
+	lowByte := byte(pixel & 0xff)
+	highByte := byte(pixel >> 8)
+	for i := 0; i < d.width*d.height; i++ {
+		if f == RGB565BE {
+			data = append(data, highByte, lowByte)
+		} else {
+			data = append(data, lowByte, highByte)
+		}
+	}
+
+	// This is a probe, so it has to be sent even if it doesn't
+	// look like it would change anything.
+	d.loupedeck.forgetShown(d.id)
+	_ = d.writeFramebuffer(data, nil)
+}
@@ -0,0 +1,245 @@
+package loupedeck
+
+import (
+	"strings"
+)
+
+// DisplayProfile describes a single display on a Loupedeck device.
+// See Display for what the fields mean.
+type DisplayProfile struct {
+	Name             string
+	ID               byte
+	Width, Height    int
+	OffsetX, OffsetY int
+	Format           PixelFormat
+}
+
+// TouchGrid describes the layout of the touchscreen: an optional strip
+// on each side (next to the knobs), with a grid of square touch
+// buttons in between.  Devices without strips may have a dead margin
+// on each side of the grid instead.  All sizes are in pixels, in
+// touchscreen coordinates.
+type TouchGrid struct {
+	StripWidth    int
+	Margin        int
+	Columns, Rows int
+	CellSize      int
+}
+
+// DeviceProfile describes the hardware layout of a specific Loupedeck
+// product: its displays, its touchscreen, and any differences in the
+// IDs it uses for buttons and knobs.
+type DeviceProfile struct {
+	Name     string
+	Displays []DisplayProfile
+	Touch    TouchGrid
+
+	// ButtonMap translates the button IDs reported by the device
+	// into Buttons, for devices that don't use the same IDs as the
+	// Loupedeck Live.  IDs that aren't in the map are passed
+	// through unchanged.
+	ButtonMap map[Button]Button
+
+	// KnobMap does the same as ButtonMap, but for knobs.
+	KnobMap map[Knob]Knob
+
+	// Knobs and Buttons list the controls that the device has,
+	// after mapping.  Knob presses are included in Buttons.
+	Knobs   []Knob
+	Buttons []Button
+
+	// Vibration is true if the device has a vibration motor.
+	Vibration bool
+}
+
+// liveKnobs and liveButtons are the controls on the Loupedeck Live.
+var (
+	liveKnobs   = []Knob{Knob1, Knob2, Knob3, Knob4, Knob5, Knob6}
+	liveButtons = []Button{
+		KnobPress1, KnobPress2, KnobPress3, KnobPress4, KnobPress5, KnobPress6,
+		Circle, Button1, Button2, Button3, Button4, Button5, Button6, Button7,
+	}
+)
+
+// ctKnobs and ctButtons are the controls on the Loupedeck CT.
+var (
+	ctKnobs   = append([]Knob{CTKnob}, liveKnobs...)
+	ctButtons = append(append([]Button{}, liveButtons...),
+		CTCircle, Undo, Keyboard, Enter, Save, LeftFn, Up, Left, RightFn, Down, Right, E)
+)
+
+// deviceProfiles holds the known device profiles, keyed by USB
+// product ID.
+var deviceProfiles = map[string]*DeviceProfile{
+	"0003": {
+		Name: "Loupedeck CT v1",
+		Displays: []DisplayProfile{
+			{"left", 'L', 60, 270, 0, 0, RGB565LE},
+			{"main", 'A', 360, 270, 60, 0, RGB565LE},
+			{"right", 'R', 60, 270, 420, 0, RGB565LE},
+			{"dial", 'W', 240, 240, 0, 0, RGB565BE},
+		},
+		Touch:     liveTouchGrid,
+		Knobs:     ctKnobs,
+		Buttons:   ctButtons,
+		Vibration: true,
+	},
+	"0007": {
+		Name: "Loupedeck CT v2",
+		Displays: []DisplayProfile{
+			{"left", 'M', 60, 270, 0, 0, RGB565LE},
+			{"main", 'M', 360, 270, 60, 0, RGB565LE},
+			{"right", 'M', 60, 270, 420, 0, RGB565LE},
+			{"all", 'M', 480, 270, 0, 0, RGB565LE}, // Same as left+main+right
+			{"dial", 'W', 240, 240, 0, 0, RGB565BE},
+		},
+		Touch:     liveTouchGrid,
+		Knobs:     ctKnobs,
+		Buttons:   ctButtons,
+		Vibration: true,
+	},
+	"0004": {
+		Name: "Loupedeck Live",
+		Displays: []DisplayProfile{
+			{"left", 'L', 60, 270, 0, 0, RGB565LE},
+			{"main", 'A', 360, 270, 0, 0, RGB565LE},
+			{"right", 'R', 60, 270, 0, 0, RGB565LE},
+		},
+		Touch:     liveTouchGrid,
+		Knobs:     liveKnobs,
+		Buttons:   liveButtons,
+		Vibration: true,
+	},
+	"0006": {
+		Name: "Loupedeck Live S",
+		// The Live S has no knob strips; its 5x3 grid of touch
+		// buttons fills the display, apart from a narrow margin
+		// on each side.  Its two knobs report as Knob1 and
+		// Knob2, and its four buttons as Circle and
+		// Button1-Button3.
+		Displays: []DisplayProfile{
+			{"main", 'M', 450, 270, 15, 0, RGB565LE},
+			{"all", 'M', 480, 270, 0, 0, RGB565LE},
+		},
+		Touch:     liveSTouchGrid,
+		Knobs:     []Knob{Knob1, Knob2},
+		Buttons:   []Button{KnobPress1, KnobPress2, Circle, Button1, Button2, Button3},
+		Vibration: true,
+	},
+	// The Razer Stream Controller is a Loupedeck Live in a
+	// different case, but with the newer unified display.  Its
+	// controls are laid out like the Live's; if your unit reports
+	// different button or knob IDs, fix them with
+	// PatchDeviceProfile and please send a patch.
+	"0d06": {
+		Name: "Razer Stream Controller",
+		Displays: []DisplayProfile{
+			{"left", 'M', 60, 270, 0, 0, RGB565LE},
+			{"main", 'M', 360, 270, 60, 0, RGB565LE},
+			{"right", 'M', 60, 270, 420, 0, RGB565LE},
+			{"all", 'M', 480, 270, 0, 0, RGB565LE}, // Same as left+main+right
+		},
+		Touch:     liveTouchGrid,
+		Knobs:     liveKnobs,
+		Buttons:   liveButtons,
+		Vibration: true,
+	},
+	// The Razer Stream Controller X has no knobs or side strips,
+	// just a 5x3 grid of 96x96 keys over a single 480x288
+	// display.
+	"0d09": {
+		Name: "Razer Stream Controller X",
+		Displays: []DisplayProfile{
+			{"main", 'M', 480, 288, 0, 0, RGB565LE},
+			{"all", 'M', 480, 288, 0, 0, RGB565LE},
+		},
+		Touch: TouchGrid{Columns: 5, Rows: 3, CellSize: 96},
+	},
+}
+
+// PatchDeviceProfile applies a correction to the built-in profile for
+// a USB product ID, for example to fix a button ID that differs on
+// your hardware:
+//
+//	loupedeck.PatchDeviceProfile("0d06", func(p *loupedeck.DeviceProfile) {
+//		p.ButtonMap[loupedeck.Button(0x10)] = loupedeck.Circle
+//	})
+//
+// Corrections need to be applied before SetDisplays is called.  It
+// returns false if there is no profile for the product.
+func PatchDeviceProfile(product string, fix func(*DeviceProfile)) bool {
+	p := deviceProfiles[product]
+	if p == nil {
+		return false
+	}
+	if p.ButtonMap == nil {
+		p.ButtonMap = map[Button]Button{}
+	}
+	if p.KnobMap == nil {
+		p.KnobMap = map[Knob]Knob{}
+	}
+	fix(p)
+	return true
+}
+
+// RegisterDeviceProfile adds a profile for a USB product ID that this
+// library doesn't know about, or replaces the built-in one.  This lets
+// new or unusual hardware be described (displays, pixel formats,
+// touch grid, and button and knob maps) without forking the library:
+//
+//	loupedeck.RegisterDeviceProfile("0d0a", loupedeck.DeviceProfile{
+//		Name: "Some New Controller",
+//		Displays: []loupedeck.DisplayProfile{
+//			{"main", 'M', 480, 270, 0, 0, loupedeck.RGB565LE},
+//		},
+//		Touch: loupedeck.TouchGrid{Columns: 5, Rows: 3, CellSize: 90},
+//	})
+//
+// Profiles need to be registered before SetDisplays is called.
+func RegisterDeviceProfile(pid string, profile DeviceProfile) {
+	p := profile
+	if p.ButtonMap == nil {
+		p.ButtonMap = map[Button]Button{}
+	}
+	if p.KnobMap == nil {
+		p.KnobMap = map[Knob]Knob{}
+	}
+	deviceProfiles[strings.ToLower(pid)] = &p
+}
+
+// Profile returns the DeviceProfile in use for the connected device,
+// or nil if SetDisplays hasn't been called yet.
+func (l *Loupedeck) Profile() *DeviceProfile {
+	return l.profile
+}
+
+// applyProfile configures the Loupedeck's displays from a profile.
+func (l *Loupedeck) applyProfile(p *DeviceProfile) {
+	l.log().Info("Using device profile", "name", p.Name, "product", l.Product)
+	l.profile = p
+	for _, d := range p.Displays {
+		l.addDisplay(d.Name, d.ID, d.Width, d.Height, d.OffsetX, d.OffsetY, d.Format)
+	}
+}
+
+// mapButton translates a button ID from the device into a Button,
+// using the device profile.
+func (l *Loupedeck) mapButton(b Button) Button {
+	if l.profile != nil {
+		if m, ok := l.profile.ButtonMap[b]; ok {
+			return m
+		}
+	}
+	return b
+}
+
+// mapKnob translates a knob ID from the device into a Knob, using the
+// device profile.
+func (l *Loupedeck) mapKnob(k Knob) Knob {
+	if l.profile != nil {
+		if m, ok := l.profile.KnobMap[k]; ok {
+			return m
+		}
+	}
+	return k
+}
@@ -0,0 +1,58 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// WriteRaw writes pre-encoded RGB565 pixel data to a w x h area of
+// the Display with its upper left corner at x,y.  This skips the
+// image.Image conversion done by Draw, which is useful for sources
+// that already produce RGB565, like some video decoders.
+//
+// rgb565 must hold exactly w*h pixels, two bytes each, in row order
+// and in the Display's PixelFormat; no byte swapping is done.
+func (d *Display) WriteRaw(x, y, w, h int, rgb565 []byte) error {
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("invalid size %dx%d", w, h)
+	}
+	if len(rgb565) != 2*w*h {
+		return fmt.Errorf("got %d bytes of pixel data for a %dx%d area, want %d", len(rgb565), w, h, 2*w*h)
+	}
+
+	fx := x + d.offsetx
+	fy := y + d.offsety
+	d.loupedeck.drawMutex.Lock()
+	defer d.loupedeck.drawMutex.Unlock()
+	if d.loupedeck.mirroring.Load() {
+		d.updateMirror(d.decodeRaw(w, h, rgb565), fx, fy)
+	}
+	d.loupedeck.cache.invalidateRect(d.id, image.Rect(fx, fy, fx+w, fy+h))
+	if d.loupedeck.holdDraws.Load() {
+		return nil
+	}
+
+	data := d.framebufferHeader(fx, fy, w, h)
+	data = append(data, rgb565...)
+	return d.writeFramebufferLocked(data, nil)
+}
+
+// decodeRaw converts RGB565 data in the Display's PixelFormat into an
+// image, for the client-side mirror.
+func (d *Display) decodeRaw(w, h int, rgb565 []byte) image.Image {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		var p uint16
+		if d.format == RGB565BE {
+			p = uint16(rgb565[2*i])<<8 | uint16(rgb565[2*i+1])
+		} else {
+			p = uint16(rgb565[2*i+1])<<8 | uint16(rgb565[2*i])
+		}
+		r := byte(p>>11) << 3
+		g := byte(p>>5&0x3f) << 2
+		b := byte(p&0x1f) << 3
+		im.SetRGBA(i%w, i/w, color.RGBA{r | r>>5, g | g>>6, b | b>>5, 255})
+	}
+	return im
+}
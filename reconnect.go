@@ -0,0 +1,286 @@
+package loupedeck
+
+import (
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// reconnectDialTimeout is how long a reconnection attempt waits for
+// the websocket handshake.  See tryConnect for why this is needed.
+const reconnectDialTimeout = 2 * time.Second
+
+// ConnectionState describes a change in the state of the connection
+// to the Loupedeck.  See SetAutoReconnect.
+type ConnectionState int
+
+const (
+	// ConnectionLost means that reading from the Loupedeck
+	// failed, usually because it was unplugged.
+	ConnectionLost ConnectionState = iota
+	// ConnectionRestored means that the Loupedeck has been
+	// reconnected and restored, as configured by
+	// SetRedrawStrategy.
+	ConnectionRestored
+	// ConnectionClosed means that the connection was lost and
+	// won't be retried, because Close was called.
+	ConnectionClosed
+)
+
+// String returns a human-readable name for the ConnectionState.
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionLost:
+		return "lost"
+	case ConnectionRestored:
+		return "restored"
+	case ConnectionClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("ConnectionState(%d)", int(s))
+}
+
+// ConnectionFunc is a function signature used for callbacks on
+// connection state changes.
+type ConnectionFunc func(ConnectionState)
+
+// RedrawStrategy says how the displays are restored after the
+// Loupedeck is reconnected.  See SetRedrawStrategy.
+type RedrawStrategy int
+
+const (
+	// RedrawReplay resends the client-side mirror of each display
+	// (see SetMirroring).  This is the default.  It needs no help
+	// from the application, but sending every pixel of every
+	// display can take seconds on slow links.
+	RedrawReplay RedrawStrategy = iota
+	// RedrawCallback calls the function passed to
+	// SetRedrawStrategy, which should redraw everything itself.
+	// This is usually faster than replaying the mirror, since
+	// applications can skip blank areas.
+	RedrawCallback
+	// RedrawReset leaves the Loupedeck as it is after being
+	// reset, with blank displays and unlit buttons.
+	RedrawReset
+)
+
+// String returns a human-readable name for the RedrawStrategy.
+func (s RedrawStrategy) String() string {
+	switch s {
+	case RedrawReplay:
+		return "replay"
+	case RedrawCallback:
+		return "callback"
+	case RedrawReset:
+		return "reset"
+	}
+	return fmt.Sprintf("RedrawStrategy(%d)", int(s))
+}
+
+// SetRedrawStrategy sets how the Loupedeck is restored after being
+// reconnected.  With RedrawCallback, redraw is called from the Listen
+// goroutine once the Loupedeck has been reset and its brightness and
+// button colors restored; it's ignored for the other strategies.
+func (l *Loupedeck) SetRedrawStrategy(s RedrawStrategy, redraw func()) {
+	l.redrawStrategy = s
+	l.redraw = redraw
+}
+
+// SetAutoReconnect makes Listen survive the Loupedeck being unplugged.
+// Instead of returning when a read fails, Listen looks for the device
+// every retry, first at the same serial device path and then at any
+// other path with the same USB IDs.  Once it's found, Listen
+// reconnects, resets it, restores the brightness and button colors,
+// redraws the displays as configured by SetRedrawStrategy, and
+// carries on.  Bindings are kept.
+//
+// A retry of 0 turns reconnection off, which is the default.  This
+// only works for Loupedecks connected via the serial port.
+func (l *Loupedeck) SetAutoReconnect(retry time.Duration) {
+	l.reconnectRetry = retry
+}
+
+// OnConnectionState adds a callback for changes in the state of the
+// connection, such as the Loupedeck being unplugged and plugged back
+// in.  Callbacks are called from the Listen goroutine.
+func (l *Loupedeck) OnConnectionState(f ConnectionFunc) {
+	l.connectionBindings = append(l.connectionBindings, f)
+}
+
+// sendConnectionState calls the connection state callbacks.
+func (l *Loupedeck) sendConnectionState(s ConnectionState) {
+	l.log().Info("Loupedeck connection state changed", "state", s)
+	for _, f := range l.connectionBindings {
+		f(s)
+	}
+}
+
+// reconnect waits for the Loupedeck to come back after a read error
+// and reconnects to it.  It returns false if reconnection is turned
+// off or if Close is called while waiting.
+func (l *Loupedeck) reconnect() bool {
+	conn, old := l.connection()
+	if l.reconnectRetry <= 0 || old == nil || l.closed.Load() {
+		return false
+	}
+	l.sendConnectionState(ConnectionLost)
+
+	// Release the old port and its lock, so that it can be opened
+	// again.
+	conn.Close()
+	old.Close()
+
+	for {
+		time.Sleep(l.reconnectRetry)
+		if l.closed.Load() {
+			l.sendConnectionState(ConnectionClosed)
+			return false
+		}
+
+		c, err := l.findSerial(old.Name)
+		if err != nil {
+			l.log().Debug("Loupedeck not found yet", "err", err)
+			continue
+		}
+		if err := l.redial(c); err != nil {
+			l.log().Info("Reconnection failed, retrying", "err", err)
+			c.Close()
+			continue
+		}
+		break
+	}
+
+	l.updateStats(func(s *Stats) { s.Reconnects++ })
+	l.sendConnectionState(ConnectionRestored)
+	return true
+}
+
+// findSerial looks for the serial port of a Loupedeck that we lost
+// the connection to, preferring the path that it used to have.
+func (l *Loupedeck) findSerial(path string) (*SerialWebSockConn, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *enumerator.PortDetails
+	for _, port := range ports {
+		if !isLoupedeckPort(port) || port.VID != l.Vendor || port.PID != l.Product {
+			continue
+		}
+		if port.Name == path {
+			match = port
+			break
+		}
+		if match == nil {
+			match = port
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no %s found", l.Model)
+	}
+
+	lock, err := lockDevice(match.Name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := serial.Open(match.Name, &serial.Mode{})
+	if err != nil {
+		lock.unlock()
+		return nil, fmt.Errorf("unable to open port %q: %v", match.Name, err)
+	}
+	return &SerialWebSockConn{
+		Name:    match.Name,
+		Port:    p,
+		Vendor:  match.VID,
+		Product: match.PID,
+		lock:    lock,
+	}, nil
+}
+
+// redial opens a new websocket connection over c, switches the
+// Loupedeck over to it, and restores the device's state.
+func (l *Loupedeck) redial(c *SerialWebSockConn) error {
+	if err := c.ResetLines(); err != nil {
+		l.log().Warn("Unable to reset serial port", "err", err)
+	}
+
+	conn, _ := l.connection()
+	if _, ok := conn.(*nativeConn); ok {
+		l.setConnection(newNativeConn(c), c)
+		return l.restore()
+	}
+
+	type result struct {
+		conn messageConn
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := dialWebsocket(c, l.log())
+		ch <- result{conn, err}
+	}()
+
+	var r result
+	select {
+	case r = <-ch:
+	case <-time.After(reconnectDialTimeout):
+		return fmt.Errorf("timeout waiting for websocket handshake")
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	l.setConnection(r.conn, c)
+	return l.restore()
+}
+
+// connection returns the current connection to the Loupedeck, and the
+// serial port that it runs over (which is nil for HID and network
+// devices).  Both are replaced when reconnecting, while other
+// goroutines (like the send queue's writer) are using them.
+func (l *Loupedeck) connection() (messageConn, *SerialWebSockConn) {
+	l.connMutex.Lock()
+	defer l.connMutex.Unlock()
+	return l.conn, l.serial
+}
+
+// setConnection switches to a new connection after reconnecting.
+func (l *Loupedeck) setConnection(conn messageConn, serial *SerialWebSockConn) {
+	l.connMutex.Lock()
+	defer l.connMutex.Unlock()
+	l.conn = conn
+	l.serial = serial
+}
+
+// restore resets a reconnected Loupedeck and puts back its
+// brightness, button colors, and displays, as configured by
+// SetRedrawStrategy.
+func (l *Loupedeck) restore() error {
+	l.lastReceive.Store(time.Now().UnixNano())
+
+	if err := l.Send(l.NewMessage(Reset, []byte{})); err != nil {
+		return fmt.Errorf("unable to send reset: %v", err)
+	}
+
+	if l.redrawStrategy == RedrawReset {
+		// Forget what used to be shown, so that it matches the
+		// freshly reset Loupedeck.
+		return l.forgetDeviceState()
+	}
+
+	if err := l.restoreLEDs(); err != nil {
+		return err
+	}
+	switch l.redrawStrategy {
+	case RedrawReplay:
+		l.replayMirrors()
+	case RedrawCallback:
+		if l.redraw != nil {
+			l.redraw()
+		}
+	}
+	return nil
+}
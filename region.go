@@ -0,0 +1,140 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Region is a named rectangular sub-area of a Display.  Regions hide
+// the offset math needed to address the side strips and the 90x90
+// touch button cells, which is especially handy on devices with a
+// single unified ('M') display, where the legacy left/main/right
+// screens are emulated with offsets.
+type Region struct {
+	Name          string
+	display       *Display
+	x, y          int
+	width, height int
+}
+
+// Display returns the Display that the Region is part of.
+func (r *Region) Display() *Display {
+	return r.display
+}
+
+// Width returns the width (in pixels) of the Region.
+func (r *Region) Width() int {
+	return r.width
+}
+
+// Height returns the height (in pixels) of the Region.
+func (r *Region) Height() int {
+	return r.height
+}
+
+// Draw draws an image onto the Region.  The upper left corner of the
+// image is placed at the upper left corner of the Region; images
+// larger than the Region are cropped so that they never spill into
+// neighboring cells.
+func (r *Region) Draw(im image.Image) {
+	b := im.Bounds()
+	if b.Dx() > r.width || b.Dy() > r.height {
+		cropped := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+		draw.Draw(cropped, cropped.Bounds(), im, b.Min, draw.Src)
+		im = cropped
+	}
+	r.display.Draw(im, r.x, r.y)
+}
+
+// Fill fills the whole Region with a single color.
+func (r *Region) Fill(c color.Color) {
+	im := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	draw.Draw(im, im.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	r.display.Draw(im, r.x, r.y)
+}
+
+// GetRegion returns the Region with a given name if it exists,
+// otherwise it returns nil.  Regions are created by SetDisplays, and
+// are named:
+//
+//   - left (the left strip, next to knobs 1-3)
+//   - right (the right strip, next to knobs 4-6)
+//   - cell1 through cell12 (the touch buttons on the main display, 90x90
+//     on most devices; cell15 on devices with 5x3 grids)
+//   - knob1 through knob6 (the part of the left or right strip next to each knob)
+func (l *Loupedeck) GetRegion(name string) *Region {
+	return l.regions[name]
+}
+
+// CellRegion returns the Region that covers a specific touch
+// button, or nil for TouchButtons that aren't part of the main grid.
+func (l *Loupedeck) CellRegion(b TouchButton) *Region {
+	switch b {
+	case TouchLeft:
+		return l.regions["left"]
+	case TouchRight:
+		return l.regions["right"]
+	}
+	return l.regions[fmt.Sprintf("cell%d", int(b)-int(Touch1)+1)]
+}
+
+// KnobRegion returns the Region of the left or right strip that sits
+// next to a specific knob, or nil if there isn't one.
+func (l *Loupedeck) KnobRegion(k Knob) *Region {
+	return l.regions[fmt.Sprintf("knob%d", k)]
+}
+
+// addRegions creates the standard strip and cell regions for
+// whichever displays are currently configured.
+func (l *Loupedeck) addRegions() {
+	l.regions = map[string]*Region{}
+
+	for _, name := range []string{"left", "right"} {
+		if d := l.displays[name]; d != nil {
+			l.regions[name] = &Region{
+				Name:    name,
+				display: d,
+				width:   d.width,
+				height:  d.height,
+			}
+		}
+	}
+
+	for k := Knob1; k <= Knob6; k++ {
+		strip := l.displays["left"]
+		if k >= Knob4 {
+			strip = l.displays["right"]
+		}
+		if strip == nil {
+			continue
+		}
+		name := fmt.Sprintf("knob%d", k)
+		l.regions[name] = &Region{
+			Name:    name,
+			display: strip,
+			y:       ((k - 1) % 3) * 90,
+			width:   strip.width,
+			height:  90,
+		}
+	}
+
+	main := l.displays["main"]
+	if main == nil {
+		return
+	}
+	g := l.touchGrid()
+	for _, b := range l.TouchButtons() {
+		x, y := l.cellXY(b)
+		name := fmt.Sprintf("cell%d", int(b)-int(Touch1)+1)
+		l.regions[name] = &Region{
+			Name:    name,
+			display: main,
+			x:       x,
+			y:       y,
+			width:   g.CellSize,
+			height:  g.CellSize,
+		}
+	}
+}
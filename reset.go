@@ -0,0 +1,36 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+)
+
+// Reset resets the Loupedeck, clearing its displays and turning off
+// its button lights, just like when it's first connected.  This is
+// useful when an application throws away its whole layout, for
+// example after reloading a configuration file.  Bindings are left
+// alone, but the client-side mirror and remembered button colors are
+// cleared to match the freshly reset device, and the brightness is
+// set again.
+func (l *Loupedeck) Reset() error {
+	if l.hid {
+		return errors.New("the Loupedeck+ can't be reset")
+	}
+	if err := l.Send(l.NewMessage(Reset, []byte{})); err != nil {
+		return fmt.Errorf("unable to send reset: %v", err)
+	}
+	return l.forgetDeviceState()
+}
+
+// forgetDeviceState forgets what used to be shown on the Loupedeck
+// after it's been reset, and sets its brightness again.
+func (l *Loupedeck) forgetDeviceState() error {
+	l.ledMutex.Lock()
+	l.buttonColors = map[Button]color.RGBA{}
+	l.ledMutex.Unlock()
+	l.mirrorMutex.Lock()
+	l.mirrors = nil
+	l.mirrorMutex.Unlock()
+	return l.SetBrightness(l.brightness)
+}
@@ -0,0 +1,55 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+
+	"maze.io/x/pixel/pixelcolor"
+)
+
+// rgb565Red, rgb565Green, and rgb565Blue map 8-bit color channels to
+// their bits of an RGB565 pixel.  They're built from
+// pixelcolor.ToRGB565, so that the fast path in encodeRGBA produces
+// exactly the same pixels as the general one in encode.
+var rgb565Red, rgb565Green, rgb565Blue [256]uint16
+
+func init() {
+	for i := 0; i < 256; i++ {
+		v := uint8(i)
+		rgb565Red[i] = uint16(pixelcolor.ToRGB565(color.RGBA{R: v, A: 255}))
+		rgb565Green[i] = uint16(pixelcolor.ToRGB565(color.RGBA{G: v, A: 255}))
+		rgb565Blue[i] = uint16(pixelcolor.ToRGB565(color.RGBA{B: v, A: 255}))
+	}
+}
+
+// encodeRGBA appends the pixels of im to data, in the Display's
+// PixelFormat.  It's the same as the loop in encode, but reads the
+// image's Pix slice directly instead of calling At and converting
+// colors for every pixel, which makes it several times faster; most
+// images drawn are *image.RGBA.
+func (d *Display) encodeRGBA(data []byte, im *image.RGBA) []byte {
+	b := im.Bounds()
+	w, h := b.Dx(), b.Dy()
+	start := len(data)
+	data = append(data, make([]byte, 2*w*h)...)
+	out := data[start:]
+
+	bigEndian := d.format == RGB565BE
+	for y := 0; y < h; y++ {
+		row := im.Pix[im.PixOffset(b.Min.X, b.Min.Y+y):]
+		row = row[:4*w]
+		line := out[2*w*y : 2*w*(y+1)]
+		for x := 0; x < w; x++ {
+			p := row[4*x : 4*x+3]
+			pixel := rgb565Red[p[0]] | rgb565Green[p[1]] | rgb565Blue[p[2]]
+			if bigEndian {
+				line[2*x] = byte(pixel >> 8)
+				line[2*x+1] = byte(pixel)
+			} else {
+				line[2*x] = byte(pixel)
+				line[2*x+1] = byte(pixel >> 8)
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,55 @@
+package loupedeck
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// opaqueImage hides an image's concrete type, to force encode onto its
+// general path.
+type opaqueImage struct {
+	image.Image
+}
+
+// randomRGBA returns an image full of random pixels.
+func randomRGBA(w, h int) *image.RGBA {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	r.Read(im.Pix)
+	return im
+}
+
+func TestEncodeRGBAMatchesGeneral(t *testing.T) {
+	im := randomRGBA(100, 60)
+	sub := im.SubImage(image.Rect(10, 5, 70, 45)).(*image.RGBA)
+	for _, format := range []PixelFormat{RGB565LE, RGB565BE} {
+		d := &Display{id: 'M', width: 480, height: 270, format: format}
+		for _, src := range []*image.RGBA{im, sub} {
+			fast := d.encode(src, 3, 4, nil)
+			general := d.encode(opaqueImage{src}, 3, 4, nil)
+			if !bytes.Equal(fast, general) {
+				t.Errorf("format %v, bounds %v: fast path doesn't match general path", format, src.Bounds())
+			}
+		}
+	}
+}
+
+func BenchmarkEncodeRGBA(b *testing.B) {
+	d := &Display{id: 'M', width: 480, height: 270}
+	im := randomRGBA(480, 270)
+	b.SetBytes(int64(len(im.Pix)))
+	for i := 0; i < b.N; i++ {
+		d.encode(im, 0, 0, nil)
+	}
+}
+
+func BenchmarkEncodeGeneral(b *testing.B) {
+	d := &Display{id: 'M', width: 480, height: 270}
+	im := opaqueImage{randomRGBA(480, 270)}
+	b.SetBytes(int64(len(im.Image.(*image.RGBA).Pix)))
+	for i := 0; i < b.N; i++ {
+		d.encode(im, 0, 0, nil)
+	}
+}
@@ -0,0 +1,92 @@
+package loupedeck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// safeModeProfile is used for devices with unknown product IDs.  It's
+// a guess: most recent Loupedecks have a single 480x270 display with
+// ID 'M', and the same knobs and buttons as the Loupedeck Live.
+var safeModeProfile = &DeviceProfile{
+	Name: "Unknown Loupedeck (safe mode)",
+	Displays: []DisplayProfile{
+		{"main", 'M', 480, 270, 0, 0, RGB565LE},
+	},
+	Touch:   liveTouchGrid,
+	Knobs:   liveKnobs,
+	Buttons: liveButtons,
+}
+
+// RawMessageFunc is a function signature used for callbacks on every
+// message received from the Loupedeck.  See OnRawMessage.
+type RawMessageFunc func(*Message)
+
+// SafeMode returns true if the connected device's product ID isn't
+// known, so SetDisplays fell back to a generic profile.  In safe mode
+// there's a single "main" display covering the whole screen (which
+// may or may not be right), button and knob IDs are passed through
+// unmapped, and sniffing is turned on, so that everything sent and
+// received is logged.  Please report what works, or better yet send
+// a patch adding a proper profile; see RegisterDeviceProfile.
+func (l *Loupedeck) SafeMode() bool {
+	return l.safeMode
+}
+
+// enterSafeMode sets up a device that has no known profile.
+func (l *Loupedeck) enterSafeMode() {
+	l.log().Warn("Unknown Loupedeck product, using safe mode; please report what works", "product", l.Product, "version", l.Version, "serial", l.SerialNo)
+	l.safeMode = true
+	l.SetSniffing(true)
+	l.applyProfile(safeModeProfile)
+	l.addRegions()
+}
+
+// SetSniffing turns logging of every message sent to and received from
+// the Loupedeck on or off.  Messages are logged as hex (the first 32
+// bytes, along with the full length) at Warn level, so that they
+// stand out from the usual logging.  Outgoing drawing messages are
+// logged at Debug level instead, since there are so many of them.
+// This is mostly useful for working out how new hardware behaves.
+func (l *Loupedeck) SetSniffing(enabled bool) {
+	l.sniffing = enabled
+}
+
+// OnRawMessage adds a callback that's called with every message
+// received from the Loupedeck, including ones that the library
+// doesn't understand, before it's dispatched.  Callbacks are called
+// from the Listen goroutine.
+func (l *Loupedeck) OnRawMessage(f RawMessageFunc) {
+	l.rawBindings = append(l.rawBindings, f)
+}
+
+// sniffBytes is the number of bytes of each message logged by sniff.
+const sniffBytes = 32
+
+// sniff logs a message if sniffing is enabled.
+func (l *Loupedeck) sniff(direction string, b []byte) {
+	if !l.sniffing || len(b) < 2 {
+		return
+	}
+	level := slog.LevelWarn
+	if t := MessageType(b[1]); direction == "sent" && (t == WriteFramebuff || t == Draw) {
+		level = slog.LevelDebug
+	}
+	ctx := context.Background()
+	if !l.log().Enabled(ctx, level) {
+		return
+	}
+	dump := b
+	if len(dump) > sniffBytes {
+		dump = dump[:sniffBytes]
+	}
+	l.log().Log(ctx, level, "Sniffed", "direction", direction, "type", fmt.Sprintf("0x%02x", b[1]), "length", len(b), "bytes", fmt.Sprintf("% x", dump))
+}
+
+// sendRawMessage calls the raw message callbacks.
+func (l *Loupedeck) sendRawMessage(m *Message) {
+	for _, f := range l.rawBindings {
+		f(m)
+	}
+}
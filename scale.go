@@ -0,0 +1,76 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// FitMode says how DrawScaled fits an image into an area with a
+// different shape.
+type FitMode int
+
+const (
+	// FitContain scales the image to fit entirely inside the
+	// area, keeping its aspect ratio, and fills the rest of the
+	// area with the theme's background color (letterboxing).
+	FitContain FitMode = iota
+	// FitCover scales the image to cover the whole area, keeping
+	// its aspect ratio, and crops whatever sticks out evenly on
+	// both sides.
+	FitCover
+	// FitStretch scales the image to exactly the size of the area,
+	// distorting it if the aspect ratios differ.
+	FitStretch
+)
+
+// DrawScaled draws an image of any size over the whole Display,
+// scaled to fit according to mode.  This is handy for things like
+// album art or video thumbnails that come in whatever size they
+// come in.
+func (d *Display) DrawScaled(im image.Image, mode FitMode) {
+	bg := d.loupedeck.Theme().Background
+	d.Draw(scaleImage(im, d.Width(), d.Height(), mode, bg), 0, 0)
+}
+
+// DrawScaled draws an image of any size over the whole Region, scaled
+// to fit according to mode.  See Display.DrawScaled.
+func (r *Region) DrawScaled(im image.Image, mode FitMode) {
+	bg := r.display.loupedeck.Theme().Background
+	r.Draw(scaleImage(im, r.width, r.height, mode, bg))
+}
+
+// scaleImage returns im scaled to w x h according to mode, with bg
+// showing wherever the image doesn't reach.
+func scaleImage(im image.Image, w, h int, mode FitMode, bg color.Color) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	src := im.Bounds()
+	dst := out.Bounds()
+	if src.Empty() || dst.Empty() {
+		return out
+	}
+
+	switch mode {
+	case FitContain:
+		draw.Draw(out, dst, &image.Uniform{bg}, image.Point{}, draw.Src)
+		dst = fitRect(src, dst)
+	case FitCover:
+		draw.Draw(out, dst, &image.Uniform{bg}, image.Point{}, draw.Src)
+		src = coverRect(src, dst)
+	}
+	xdraw.CatmullRom.Scale(out, dst, im, src, draw.Over, nil)
+	return out
+}
+
+// coverRect returns the largest rectangle with the aspect ratio of
+// dst that fits inside src, centered in src.  Scaling that part of
+// src to dst covers dst completely, cropping src evenly.
+func coverRect(src, dst image.Rectangle) image.Rectangle {
+	r := fitRect(dst, src)
+	if r.Empty() {
+		return src
+	}
+	return r
+}
@@ -0,0 +1,38 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScaleImage(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	bg := color.RGBA{0, 0, 255, 255}
+	src := image.NewRGBA(image.Rect(0, 0, 1920, 1080))
+	for i := range src.Pix {
+		if i%4 == 0 || i%4 == 3 {
+			src.Pix[i] = 255
+		}
+	}
+
+	// A 16:9 image in a square leaves bars at the top and bottom
+	// when contained, and fills it when covered or stretched.
+	tests := []struct {
+		mode   FitMode
+		corner color.RGBA
+	}{
+		{FitContain, bg},
+		{FitCover, red},
+		{FitStretch, red},
+	}
+	for _, tc := range tests {
+		im := scaleImage(src, 90, 90, tc.mode, bg)
+		if got := im.RGBAAt(0, 0); got != tc.corner {
+			t.Errorf("mode %d: corner is %v, want %v", tc.mode, got, tc.corner)
+		}
+		if got := im.RGBAAt(45, 45); got != red {
+			t.Errorf("mode %d: center is %v, want %v", tc.mode, got, red)
+		}
+	}
+}
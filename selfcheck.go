@@ -0,0 +1,210 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// selfCheckTimeout is how long SelfCheck waits for each query
+	// to be answered.
+	selfCheckTimeout = time.Second
+
+	// selfCheckFlash is how long SelfCheck shows its display
+	// pattern and lights the buttons for.
+	selfCheckFlash = 300 * time.Millisecond
+
+	// selfCheckSize is the size of the square that SelfCheck draws
+	// on each display.
+	selfCheckSize = 20
+)
+
+// CheckResult is the result of a single step of SelfCheck.
+type CheckResult struct {
+	// Name says what was checked, like "version" or
+	// "display main".
+	Name string
+	// Detail holds what was learned, like the firmware version.
+	Detail string
+	// Err is nil if the check passed.
+	Err error
+}
+
+// SelfCheckReport is the result of SelfCheck.
+type SelfCheckReport struct {
+	Model   Model
+	Results []CheckResult
+}
+
+// OK returns true if every check passed.
+func (r *SelfCheckReport) OK() bool {
+	for _, c := range r.Results {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human-readable version of the report, one line
+// per check.
+func (r *SelfCheckReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "model: %s\n", r.Model)
+	for _, c := range r.Results {
+		status := "ok"
+		if c.Err != nil {
+			status = "FAILED: " + c.Err.Error()
+		}
+		fmt.Fprintf(&b, "%s: %s", c.Name, status)
+		if c.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", c.Detail)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// SelfCheck exercises the Loupedeck end to end, for verifying a unit
+// after installation.  It asks for the firmware version, serial
+// number, and MCU information, briefly draws a white square in the
+// top left corner of each display, and briefly lights each of the
+// colored buttons.  Displays and buttons are put back afterwards;
+// displays are restored from the client-side mirror if mirroring is
+// enabled (see SetMirroring), and cleared otherwise.
+//
+// Answers are delivered by Listen, so it needs to be running.
+// Whether anything actually appeared on the displays and buttons
+// can't be detected, so someone should watch.
+func (l *Loupedeck) SelfCheck() (*SelfCheckReport, error) {
+	if !l.listening.Load() {
+		return nil, errors.New("SelfCheck needs Listen to be running")
+	}
+
+	r := &SelfCheckReport{Model: l.Model}
+	r.Results = append(r.Results,
+		l.checkQuery("version", Version, func(data []byte) (string, error) {
+			if len(data) < 3 {
+				return "", fmt.Errorf("short response: %v", data)
+			}
+			return fmt.Sprintf("%d.%d.%d", data[0], data[1], data[2]), nil
+		}),
+		l.checkQuery("serial", Serial, func(data []byte) (string, error) {
+			if len(data) == 0 {
+				return "", errors.New("empty response")
+			}
+			return string(data), nil
+		}),
+		l.checkQuery("mcu", MCU, func(data []byte) (string, error) {
+			return fmt.Sprintf("% x", data), nil
+		}),
+	)
+
+	for _, d := range l.testPatternDisplays() {
+		r.Results = append(r.Results, l.checkDisplay(d))
+	}
+	r.Results = append(r.Results, l.checkButtons())
+	return r, nil
+}
+
+// SelfTest runs SelfCheck and returns an error describing every check
+// that failed, or nil if they all passed.  Use SelfCheck instead to
+// see what each check found.
+func (l *Loupedeck) SelfTest() error {
+	r, err := l.SelfCheck()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range r.Results {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkQuery sends a query to the Loupedeck and waits for the answer,
+// which is decoded by decode.
+func (l *Loupedeck) checkQuery(name string, t MessageType, decode func([]byte) (string, error)) CheckResult {
+	c := CheckResult{Name: name}
+	m, err := l.SendAndWait(l.NewMessage(t, []byte{}), selfCheckTimeout)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.Detail, c.Err = decode(m.data)
+	return c
+}
+
+// checkDisplay draws a white square on a display and then clears it
+// again.  White and black are the same in every PixelFormat, so this
+// works even if the display's format is wrong.
+func (l *Loupedeck) checkDisplay(d *Display) CheckResult {
+	c := CheckResult{
+		Name:   "display " + d.Name,
+		Detail: fmt.Sprintf("%dx%d", d.Width(), d.Height()),
+	}
+	white := make([]byte, 2*selfCheckSize*selfCheckSize)
+	for i := range white {
+		white[i] = 0xff
+	}
+	if err := d.WriteRaw(0, 0, selfCheckSize, selfCheckSize, white); err != nil {
+		c.Err = err
+		return c
+	}
+	time.Sleep(selfCheckFlash)
+
+	if l.mirroring.Load() {
+		l.replayMirrors()
+		return c
+	}
+	c.Err = d.WriteRaw(0, 0, selfCheckSize, selfCheckSize, make([]byte, len(white)))
+	return c
+}
+
+// checkButtons lights each of the colored buttons, and then puts
+// their colors back.
+func (l *Loupedeck) checkButtons() CheckResult {
+	c := CheckResult{Name: "buttons"}
+	var buttons []Button
+	for b := Circle; b <= Button7; b++ {
+		if l.profile == nil || slices.Contains(l.profile.Buttons, b) {
+			buttons = append(buttons, b)
+		}
+	}
+	if len(buttons) == 0 {
+		c.Detail = "no colored buttons"
+		return c
+	}
+
+	saved := l.ButtonColors()
+	for _, b := range buttons {
+		if err := l.SetButtonColor(b, color.RGBA{255, 255, 255, 255}); err != nil {
+			c.Err = err
+			return c
+		}
+	}
+	time.Sleep(selfCheckFlash)
+	for _, b := range buttons {
+		old, ok := saved[b]
+		if !ok {
+			old = colorBackground
+		}
+		if err := l.SetButtonColor(b, old); err != nil {
+			c.Err = err
+			return c
+		}
+		if !ok {
+			l.ledMutex.Lock()
+			delete(l.buttonColors, b)
+			l.ledMutex.Unlock()
+		}
+	}
+	c.Detail = fmt.Sprintf("%d buttons", len(buttons))
+	return c
+}
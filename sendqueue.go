@@ -0,0 +1,121 @@
+package loupedeck
+
+import (
+	"errors"
+	"sync"
+)
+
+// sendQueueSize is how many messages of each priority can be waiting
+// to be written before senders block.
+const sendQueueSize = 64
+
+// ErrClosed is returned when sending to a Loupedeck that has been
+// closed.
+var ErrClosed = errors.New("loupedeck connection closed")
+
+// sendRequest is a single message waiting to be written.
+type sendRequest struct {
+	data []byte
+	done chan error
+}
+
+// sendQueue feeds messages to a single writer goroutine, so that
+// messages sent from different goroutines are never interleaved on
+// the wire.  Messages are split into two priorities: drawing
+// (WriteFramebuff and Draw), which is bulky and can take a while, and
+// everything else, which is small and often answers user input, like
+// button colors and vibration.  Whenever both are waiting, the
+// everything-else queue goes first, so that drawing can't starve it.
+//
+// Each queue is bounded, so a goroutine that draws faster than the
+// Loupedeck can keep up blocks instead of building up an ever-growing
+// backlog.
+type sendQueue struct {
+	once    sync.Once
+	high    chan *sendRequest
+	low     chan *sendRequest
+	stop    chan struct{}
+	stopped chan struct{}
+	halt    sync.Once
+}
+
+// sendPriorityLow returns true for message types that go in the
+// low-priority queue.
+func sendPriorityLow(t MessageType) bool {
+	return t == WriteFramebuff || t == Draw
+}
+
+// startWriter starts the writer goroutine, if it isn't already
+// running.
+func (l *Loupedeck) startWriter() {
+	q := &l.sendQueue
+	q.once.Do(func() {
+		q.high = make(chan *sendRequest, sendQueueSize)
+		q.low = make(chan *sendRequest, sendQueueSize)
+		q.stop = make(chan struct{})
+		q.stopped = make(chan struct{})
+		go l.writer()
+	})
+}
+
+// stopWriter stops the writer goroutine.  Messages that haven't been
+// written yet fail with ErrClosed.
+func (l *Loupedeck) stopWriter() {
+	l.startWriter()
+	q := &l.sendQueue
+	q.halt.Do(func() { close(q.stop) })
+	<-q.stopped
+}
+
+// writer writes queued messages to the connection, one at a time.
+func (l *Loupedeck) writer() {
+	q := &l.sendQueue
+	defer close(q.stopped)
+	for {
+		// Drain the high-priority queue before looking at
+		// anything else.
+		var r *sendRequest
+		select {
+		case r = <-q.high:
+		default:
+			select {
+			case r = <-q.high:
+			case r = <-q.low:
+			case <-q.stop:
+				return
+			}
+		}
+		conn, _ := l.connection()
+		r.done <- conn.WriteMessage(binaryMessage, r.data)
+	}
+}
+
+// write queues a message for the writer goroutine and waits until
+// it's been written.
+func (l *Loupedeck) write(t MessageType, b []byte) error {
+	l.startWriter()
+	q := &l.sendQueue
+	queue := q.high
+	if sendPriorityLow(t) {
+		queue = q.low
+	}
+
+	r := &sendRequest{data: b, done: make(chan error, 1)}
+	select {
+	case queue <- r:
+	case <-q.stopped:
+		return ErrClosed
+	}
+	select {
+	case err := <-r.done:
+		return err
+	case <-q.stopped:
+		// The writer may have finished this one on its way out.
+		select {
+		case err := <-r.done:
+			return err
+		default:
+			return ErrClosed
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package loupedeck
+
+import (
+	"fmt"
+	"time"
+)
+
+// lineResetDelay is how long ResetLines holds DTR and RTS low.
+const lineResetDelay = 50 * time.Millisecond
+
+// ResetLines recovers a Loupedeck that's stuck after the previous
+// program using it exited without closing the connection cleanly.
+// In that state, the device often ignores the websocket handshake
+// until it's been retried a few times.
+//
+// ResetLines drops DTR and RTS, waits briefly, raises them again
+// (which the device sees as the port being closed and reopened), and
+// then throws away anything left in the input and output buffers, so
+// that the next handshake starts from a clean slate.  It's called
+// automatically before every handshake attempt; see WithoutPortReset.
+func (l *SerialWebSockConn) ResetLines() error {
+	if err := l.Port.SetDTR(false); err != nil {
+		return fmt.Errorf("unable to clear DTR: %v", err)
+	}
+	if err := l.Port.SetRTS(false); err != nil {
+		return fmt.Errorf("unable to clear RTS: %v", err)
+	}
+	time.Sleep(lineResetDelay)
+	if err := l.Port.SetDTR(true); err != nil {
+		return fmt.Errorf("unable to set DTR: %v", err)
+	}
+	if err := l.Port.SetRTS(true); err != nil {
+		return fmt.Errorf("unable to set RTS: %v", err)
+	}
+	if err := l.Port.ResetInputBuffer(); err != nil {
+		return fmt.Errorf("unable to flush input: %v", err)
+	}
+	if err := l.Port.ResetOutputBuffer(); err != nil {
+		return fmt.Errorf("unable to flush output: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package loupedeck
+
+import (
+	"image"
+	"image/draw"
+)
+
+// SetSplash sets the image shown by ShowSplash.  The image is laid
+// out across the left, main, and right displays as if they were one
+// 480x270 display, centered and cropped if needed.  Devices with a
+// dial display show the center of the image there as well.
+func (l *Loupedeck) SetSplash(im image.Image) {
+	l.splash = im
+}
+
+// ShowSplash draws the splash image set by SetSplash onto every
+// display.  Call this immediately after connecting, so users see
+// something sensible while the application builds its pages, rather
+// than a blank screen or leftovers from the previous run.  If the
+// displays haven't been configured yet, ShowSplash calls SetDisplays.
+func (l *Loupedeck) ShowSplash() {
+	if l.splash == nil {
+		return
+	}
+	if len(l.displays) == 0 {
+		if err := l.SetDisplays(); err != nil {
+			l.reportError(err)
+			return
+		}
+	}
+	l.drawAcrossDisplays(l.splash)
+}
+
+// SetShutdownScreen makes Close leave the Loupedeck in a tidy state
+// instead of showing stale controls after the program exits.  When
+// enabled, Close draws im across all displays (laid out the same way
+// as the splash image; nil means clear them to black) and turns off
+// the button LEDs before disconnecting.
+func (l *Loupedeck) SetShutdownScreen(enabled bool, im image.Image) {
+	l.shutdownScreen = enabled
+	l.shutdownImage = im
+}
+
+// showShutdownScreen draws the shutdown screen and turns off the
+// button LEDs.
+func (l *Loupedeck) showShutdownScreen() {
+	im := l.shutdownImage
+	if im == nil {
+		im = blankImage(480, 270)
+	}
+	l.drawAcrossDisplays(im)
+
+	for _, b := range l.ledButtons() {
+		_ = l.SetButtonColor(b, colorBackground)
+	}
+}
+
+// drawAcrossDisplays draws an image centered across all of the
+// Loupedeck's displays.
+func (l *Loupedeck) drawAcrossDisplays(src image.Image) {
+	canvas := centeredImage(src, 480, 270)
+
+	if all := l.GetDisplay("all"); all != nil {
+		all.Draw(canvas, 0, 0)
+	} else {
+		for name, x := range map[string]int{"left": 0, "main": 60, "right": 420} {
+			d := l.GetDisplay(name)
+			if d == nil {
+				continue
+			}
+			d.Draw(canvas.SubImage(image.Rect(x, 0, x+d.Width(), d.Height())), 0, 0)
+		}
+	}
+
+	if dial := l.GetDisplay("dial"); dial != nil {
+		dial.Draw(centeredImage(src, dial.Width(), dial.Height()), 0, 0)
+	}
+}
+
+// centeredImage returns a w x h image with src centered on it,
+// cropped if src is larger and padded with the background color if
+// it's smaller.
+func centeredImage(src image.Image, w, h int) *image.RGBA {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(im, im.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	b := src.Bounds()
+	offset := image.Pt((w-b.Dx())/2, (h-b.Dy())/2)
+	draw.Draw(im, b.Sub(b.Min).Add(offset), src, b.Min, draw.Src)
+	return im
+}
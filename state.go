@@ -0,0 +1,126 @@
+package loupedeck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UIState records where the user was in the UI: which WidgetHolder
+// tab and which Pager page were active.  See SetStateDir and
+// OnStateChange.
+type UIState struct {
+	WidgetTab int `json:"widget_tab"`
+	Page      int `json:"page"`
+}
+
+// StateFunc is a function signature used for callbacks on UIState
+// changes.
+type StateFunc func(UIState)
+
+// SetStateDir makes the Loupedeck remember which WidgetHolder tab and
+// Pager page were active in a small JSON file in dir, named after the
+// device's serial number.  When the controlling program restarts, the
+// WidgetHolder and Pager start where the user left off, rather than on
+// the first tab and page.
+//
+// It needs to be called before WidgetHolder and NewPager, and with
+// Listen running, since the Loupedeck reports its serial number
+// asynchronously; SetStateDir waits briefly for it if it hasn't
+// arrived yet.  A missing state file isn't an error.
+func (l *Loupedeck) SetStateDir(dir string) error {
+	select {
+	case <-l.serialReceived:
+	case <-time.After(serialTimeout):
+		return fmt.Errorf("timed out waiting for serial number")
+	}
+	path := filepath.Join(dir, stateFileName(l.SerialNo))
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		var s UIState
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("unable to parse %q: %v", path, err)
+		}
+		l.uiState = s
+	}
+
+	l.stateFile = path
+	return nil
+}
+
+// stateFileName returns the name of the state file for the device
+// with the given serial number.  The serial number comes from the
+// device, so anything other than letters, digits, '-', and '_' is
+// replaced with '_', to keep it from escaping the state directory.
+func stateFileName(serial string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, serial)
+	return "loupedeck-" + safe + ".json"
+}
+
+// RestoreState sets the UIState that WidgetHolder and NewPager start
+// from, for programs that persist it themselves via OnStateChange.
+func (l *Loupedeck) RestoreState(s UIState) {
+	l.uiState = s
+}
+
+// State returns the current UIState.
+func (l *Loupedeck) State() UIState {
+	return l.uiState
+}
+
+// OnStateChange adds a callback that is called whenever the active
+// WidgetHolder tab or Pager page changes.
+func (l *Loupedeck) OnStateChange(f StateFunc) {
+	l.stateBindings = append(l.stateBindings, f)
+}
+
+// updateState changes the UIState, saves it to the state file (if
+// there is one), and calls any callbacks.
+func (l *Loupedeck) updateState(f func(*UIState)) {
+	old := l.uiState
+	f(&l.uiState)
+	if l.uiState == old {
+		return
+	}
+
+	if l.stateFile != "" {
+		if err := l.saveState(); err != nil {
+			l.reportError(err)
+		}
+	}
+	for _, f := range l.stateBindings {
+		f(l.uiState)
+	}
+}
+
+// saveState writes the UIState to the state file.  It writes to a
+// temporary file first, so a crash can't leave a half-written file.
+func (l *Loupedeck) saveState() error {
+	b, err := json.Marshal(l.uiState)
+	if err != nil {
+		return err
+	}
+	tmp := l.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("unable to save state: %v", err)
+	}
+	if err := os.Rename(tmp, l.stateFile); err != nil {
+		return fmt.Errorf("unable to save state: %v", err)
+	}
+	return nil
+}
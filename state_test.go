@@ -0,0 +1,42 @@
+package loupedeck
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateFileNameIsSanitized(t *testing.T) {
+	for serial, want := range map[string]string{
+		"LDD2103012345A": "loupedeck-LDD2103012345A.json",
+		"../../etc/x":    "loupedeck-______etc_x.json",
+		`C:\x y`:         "loupedeck-C__x_y.json",
+	} {
+		if got := stateFileName(serial); got != want {
+			t.Errorf("stateFileName(%q) = %q, want %q", serial, got, want)
+		}
+	}
+}
+
+func TestSetStateDirWaitsForSerial(t *testing.T) {
+	d := NewMockDevice()
+	l, err := ConnectMock(d)
+	if err != nil {
+		t.Fatalf("ConnectMock: %v", err)
+	}
+	t.Cleanup(l.Close)
+	// Listen starts after SetStateDir, so the serial number is still
+	// on its way.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.Listen()
+	}()
+
+	dir := t.TempDir()
+	if err := l.SetStateDir(dir); err != nil {
+		t.Fatalf("SetStateDir: %v", err)
+	}
+	if want := filepath.Join(dir, stateFileName(d.SerialNo)); l.stateFile != want {
+		t.Errorf("state file is %q, want %q", l.stateFile, want)
+	}
+}
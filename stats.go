@@ -0,0 +1,94 @@
+package loupedeck
+
+import (
+	"sync"
+)
+
+// Stats holds counters describing the traffic to and from a
+// Loupedeck since it was connected (or since ResetStats was called).
+// They're mostly useful for diagnosing performance problems, like
+// displays lagging behind knob turns.
+type Stats struct {
+	// MessagesSent and MessagesReceived count messages by type.
+	MessagesSent     map[MessageType]uint64
+	MessagesReceived map[MessageType]uint64
+	// BytesWritten and BytesRead count the bytes in those
+	// messages, not including transport overhead.
+	BytesWritten uint64
+	BytesRead    uint64
+	// FramebufferWrites is the number of WriteFramebuff messages
+	// sent, which is usually what limits drawing speed.
+	FramebufferWrites uint64
+	// DroppedCallbacks is the number of transaction callbacks
+	// that never got a response, either because they were still
+	// waiting when their transaction ID was reused, or because
+	// SendAndWait gave up waiting.
+	DroppedCallbacks uint64
+	// Reconnects is the number of times the connection was
+	// restored after being lost.  See SetAutoReconnect.
+	Reconnects uint64
+}
+
+// statsState holds the counters behind Stats.
+type statsState struct {
+	mutex sync.Mutex
+	stats Stats
+}
+
+// Stats returns a copy of the Loupedeck's traffic counters.
+func (l *Loupedeck) Stats() Stats {
+	l.statsState.mutex.Lock()
+	defer l.statsState.mutex.Unlock()
+	s := l.statsState.stats
+	s.MessagesSent = copyCounts(s.MessagesSent)
+	s.MessagesReceived = copyCounts(s.MessagesReceived)
+	return s
+}
+
+// ResetStats sets all of the traffic counters back to zero.
+func (l *Loupedeck) ResetStats() {
+	l.statsState.mutex.Lock()
+	defer l.statsState.mutex.Unlock()
+	l.statsState.stats = Stats{}
+}
+
+// copyCounts returns a copy of a map of counters, which is never nil.
+func copyCounts(m map[MessageType]uint64) map[MessageType]uint64 {
+	c := make(map[MessageType]uint64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// updateStats calls f with the counters locked.
+func (l *Loupedeck) updateStats(f func(*Stats)) {
+	l.statsState.mutex.Lock()
+	defer l.statsState.mutex.Unlock()
+	f(&l.statsState.stats)
+}
+
+// countSent records a message sent to the Loupedeck.
+func (l *Loupedeck) countSent(t MessageType, n int) {
+	l.updateStats(func(s *Stats) {
+		if s.MessagesSent == nil {
+			s.MessagesSent = map[MessageType]uint64{}
+		}
+		s.MessagesSent[t]++
+		s.BytesWritten += uint64(n)
+		if t == WriteFramebuff {
+			s.FramebufferWrites++
+		}
+	})
+}
+
+// countReceived records a message received from the Loupedeck.
+func (l *Loupedeck) countReceived(t MessageType, n int) {
+	l.updateStats(func(s *Stats) {
+		if s.MessagesReceived == nil {
+			s.MessagesReceived = map[MessageType]uint64{}
+		}
+		s.MessagesReceived[t]++
+		s.BytesRead += uint64(n)
+	})
+}
@@ -0,0 +1,253 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StatusEvent describes a change in the Loupedeck's status, as seen
+// by status polling.
+type StatusEvent int
+
+const (
+	// StatusLost means that the Loupedeck stopped answering status
+	// polls.
+	StatusLost StatusEvent = iota
+	// StatusRecovered means that the Loupedeck started answering
+	// status polls again after StatusLost.  The device may have
+	// rebooted in the meantime and lost its display contents and
+	// button colors, so applications should redraw everything.
+	StatusRecovered
+	// StatusVersionChanged means that the Loupedeck reported a
+	// different firmware version than before, which means it has
+	// rebooted.
+	StatusVersionChanged
+)
+
+// String returns a human-readable name for the StatusEvent.
+func (e StatusEvent) String() string {
+	switch e {
+	case StatusLost:
+		return "lost"
+	case StatusRecovered:
+		return "recovered"
+	case StatusVersionChanged:
+		return "version changed"
+	}
+	return fmt.Sprintf("StatusEvent(%d)", int(e))
+}
+
+// StatusFunc is a function signature used for callbacks on status
+// events.
+type StatusFunc func(StatusEvent)
+
+// Health describes what status polling knows about the Loupedeck.
+type Health struct {
+	// Responding is false if the most recent status poll wasn't
+	// answered.
+	Responding bool
+	// LastPoll is when the most recent status poll was sent.
+	LastPoll time.Time
+	// LastResponse is when the most recent answer arrived.
+	LastResponse time.Time
+	// Version is the firmware version from the most recent answer.
+	Version string
+	// Recoveries counts how many times the Loupedeck has stopped
+	// and then resumed answering.
+	Recoveries int
+}
+
+// statusState holds status polling state.
+type statusState struct {
+	mutex    sync.Mutex
+	health   Health
+	pending  bool // true if the last poll hasn't been answered
+	stop     chan struct{}
+	bindings []StatusFunc
+
+	disconnected       bool // true after a read error, until reconnected
+	disconnectBindings []DisconnectFunc
+}
+
+// ErrNotResponding is passed to DisconnectFuncs when the Loupedeck
+// stops answering status polls, even though the connection itself is
+// still open.  This usually means that the device is wedged.
+var ErrNotResponding = errors.New("Loupedeck stopped responding")
+
+// DisconnectFunc is a function signature used for callbacks when the
+// Loupedeck is disconnected or stops responding.  See OnDisconnect.
+type DisconnectFunc func(err error)
+
+// Healthy returns true if the connection to the Loupedeck is open and
+// (if status polling is running) the Loupedeck answered its most
+// recent status poll.  Status polling doubles as a keepalive, so
+// long-running programs should use StartStatusPolling to notice a
+// wedged device before anyone presses a button.
+func (l *Loupedeck) Healthy() bool {
+	if l.closed.Load() {
+		return false
+	}
+	l.status.mutex.Lock()
+	defer l.status.mutex.Unlock()
+	if l.status.disconnected {
+		return false
+	}
+	return l.status.stop == nil || l.status.health.Responding
+}
+
+// OnDisconnect adds a callback that is called when reading from the
+// Loupedeck fails (usually because it was unplugged), or with
+// ErrNotResponding when it stops answering status polls.  It isn't
+// called when the connection is closed with Close.
+func (l *Loupedeck) OnDisconnect(f DisconnectFunc) {
+	l.status.mutex.Lock()
+	defer l.status.mutex.Unlock()
+	l.status.disconnectBindings = append(l.status.disconnectBindings, f)
+}
+
+// setDisconnected records whether the connection has failed, and
+// calls the OnDisconnect callbacks with err when it does.
+func (l *Loupedeck) setDisconnected(disconnected bool, err error) {
+	l.status.mutex.Lock()
+	l.status.disconnected = disconnected
+	bindings := l.status.disconnectBindings
+	l.status.mutex.Unlock()
+
+	if disconnected {
+		l.sendDisconnect(bindings, err)
+	}
+}
+
+func (l *Loupedeck) sendDisconnect(bindings []DisconnectFunc, err error) {
+	for _, f := range bindings {
+		f(err)
+	}
+}
+
+// Health returns the Loupedeck's health as of the most recent status
+// poll.  See StartStatusPolling.
+func (l *Loupedeck) Health() Health {
+	l.status.mutex.Lock()
+	defer l.status.mutex.Unlock()
+	return l.status.health
+}
+
+// OnStatusEvent adds a callback for status events detected by status
+// polling.
+func (l *Loupedeck) OnStatusEvent(f StatusFunc) {
+	l.status.mutex.Lock()
+	defer l.status.mutex.Unlock()
+	l.status.bindings = append(l.status.bindings, f)
+}
+
+// StartStatusPolling starts asking the Loupedeck for its firmware
+// version every interval.  The answers (or lack of them) are
+// reflected in Health, and changes are reported to callbacks added
+// with OnStatusEvent.  This is intended for long-running
+// installations, where the device may silently reboot and lose its
+// display contents and button colors.  The polls also act as a
+// keepalive: a poll that goes unanswered marks the Loupedeck as
+// unhealthy (see Healthy) and calls any OnDisconnect callbacks.
+//
+// Answers are delivered by Listen, so it needs to be running.
+func (l *Loupedeck) StartStatusPolling(interval time.Duration) {
+	l.StopStatusPolling()
+
+	l.status.mutex.Lock()
+	stop := make(chan struct{})
+	l.status.stop = stop
+	l.status.health.Responding = true
+	l.status.health.Version = l.Version
+	l.status.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				l.pollStatus()
+			}
+		}
+	}()
+}
+
+// StopStatusPolling stops status polling.
+func (l *Loupedeck) StopStatusPolling() {
+	l.status.mutex.Lock()
+	defer l.status.mutex.Unlock()
+	if l.status.stop != nil {
+		close(l.status.stop)
+		l.status.stop = nil
+	}
+}
+
+// pollStatus sends a single status poll, and records whether the
+// previous one was answered.
+func (l *Loupedeck) pollStatus() {
+	s := &l.status
+	s.mutex.Lock()
+	var events []StatusEvent
+	if s.pending && s.health.Responding {
+		s.health.Responding = false
+		events = append(events, StatusLost)
+	}
+	s.pending = true
+	s.health.LastPoll = time.Now()
+	s.mutex.Unlock()
+
+	l.sendStatusEvents(events)
+
+	m := l.NewMessage(Version, []byte{})
+	err := l.SendWithCallback(m, func(m *Message) {
+		if len(m.data) < 3 {
+			return
+		}
+		l.statusResponse(fmt.Sprintf("%d.%d.%d", m.data[0], m.data[1], m.data[2]))
+	})
+	if err != nil {
+		l.reportError(fmt.Errorf("unable to send status poll: %v", err))
+	}
+}
+
+// statusResponse records an answer to a status poll.
+func (l *Loupedeck) statusResponse(version string) {
+	s := &l.status
+	s.mutex.Lock()
+	var events []StatusEvent
+	s.pending = false
+	s.health.LastResponse = time.Now()
+	if !s.health.Responding {
+		s.health.Responding = true
+		s.health.Recoveries++
+		events = append(events, StatusRecovered)
+	}
+	if s.health.Version != "" && s.health.Version != version {
+		events = append(events, StatusVersionChanged)
+	}
+	s.health.Version = version
+	s.mutex.Unlock()
+
+	l.sendStatusEvents(events)
+}
+
+func (l *Loupedeck) sendStatusEvents(events []StatusEvent) {
+	l.status.mutex.Lock()
+	bindings := l.status.bindings
+	disconnectBindings := l.status.disconnectBindings
+	l.status.mutex.Unlock()
+
+	for _, e := range events {
+		l.log().Info("Loupedeck status changed", "event", e)
+		for _, f := range bindings {
+			f(e)
+		}
+		if e == StatusLost {
+			l.sendDisconnect(disconnectBindings, ErrNotResponding)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package loupedeck
+
+import (
+	"image"
+	"image/draw"
+)
+
+// stripSliderMargin is the space left above and below the fill in a
+// StripSlider, so that the minimum and maximum are easy to reach
+// with a finger.
+const stripSliderMargin = 10
+
+// StripSlider is a touch slider that uses the whole height of one of
+// the Loupedeck Live's 60x270 side strips.  Touching or dragging on
+// the strip sets the value directly: the top of the strip is the
+// maximum, and the bottom is the minimum.  The strip is filled from
+// the bottom up to show the current value, with the value itself
+// written across the top.
+//
+// This complements TouchDial, which uses the same strips but is
+// driven mostly by the knobs.
+type StripSlider struct {
+	// Format controls how the value is shown.
+	Format ValueFormat
+
+	loupedeck *Loupedeck
+	display   *Display
+	value     *WatchedInt
+	min, max  int
+	bindings  *BindingSet
+	destroyed bool
+}
+
+// NewStripSlider creates a StripSlider on display, which should be
+// the "left" or "right" strip, setting value between min and max.
+func (l *Loupedeck) NewStripSlider(display *Display, value *WatchedInt, min, max int) *StripSlider {
+	touch := TouchLeft
+	if display.Name == "right" {
+		touch = TouchRight
+	}
+
+	s := &StripSlider{
+		loupedeck: l,
+		display:   display,
+		value:     value,
+		min:       min,
+		max:       max,
+		bindings:  l.NewBindingSet(),
+	}
+
+	s.bindings.BindTouch(touch, func(_ TouchButton, _ ButtonStatus, _, y uint16) {
+		s.value.Set(s.valueAt(int(y)))
+	})
+	s.bindings.Apply()
+
+	s.Draw()
+	value.AddWatcher(func(int) { s.Draw() })
+	return s
+}
+
+// valueAt returns the value for a touch at y.
+func (s *StripSlider) valueAt(y int) int {
+	h := s.display.Height() - 2*stripSliderMargin
+	if h <= 0 || s.max == s.min {
+		return s.min
+	}
+	fraction := float64(h-(y-stripSliderMargin)) / float64(h)
+	v := s.min + int(fraction*float64(s.max-s.min)+0.5)
+	return clamp(v, s.min, s.max)
+}
+
+// Destroy removes the StripSlider's touch bindings and stops it from
+// drawing, so that its display can be reused for something else.
+func (s *StripSlider) Destroy() {
+	s.destroyed = true
+	s.bindings.Remove()
+}
+
+// Draw updates the display for a StripSlider.
+func (s *StripSlider) Draw() {
+	if s.destroyed {
+		return
+	}
+	theme := s.loupedeck.Theme()
+	w, h := s.display.Width(), s.display.Height()
+	im := s.display.Canvas(0, 0, w, h)
+	im.Fill(theme.Background)
+
+	v := clamp(s.value.Get(), s.min, s.max)
+	fraction := 0.0
+	if s.max != s.min {
+		fraction = float64(v-s.min) / float64(s.max-s.min)
+	}
+	track := h - 2*stripSliderMargin
+	top := h - stripSliderMargin - int(float64(track)*fraction)
+	draw.Draw(im, image.Rect(4, stripSliderMargin, w-4, h-stripSliderMargin), &image.Uniform{theme.Inactive}, image.Point{}, draw.Src)
+	draw.Draw(im, image.Rect(4, top, w-4, h-stripSliderMargin), &image.Uniform{theme.Active}, image.Point{}, draw.Src)
+
+	fd := s.loupedeck.FontDrawer()
+	fd.Dst = im
+	fd.Src = &image.Uniform{theme.Text}
+	drawCenteredStringAt(fd, s.loupedeck.FormatValue(s.value.Get(), s.Format), w/2, stripSliderMargin+20)
+
+	im.Flush()
+}
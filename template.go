@@ -0,0 +1,179 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/jphsd/graphics2d"
+	xdraw "golang.org/x/image/draw"
+)
+
+// ButtonTemplate describes a consistent look for button artwork: a
+// background (solid or a vertical gradient), an icon slot, a label
+// slot along the bottom, and a badge slot in the upper right corner.
+// The slots are filled in from a ButtonContent, so applications can
+// describe their buttons as data and get a uniform look without any
+// custom drawing code.
+type ButtonTemplate struct {
+	// Name identifies the template in the image cache, and needs
+	// to be unique among templates drawn into the same Region.
+	Name string
+
+	// Background is the background color.  If Gradient is set
+	// (has a non-zero alpha), the background fades from
+	// Background at the top to Gradient at the bottom.
+	Background color.RGBA
+	Gradient   color.RGBA
+
+	// LabelColor is the color of the label text.
+	LabelColor color.RGBA
+	// LabelHeight is the height (in pixels) of the label slot.  If
+	// it's 0, a third of the button's height is used.
+	LabelHeight int
+
+	// IconPadding is the space (in pixels) left around the icon.
+	IconPadding int
+
+	// BadgeColor and BadgeTextColor are the colors of the badge
+	// and its text.
+	BadgeColor     color.RGBA
+	BadgeTextColor color.RGBA
+}
+
+// ButtonContent holds the data shown in a ButtonTemplate's slots.
+// Empty slots are left out, and if there's no label, the icon gets
+// the whole button.
+type ButtonContent struct {
+	Icon image.Image
+	// IconID identifies the icon for caching purposes.  If it's
+	// empty, the icon is identified by its address, which only
+	// works if the same image.Image is reused.
+	IconID string
+	Label  string
+	Badge  string
+}
+
+// DefaultButtonTemplate is a simple template with a dark gray
+// gradient, white labels, and red badges.
+var DefaultButtonTemplate = &ButtonTemplate{
+	Name:           "default",
+	Background:     color.RGBA{64, 64, 64, 255},
+	Gradient:       color.RGBA{16, 16, 16, 255},
+	LabelColor:     color.RGBA{255, 255, 255, 255},
+	IconPadding:    8,
+	BadgeColor:     color.RGBA{192, 0, 0, 255},
+	BadgeTextColor: color.RGBA{255, 255, 255, 255},
+}
+
+// badgeSize is the diameter (in pixels) of badges.
+const badgeSize = 28
+
+// contentID returns a cache content ID for c rendered with t.
+func (t *ButtonTemplate) contentID(c ButtonContent) string {
+	icon := c.IconID
+	if icon == "" && c.Icon != nil {
+		icon = fmt.Sprintf("%p", c.Icon)
+	}
+	return fmt.Sprintf("template:%s:%s:%q:%q", t.Name, icon, c.Label, c.Badge)
+}
+
+// Render renders c into a new w x h image using the template.
+func (t *ButtonTemplate) Render(l *Loupedeck, w, h int, c ButtonContent) image.Image {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	t.drawBackground(im)
+
+	iconArea := im.Bounds()
+	if c.Label != "" {
+		labelHeight := t.LabelHeight
+		if labelHeight == 0 {
+			labelHeight = h / 3
+		}
+		iconArea.Max.Y -= labelHeight
+		label, err := l.TextInBox(w, labelHeight, c.Label, t.LabelColor, color.Transparent)
+		if err == nil {
+			draw.Draw(im, image.Rect(0, h-labelHeight, w, h), label, image.Point{}, draw.Over)
+		}
+	}
+
+	if c.Icon != nil {
+		iconArea = iconArea.Inset(t.IconPadding)
+		xdraw.ApproxBiLinear.Scale(im, fitRect(c.Icon.Bounds(), iconArea), c.Icon, c.Icon.Bounds(), draw.Over, nil)
+	}
+
+	if c.Badge != "" {
+		drawBadge(l, im, c.Badge, t.BadgeColor, t.BadgeTextColor)
+	}
+	return im
+}
+
+// DrawTemplate renders c with the template t into a Region.  Rendered
+// images are cached (see DrawCached), so redrawing unchanged content
+// doesn't send anything to the Loupedeck.  It returns true if
+// anything was sent.
+func (l *Loupedeck) DrawTemplate(r *Region, t *ButtonTemplate, c ButtonContent) bool {
+	return l.DrawCached(r, t.contentID(c), func() image.Image {
+		return t.Render(l, r.width, r.height, c)
+	})
+}
+
+// drawBackground fills im with the template's background.
+func (t *ButtonTemplate) drawBackground(im *image.RGBA) {
+	b := im.Bounds()
+	if t.Gradient.A == 0 {
+		draw.Draw(im, b, &image.Uniform{t.Background}, image.Point{}, draw.Src)
+		return
+	}
+	h := b.Dy()
+	for y := 0; y < h; y++ {
+		f := float64(y) / float64(h)
+		c := color.RGBA{
+			lerp8(t.Background.R, t.Gradient.R, f),
+			lerp8(t.Background.G, t.Gradient.G, f),
+			lerp8(t.Background.B, t.Gradient.B, f),
+			lerp8(t.Background.A, t.Gradient.A, f),
+		}
+		draw.Draw(im, image.Rect(b.Min.X, b.Min.Y+y, b.Max.X, b.Min.Y+y+1), &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+}
+
+func lerp8(a, b uint8, f float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*f)
+}
+
+// fitRect returns the largest rectangle with the aspect ratio of src
+// that fits inside dst, centered in dst.
+func fitRect(src, dst image.Rectangle) image.Rectangle {
+	sw, sh := src.Dx(), src.Dy()
+	dw, dh := dst.Dx(), dst.Dy()
+	if sw <= 0 || sh <= 0 || dw <= 0 || dh <= 0 {
+		return image.Rectangle{}
+	}
+	w, h := dw, sh*dw/sw
+	if h > dh {
+		w, h = sw*dh/sh, dh
+	}
+	x := dst.Min.X + (dw-w)/2
+	y := dst.Min.Y + (dh-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// drawBadge draws a round badge containing text in the upper right
+// corner of im.
+func drawBadge(l *Loupedeck, im draw.Image, text string, bg, fg color.RGBA) {
+	b := im.Bounds()
+	cx := float64(b.Max.X - badgeSize/2 - 2)
+	cy := float64(b.Min.Y + badgeSize/2 + 2)
+	graphics2d.DrawPoint(im, []float64{cx, cy}, graphics2d.NewPen(bg, badgeSize))
+
+	// The largest square that fits inside the circle.
+	side := badgeSize * 7 / 10
+	label, err := l.TextInBox(side, side, text, fg, bg)
+	if err != nil {
+		return
+	}
+	x := int(cx) - side/2
+	y := int(cy) - side/2
+	draw.Draw(im, image.Rect(x, y, x+side, y+side), label, image.Point{}, draw.Src)
+}
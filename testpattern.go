@@ -0,0 +1,99 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// testPatternBars are the classic SMPTE-style color bars.
+var testPatternBars = []color.RGBA{
+	{255, 255, 255, 255},
+	{255, 255, 0, 255},
+	{0, 255, 255, 255},
+	{0, 255, 0, 255},
+	{255, 0, 255, 255},
+	{255, 0, 0, 255},
+	{0, 0, 255, 255},
+	{0, 0, 0, 255},
+}
+
+// testPatternCorners are the colors of the corner markers, clockwise
+// from the top left, so that a rotated or mirrored image is easy to
+// spot.
+var testPatternCorners = []color.RGBA{
+	{255, 0, 0, 255},
+	{0, 255, 0, 255},
+	{0, 0, 255, 255},
+	{255, 255, 255, 255},
+}
+
+// ShowTestPattern draws a test pattern on every display: color bars
+// across the top two thirds, a grid of 1-pixel lines every 10 pixels
+// across the bottom third, and a differently colored marker in each
+// corner (red, green, blue, and white, clockwise from the top left).
+// Wrong colors mean a pixel format problem (see PixelFormat), a
+// misplaced grid or markers means an offset or size problem, and
+// nothing at all means the display isn't being addressed correctly.
+//
+// On devices with a single unified display, the pattern is drawn once
+// across the whole thing, rather than once per emulated display.
+func (l *Loupedeck) ShowTestPattern() {
+	for _, d := range l.testPatternDisplays() {
+		d.Draw(testPattern(d.Width(), d.Height()), 0, 0)
+	}
+}
+
+// testPatternDisplays returns the largest display for each display
+// ID, so that overlapping emulated displays are skipped.
+func (l *Loupedeck) testPatternDisplays() []*Display {
+	largest := map[byte]*Display{}
+	var order []byte
+	for _, d := range l.Displays() {
+		o := largest[d.id]
+		if o == nil {
+			order = append(order, d.id)
+		}
+		if o == nil || d.width*d.height > o.width*o.height {
+			largest[d.id] = d
+		}
+	}
+	displays := make([]*Display, 0, len(order))
+	for _, id := range order {
+		displays = append(displays, largest[id])
+	}
+	return displays
+}
+
+// testPattern renders a w x h test pattern.  See ShowTestPattern.
+func testPattern(w, h int) *image.RGBA {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(im, im.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	barHeight := h * 2 / 3
+	for i, c := range testPatternBars {
+		x0 := w * i / len(testPatternBars)
+		x1 := w * (i + 1) / len(testPatternBars)
+		draw.Draw(im, image.Rect(x0, 0, x1, barHeight), &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+
+	gray := color.RGBA{128, 128, 128, 255}
+	for x := 0; x < w; x += 10 {
+		draw.Draw(im, image.Rect(x, barHeight, x+1, h), &image.Uniform{gray}, image.Point{}, draw.Src)
+	}
+	for y := barHeight; y < h; y += 10 {
+		draw.Draw(im, image.Rect(0, y, w, y+1), &image.Uniform{gray}, image.Point{}, draw.Src)
+	}
+
+	size := min(w, h) / 8
+	if size < 4 {
+		size = 4
+	}
+	corners := []image.Point{{0, 0}, {w - size, 0}, {w - size, h - size}, {0, h - size}}
+	for i, p := range corners {
+		r := image.Rect(p.X, p.Y, p.X+size, p.Y+size)
+		draw.Draw(im, r, &image.Uniform{color.Black}, image.Point{}, draw.Src)
+		draw.Draw(im, r.Inset(2), &image.Uniform{testPatternCorners[i]}, image.Point{}, draw.Src)
+	}
+	return im
+}
@@ -0,0 +1,122 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// Theme holds the colors, line widths, and font sizes used by this
+// package's widgets and text helpers.
+type Theme struct {
+	// Active is used for highlighted elements, like the filled
+	// part of an arc or the current page indicator.
+	Active color.RGBA
+	// Inactive is used for unhighlighted elements.
+	Inactive color.RGBA
+	// Background is the background color for widgets.
+	Background color.RGBA
+	// Text is the default text color, used by FontDrawer.
+	Text color.RGBA
+
+	// LineScale multiplies the width of every line drawn by
+	// widgets.  See LineWidth.
+	LineScale float64
+
+	// FontSize is the size of the font returned by Face and
+	// FontDrawer, in points at 150 DPI.
+	FontSize float64
+	// MinFontSize is the smallest font size that TextInBox will
+	// use.  Text that doesn't fit at this size is clipped.  0
+	// means that there's no minimum.
+	MinFontSize float64
+}
+
+// DefaultTheme is the theme used unless SetTheme or SetHighContrast
+// is called.
+var DefaultTheme = Theme{
+	Active:     colorActive,
+	Inactive:   colorInActive,
+	Background: colorBackground,
+	Text:       color.RGBA{255, 255, 255, 255},
+	LineScale:  1,
+	FontSize:   12,
+}
+
+// HighContrastTheme is a theme for users who have trouble reading the
+// default theme, especially on the narrow side displays.  It uses
+// brighter colors, thicker lines, and larger text.
+var HighContrastTheme = Theme{
+	Active:      color.RGBA{255, 255, 255, 255},
+	Inactive:    color.RGBA{128, 128, 128, 255},
+	Background:  color.RGBA{0, 0, 0, 255},
+	Text:        color.RGBA{255, 255, 0, 255},
+	LineScale:   2,
+	FontSize:    16,
+	MinFontSize: 10,
+}
+
+// LineWidth scales a line width by the theme's LineScale.
+func (t *Theme) LineWidth(w float64) float64 {
+	if t.LineScale <= 0 {
+		return w
+	}
+	return w * t.LineScale
+}
+
+// Theme returns the theme currently in use.
+func (l *Loupedeck) Theme() *Theme {
+	return &l.theme
+}
+
+// SetTheme changes the theme used for drawing.  Widgets pick up the
+// new theme the next time that they're drawn; anything that's already
+// on the displays is left alone.
+func (l *Loupedeck) SetTheme(t Theme) error {
+	l.theme = t
+	l.InvalidateAll()
+	if l.font == nil {
+		return nil
+	}
+	return l.updateFace()
+}
+
+// SetHighContrast switches between HighContrastTheme and DefaultTheme.
+func (l *Loupedeck) SetHighContrast(enabled bool) error {
+	if enabled {
+		return l.SetTheme(HighContrastTheme)
+	}
+	return l.SetTheme(DefaultTheme)
+}
+
+// HighContrast returns true if HighContrastTheme is in use.
+func (l *Loupedeck) HighContrast() bool {
+	return l.theme == HighContrastTheme
+}
+
+// newFace returns a new font face for the theme's font size.  Faces
+// aren't safe for concurrent use, so anything that draws text off the
+// Listen goroutine needs its own.
+func (l *Loupedeck) newFace() (font.Face, error) {
+	return opentype.NewFace(l.font, &opentype.FaceOptions{
+		Size: l.theme.FontSize,
+		DPI:  150,
+	})
+}
+
+// updateFace recreates the default font face and drawer from the
+// theme.
+func (l *Loupedeck) updateFace() error {
+	face, err := l.newFace()
+	if err != nil {
+		return err
+	}
+	l.face = face
+	l.fontdrawer = &font.Drawer{
+		Src:  &image.Uniform{l.theme.Text},
+		Face: l.face,
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+// ToastSeverity controls the colors used for a toast notification.
+type ToastSeverity int
+
+const (
+	// ToastInfo is used for routine notifications.
+	ToastInfo ToastSeverity = iota
+	// ToastWarning is used for problems that may need attention.
+	ToastWarning
+	// ToastError is used for failures.
+	ToastError
+)
+
+// colors returns the foreground and background colors for a severity.
+func (s ToastSeverity) colors() (color.Color, color.Color) {
+	switch s {
+	case ToastWarning:
+		return color.Black, color.RGBA{255, 192, 0, 255}
+	case ToastError:
+		return color.White, color.RGBA{192, 0, 0, 255}
+	default:
+		return color.Black, color.RGBA{192, 192, 192, 255}
+	}
+}
+
+// toastHeight is the height (in pixels) of the toast band.
+const toastHeight = 70
+
+// toast is the state of the currently-visible toast, if any.
+type toast struct {
+	timer *time.Timer
+	saved image.Image
+	y     int
+}
+
+// ShowToast shows a short message in a band across the middle of the
+// main display for the specified duration, and then restores
+// whatever was underneath.  Showing a toast while another is visible
+// replaces it.
+//
+// Restoring the previous contents requires the client-side mirror
+// (see SetMirroring); without it, the area is cleared to black when
+// the toast expires.  Anything drawn underneath the toast while it's
+// visible will be lost when it's removed.
+func (l *Loupedeck) ShowToast(text string, duration time.Duration, severity ToastSeverity) error {
+	d := l.GetDisplay("main")
+	if d == nil {
+		return nil
+	}
+
+	fg, bg := severity.colors()
+	y := (d.Height() - toastHeight) / 2
+	im, err := l.TextInBox(d.Width(), toastHeight, text, fg, bg)
+	if err != nil {
+		return err
+	}
+
+	l.toastMutex.Lock()
+	defer l.toastMutex.Unlock()
+
+	if l.toast != nil {
+		l.toast.timer.Stop()
+	} else {
+		var saved image.Image
+		if snap := l.Snapshot(d); snap != nil {
+			saved = snap.(*image.RGBA).SubImage(image.Rect(0, y, d.Width(), y+toastHeight))
+		}
+		l.toast = &toast{saved: saved, y: y}
+	}
+
+	t := l.toast
+	t.timer = time.AfterFunc(duration, func() {
+		l.toastMutex.Lock()
+		defer l.toastMutex.Unlock()
+		if l.toast != t {
+			return
+		}
+		l.toast = nil
+		l.restoreToast(d, t)
+	})
+
+	d.Draw(im, 0, y)
+	return nil
+}
+
+// HideToast removes the current toast immediately, if one is visible.
+func (l *Loupedeck) HideToast() {
+	l.toastMutex.Lock()
+	defer l.toastMutex.Unlock()
+	if t := l.toast; t != nil {
+		t.timer.Stop()
+		l.toast = nil
+		l.restoreToast(l.GetDisplay("main"), t)
+	}
+}
+
+// restoreToast puts back whatever was under a toast.
+func (l *Loupedeck) restoreToast(d *Display, t *toast) {
+	saved := t.saved
+	if saved == nil {
+		saved = blankImage(d.Width(), toastHeight)
+	}
+	d.Draw(saved, 0, t.y)
+}
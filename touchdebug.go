@@ -0,0 +1,98 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+var (
+	colorDebugOutline = color.RGBA{0, 255, 0, 255}
+	colorDebugTrail   = color.RGBA{255, 255, 0, 255}
+	colorDebugCross   = color.RGBA{255, 0, 0, 255}
+)
+
+// touchDebugState holds the trail of touch points for the touch
+// debug overlay.
+type touchDebugState struct {
+	button TouchButton
+	trail  []image.Point
+}
+
+// SetTouchDebug enables or disables the touch debug overlay.  While
+// enabled, every touch on the main touchscreen outlines the
+// TouchButton cell that the touch was mapped to, draws a crosshair at
+// the reported coordinates, and leaves a trail as the touch moves.
+// This makes it easy to see how touch coordinates map onto buttons,
+// which is mostly useful when adding support for new hardware.
+//
+// The overlay draws over whatever is on the display; redraw the
+// display after turning it off.
+func (l *Loupedeck) SetTouchDebug(enabled bool) {
+	if enabled {
+		l.touchDebug = &touchDebugState{}
+	} else {
+		l.touchDebug = nil
+	}
+}
+
+// touchOrigin returns the location of a TouchButton's upper left
+// corner, in touchscreen coordinates.
+func (l *Loupedeck) touchOrigin(b TouchButton) image.Point {
+	g := l.touchGrid()
+	switch b {
+	case TouchLeft:
+		return image.Pt(0, 0)
+	case TouchRight:
+		return image.Pt(g.StripWidth+g.Columns*g.CellSize, 0)
+	}
+	x, y := l.cellXY(b)
+	return image.Pt(x+g.StripWidth+g.Margin, y)
+}
+
+// drawTouchDebug draws the touch debug overlay for a single touch
+// event.
+func (l *Loupedeck) drawTouchDebug(b TouchButton, s ButtonStatus, x, y uint16) {
+	t := l.touchDebug
+	r := l.CellRegion(b)
+	if t == nil || r == nil {
+		return
+	}
+
+	if b != t.button {
+		t.button = b
+		t.trail = nil
+	}
+
+	origin := l.touchOrigin(b)
+	p := image.Pt(int(x), int(y)).Sub(origin)
+	t.trail = append(t.trail, p)
+
+	im := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	draw.Draw(im, im.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	// Outline the cell that the touch was mapped to.
+	for i := 0; i < r.width; i++ {
+		im.Set(i, 0, colorDebugOutline)
+		im.Set(i, r.height-1, colorDebugOutline)
+	}
+	for i := 0; i < r.height; i++ {
+		im.Set(0, i, colorDebugOutline)
+		im.Set(r.width-1, i, colorDebugOutline)
+	}
+
+	for _, tp := range t.trail {
+		draw.Draw(im, image.Rect(tp.X-1, tp.Y-1, tp.X+2, tp.Y+2), &image.Uniform{colorDebugTrail}, image.Point{}, draw.Src)
+	}
+
+	for i := -8; i <= 8; i++ {
+		im.Set(p.X+i, p.Y, colorDebugCross)
+		im.Set(p.X, p.Y+i, colorDebugCross)
+	}
+
+	r.Draw(im)
+
+	if s == ButtonUp {
+		t.trail = nil
+	}
+}
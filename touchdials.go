@@ -17,11 +17,12 @@
 package loupedeck
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"log/slog"
-	"strconv"
+	"time"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
@@ -34,10 +35,15 @@ import (
 //
 // The display will show the current value of the WatchedInt for each
 // knob.  Turning the knob will increment/decrement each value as
-// expected.  Clicking the knob will zero the value.  Sliding up or
+// expected.  Clicking the knob will zero the value, unless changed
+// with SetPressAction on Knob1, Knob2, or Knob3.  Sliding up or
 // down on the LCD display will increase or decrease all 3 knob values
 // at once.
 type TouchDial struct {
+	// Format controls how the values are shown.  The strips are
+	// narrow, so short units work best.
+	Format ValueFormat
+
 	loupedeck              *Loupedeck
 	display                *Display
 	w1, w2, w3             *WatchedInt
@@ -45,8 +51,17 @@ type TouchDial struct {
 	Knob1, Knob2, Knob3    *IntKnob
 	touchdivisor           int
 	dragstart              uint16
+	linkHighlight          bool
+	highlighted            int // row shown in inverse video, or -1
+	highlightTimer         *time.Timer
+	bindings               *BindingSet
+	destroyed              bool
 }
 
+// linkedHighlightDuration is how long a row stays highlighted after
+// it's touched or its knob is turned.  See SetLinkedHighlight.
+const linkedHighlightDuration = time.Second
+
 // NewTouchDial creates a TouchDial.
 func (l *Loupedeck) NewTouchDial(display *Display, w1, w2, w3 *WatchedInt, min, max int) *TouchDial {
 	touch := TouchLeft
@@ -63,11 +78,13 @@ func (l *Loupedeck) NewTouchDial(display *Display, w1, w2, w3 *WatchedInt, min,
 	}
 
 	touchdial := &TouchDial{
-		loupedeck: l,
-		display:   display,
-		w1:        w1,
-		w2:        w2,
-		w3:        w3,
+		loupedeck:   l,
+		display:     display,
+		w1:          w1,
+		w2:          w2,
+		w3:          w3,
+		highlighted: -1,
+		bindings:    l.NewBindingSet(),
 	}
 
 	touchdial.touchdivisor = int(float64(display.Height()) / float64(max-min))
@@ -78,8 +95,11 @@ func (l *Loupedeck) NewTouchDial(display *Display, w1, w2, w3 *WatchedInt, min,
 
 	touchdial.dragstart = 65535
 
-	l.BindTouch(touch, func(t TouchButton, s ButtonStatus, x, y uint16) {
+	touchdial.bindings.BindTouch(touch, func(t TouchButton, s ButtonStatus, x, y uint16) {
 		if touchdial.dragstart == 65535 {
+			if touchdial.linkHighlight {
+				touchdial.highlight(int(y) / 90)
+			}
 			touchdial.dragv1 = w1.Get()
 			touchdial.dragv2 = w2.Get()
 			touchdial.dragv3 = w3.Get()
@@ -91,14 +111,15 @@ func (l *Loupedeck) NewTouchDial(display *Display, w1, w2, w3 *WatchedInt, min,
 			touchdial.Knob3.Set(int(touchdial.dragv3) + delta)
 		}
 	})
-	l.BindTouchUp(touch, func(t TouchButton, s ButtonStatus, x, y uint16) {
+	touchdial.bindings.BindTouchUp(touch, func(t TouchButton, s ButtonStatus, x, y uint16) {
 		touchdial.dragstart = 65535
 	})
+	touchdial.bindings.Apply()
 
 	touchdial.Draw()
-	touchdial.w1.AddWatcher(func(i int) { touchdial.Draw() })
-	touchdial.w2.AddWatcher(func(i int) { touchdial.Draw() })
-	touchdial.w3.AddWatcher(func(i int) { touchdial.Draw() })
+	touchdial.w1.AddWatcher(func(i int) { touchdial.queueDraw() })
+	touchdial.w2.AddWatcher(func(i int) { touchdial.queueDraw() })
+	touchdial.w3.AddWatcher(func(i int) { touchdial.queueDraw() })
 	return touchdial
 }
 
@@ -126,8 +147,90 @@ func drawCenteredStringAt(fd font.Drawer, s string, x, y int) {
 	fd.DrawString(s)
 }
 
+// SetLinkedHighlight makes the TouchDial briefly show a knob's value
+// in inverse video whenever that knob is turned or its row on the
+// display is touched.  This helps users learn which knob goes with
+// which value.
+func (t *TouchDial) SetLinkedHighlight(enabled bool) {
+	t.linkHighlight = enabled
+	for i, k := range []*IntKnob{t.Knob1, t.Knob2, t.Knob3} {
+		row, k := i, k
+		k.bindings.BindKnob(k.knob, func(_ Knob, v int) {
+			if t.linkHighlight {
+				t.highlight(row)
+			}
+			k.Inc(v * k.step())
+		})
+	}
+}
+
+// highlight shows a row in inverse video for
+// linkedHighlightDuration.
+func (t *TouchDial) highlight(row int) {
+	if t.highlightTimer != nil {
+		t.highlightTimer.Stop()
+	}
+	changed := t.highlighted != row
+	t.highlighted = row
+	var timer *time.Timer
+	timer = time.AfterFunc(linkedHighlightDuration, func() {
+		t.loupedeck.post(func() {
+			if t.highlightTimer != timer {
+				return
+			}
+			t.highlightTimer = nil
+			t.highlighted = -1
+			t.queueDraw()
+		})
+	})
+	t.highlightTimer = timer
+	if changed {
+		t.queueDraw()
+	}
+}
+
+// Destroy removes all of the TouchDial's touch and knob bindings and
+// stops it from drawing, so that its display and knobs can be reused
+// for something else.
+func (t *TouchDial) Destroy() {
+	t.destroyed = true
+	t.loupedeck.DrawQueue().drop(t.drawOwner())
+	t.bindings.Remove()
+	for _, k := range []*IntKnob{t.Knob1, t.Knob2, t.Knob3} {
+		k.Destroy()
+	}
+	if t.highlightTimer != nil {
+		t.highlightTimer.Stop()
+		t.highlightTimer = nil
+	}
+}
+
 // Draw updates the display for a TouchDial.
 func (t *TouchDial) Draw() {
+	if t.destroyed {
+		return
+	}
+	t.loupedeck.DrawQueue().drop(t.drawOwner())
+	t.display.Draw(t.render(), 0, 0)
+}
+
+// queueDraw redraws the TouchDial through the DrawQueue at
+// DrawFeedback priority, so that values changing as the knobs turn
+// are sent ahead of meters and animations.
+func (t *TouchDial) queueDraw() {
+	if t.destroyed {
+		return
+	}
+	t.loupedeck.DrawQueue().Queue(t.drawOwner(), DrawFeedback, t.display, t.render(), 0, 0)
+}
+
+// drawOwner is the TouchDial's owner name in the DrawQueue.
+func (t *TouchDial) drawOwner() string {
+	return fmt.Sprintf("touchdial:%p", t)
+}
+
+// render returns the TouchDial's current image.
+func (t *TouchDial) render() image.Image {
 	im := image.NewRGBA(image.Rect(0, 0, 60, 270))
 	bg := color.RGBA{0, 0, 0, 255}
 	draw.Draw(im, im.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
@@ -137,9 +240,19 @@ func (t *TouchDial) Draw() {
 
 	baseline := 55
 	height := 90
-	drawRightJustifiedStringAt(fd, strconv.Itoa(t.w1.Get()), 48, baseline)
-	drawRightJustifiedStringAt(fd, strconv.Itoa(t.w2.Get()), 48, baseline+height)
-	drawRightJustifiedStringAt(fd, strconv.Itoa(t.w3.Get()), 48, baseline+2*height)
+	for i, w := range []*WatchedInt{t.w1, t.w2, t.w3} {
+		fd.Src = &image.Uniform{t.loupedeck.theme.Text}
+		if i == t.highlighted {
+			draw.Draw(im, image.Rect(0, i*height, 60, (i+1)*height), &image.Uniform{t.loupedeck.theme.Text}, image.Point{}, draw.Src)
+			fd.Src = &image.Uniform{bg}
+		}
+		drawRightJustifiedStringAt(fd, t.loupedeck.FormatValue(w.Get(), t.Format), 48, baseline+i*height)
+	}
 
-	t.display.Draw(im, 0, 0)
+	for i, k := range []*IntKnob{t.Knob1, t.Knob2, t.Knob3} {
+		if k.Fine() {
+			drawFineIndicator(im, t.loupedeck.Theme(), 0, i*height)
+		}
+	}
+	return im
 }
@@ -0,0 +1,80 @@
+package loupedeck
+
+import (
+	"encoding/binary"
+)
+
+// TouchEvent holds everything that the Loupedeck reports about a
+// single touch message, including the bytes that the simpler
+// TouchFunc callbacks leave out.
+//
+// Touch messages look like this:
+//
+//	byte 0:    length
+//	byte 1:    message type (Touch, TouchEnd, TouchCT, or TouchEndCT)
+//	byte 2:    transaction ID (always 0)
+//	byte 3:    unknown; always 0 so far
+//	bytes 4-5: X
+//	bytes 6-7: Y
+//	byte 8:    touch ID
+//	bytes 9-:  optional extra data
+//
+// The touch ID stays the same for the whole of a touch, from the
+// first Touch message until the TouchEnd, and differs between
+// fingers that are down at the same time, so it can be used to track
+// multiple touches.  No firmware seen so far sends extra data, so
+// any that arrives is passed along undecoded in Extra.
+type TouchEvent struct {
+	Type   MessageType
+	Button TouchButton
+	Status ButtonStatus
+	X, Y   uint16
+	ID     byte
+	// Extra holds any bytes after the touch ID.
+	Extra []byte
+}
+
+// TouchEventFunc is a function signature used for callbacks on touch
+// events.
+type TouchEventFunc func(TouchEvent)
+
+// OnTouchEvent adds a callback that's called with the full details of
+// every touch message, on the main touchscreen and the CT's dial,
+// before any touch bindings are called.  Callbacks are called from
+// the Listen goroutine.
+func (l *Loupedeck) OnTouchEvent(f TouchEventFunc) {
+	l.touchEventBindings = append(l.touchEventBindings, f)
+}
+
+// decodeTouch decodes a touch message, which handleMessage has
+// already checked is long enough.
+func (l *Loupedeck) decodeTouch(t MessageType, message []byte) TouchEvent {
+	e := TouchEvent{
+		Type:   t,
+		Status: ButtonDown,
+		X:      binary.BigEndian.Uint16(message[4:]),
+		Y:      binary.BigEndian.Uint16(message[6:]),
+		ID:     message[8],
+	}
+	if t == TouchEnd || t == TouchEndCT {
+		e.Status = ButtonUp
+	}
+	if t == Touch || t == TouchEnd {
+		e.Button = l.touchCoordToButton(e.X, e.Y)
+	}
+	if len(message) > 9 {
+		e.Extra = append([]byte{}, message[9:]...)
+	}
+	return e
+}
+
+// sendTouchEvent calls the touch event callbacks.
+func (l *Loupedeck) sendTouchEvent(t MessageType, message []byte) {
+	if len(l.touchEventBindings) == 0 {
+		return
+	}
+	e := l.decodeTouch(t, message)
+	for _, f := range l.touchEventBindings {
+		f(e)
+	}
+}
@@ -0,0 +1,113 @@
+package loupedeck
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// unknownLogInterval is the shortest time between log messages about
+// the same kind of unknown event.
+const unknownLogInterval = 30 * time.Second
+
+// UnknownEvent summarizes one kind of event from the Loupedeck that
+// the library didn't understand, such as a message type that it
+// doesn't know or a button that isn't in the device profile.  These
+// are common on models that are only partly supported, and are the
+// raw material for supporting them properly.  See UnknownEvents.
+type UnknownEvent struct {
+	// Kind describes what was unknown, like "message type" or
+	// "button".
+	Kind string
+	// Type is the type of the message it arrived in.
+	Type MessageType
+	// ID is the unknown button or knob ID, or the unknown CT event
+	// status; it's 0 for unknown message types.
+	ID int
+	// Count is the number of times it's been seen.
+	Count uint64
+	// First and Last are when it was first and most recently seen.
+	First, Last time.Time
+	// Example is the most recent message that it arrived in.
+	Example []byte
+}
+
+// unknownKey identifies a kind of unknown event.
+type unknownKey struct {
+	kind string
+	t    MessageType
+	id   int
+}
+
+// unknownState holds the unknown events seen so far.
+type unknownState struct {
+	mutex   sync.Mutex
+	events  map[unknownKey]*UnknownEvent
+	logged  map[unknownKey]time.Time // when each was last logged
+	skipped map[unknownKey]uint64    // sightings since it was last logged
+}
+
+// UnknownEvents returns a summary of every kind of unknown event seen
+// since connecting, most frequent first.
+func (l *Loupedeck) UnknownEvents() []UnknownEvent {
+	s := &l.unknown
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	events := make([]UnknownEvent, 0, len(s.events))
+	for _, e := range s.events {
+		c := *e
+		c.Example = append([]byte{}, e.Example...)
+		events = append(events, c)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Count != events[j].Count {
+			return events[i].Count > events[j].Count
+		}
+		return events[i].First.Before(events[j].First)
+	})
+	return events
+}
+
+// noteUnknown records an unknown event.  The first sighting of each
+// kind is logged in full; after that, a summary with a count is logged
+// at most once every unknownLogInterval, so that a device that sends
+// something unknown constantly doesn't flood the log.
+func (l *Loupedeck) noteUnknown(kind string, t MessageType, id int, message []byte) {
+	s := &l.unknown
+	key := unknownKey{kind, t, id}
+	now := time.Now()
+
+	s.mutex.Lock()
+	if s.events == nil {
+		s.events = map[unknownKey]*UnknownEvent{}
+		s.logged = map[unknownKey]time.Time{}
+		s.skipped = map[unknownKey]uint64{}
+	}
+	e := s.events[key]
+	first := e == nil
+	if first {
+		e = &UnknownEvent{Kind: kind, Type: t, ID: id, First: now}
+		s.events[key] = e
+	}
+	e.Count++
+	e.Last = now
+	e.Example = append(e.Example[:0], message...)
+
+	log := first || now.Sub(s.logged[key]) >= unknownLogInterval
+	skipped := s.skipped[key]
+	if log {
+		s.logged[key] = now
+		s.skipped[key] = 0
+	} else {
+		s.skipped[key]++
+	}
+	s.mutex.Unlock()
+
+	switch {
+	case first:
+		l.log().Warn("Received unknown "+kind, "type", fmt.Sprintf("0x%02x", byte(t)), "id", id, "message", fmt.Sprintf("% x", message))
+	case log:
+		l.log().Warn("Still receiving unknown "+kind, "type", fmt.Sprintf("0x%02x", byte(t)), "id", id, "since_last_log", skipped+1, "total", e.Count)
+	}
+}
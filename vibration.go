@@ -0,0 +1,73 @@
+package loupedeck
+
+// VibrationPattern is one of the haptic patterns built into devices
+// with a vibration motor, like the Loupedeck CT and Live S.  The
+// values come from https://github.com/foxxyz/loupedeck; the names
+// describe how they feel, more or less.
+type VibrationPattern byte
+
+const (
+	VibrationNone        VibrationPattern = 0x00
+	VibrationShort       VibrationPattern = 0x01
+	VibrationMedium      VibrationPattern = 0x0a
+	VibrationLong        VibrationPattern = 0x0f
+	VibrationLow         VibrationPattern = 0x31
+	VibrationShortLow    VibrationPattern = 0x32
+	VibrationShortLower  VibrationPattern = 0x33
+	VibrationLower       VibrationPattern = 0x40
+	VibrationLowest      VibrationPattern = 0x41
+	VibrationDescendSlow VibrationPattern = 0x46
+	VibrationDescendMed  VibrationPattern = 0x47
+	VibrationDescendFast VibrationPattern = 0x48
+	VibrationAscendSlow  VibrationPattern = 0x52
+	VibrationAscendMed   VibrationPattern = 0x53
+	VibrationAscendFast  VibrationPattern = 0x58
+	VibrationRevSlowest  VibrationPattern = 0x5e
+	VibrationRevSlow     VibrationPattern = 0x5f
+	VibrationRevMed      VibrationPattern = 0x60
+	VibrationRevFast     VibrationPattern = 0x61
+	VibrationRevFaster   VibrationPattern = 0x62
+	VibrationRevFastest  VibrationPattern = 0x63
+	VibrationRiseFall    VibrationPattern = 0x6a
+	VibrationBuzz        VibrationPattern = 0x70
+	VibrationRumble5     VibrationPattern = 0x77
+	VibrationRumble4     VibrationPattern = 0x78
+	VibrationRumble3     VibrationPattern = 0x79
+	VibrationRumble2     VibrationPattern = 0x7a
+	VibrationRumble1     VibrationPattern = 0x7b
+	VibrationVeryLong    VibrationPattern = 0x76
+)
+
+// Vibrate plays a haptic pattern.  It returns an UnsupportedError if
+// the connected device is known not to have a vibration motor (see
+// Capabilities).  VibrationNone does nothing.
+func (l *Loupedeck) Vibrate(pattern VibrationPattern) error {
+	if pattern == VibrationNone {
+		return nil
+	}
+	if l.profile != nil && !l.profile.Vibration {
+		return &UnsupportedError{Op: "vibration", Model: l.profile.Name}
+	}
+	return l.vibrate(byte(pattern))
+}
+
+// SetTouchVibration makes the Loupedeck play a haptic pattern
+// whenever a touch button is first pressed, for tactile confirmation
+// that the press registered.  Dragging a finger across the screen
+// doesn't trigger it again.  VibrationNone turns this off, which is
+// the default.
+func (l *Loupedeck) SetTouchVibration(pattern VibrationPattern) {
+	l.touchVibration = pattern
+}
+
+// touchVibrate plays the touch vibration pattern, if one is set.
+// Errors are reported via Errors rather than returned, since this is
+// called from Listen.
+func (l *Loupedeck) touchVibrate(b TouchButton) {
+	if l.touchVibration == VibrationNone || b == TouchNone {
+		return
+	}
+	if err := l.Vibrate(l.touchVibration); err != nil {
+		l.reportError(err)
+	}
+}
@@ -0,0 +1,125 @@
+package loupedeck
+
+import (
+	"image"
+	"sync"
+)
+
+// warmFrame is a single pre-rendered and pre-encoded button image.
+type warmFrame struct {
+	region *Region
+	im     image.Image
+	preset *ColorPreset // the color preset that data was encoded with
+	data   []byte
+}
+
+// warmPage holds the pre-rendered images for a Page.
+type warmPage struct {
+	done   chan struct{} // closed once frames is ready
+	frames []warmFrame
+}
+
+// warmupState holds the pages warmed up by Warmup.
+type warmupState struct {
+	mutex sync.Mutex
+	pages map[*Page]*warmPage
+}
+
+// Warmup starts rendering and encoding a page's Images in the
+// background, so that when the page is shown they can all be sent in
+// one quick burst, instead of popping in one at a time as they're
+// rendered.  Pagers warm up the pages on either side of the current
+// page automatically.
+//
+// Warming up a page that's already warm does nothing; call
+// InvalidateWarmup if its Images would now render differently.
+func (l *Loupedeck) Warmup(page *Page) {
+	if len(page.Images) == 0 {
+		return
+	}
+	s := &l.warmup
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.pages == nil {
+		s.pages = map[*Page]*warmPage{}
+	}
+	if s.pages[page] != nil {
+		return
+	}
+
+	w := &warmPage{done: make(chan struct{})}
+	s.pages[page] = w
+	preset := l.colorPreset.Load()
+	go func() {
+		w.frames = l.renderPageImages(page, preset)
+		close(w.done)
+	}()
+}
+
+// InvalidateWarmup discards the pre-rendered images for page.
+func (l *Loupedeck) InvalidateWarmup(page *Page) {
+	s := &l.warmup
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.pages, page)
+}
+
+// renderPageImages renders and encodes each of page's Images.
+func (l *Loupedeck) renderPageImages(page *Page, preset *ColorPreset) []warmFrame {
+	var frames []warmFrame
+	for b, render := range page.Images {
+		r := l.CellRegion(b)
+		if r == nil || render == nil {
+			continue
+		}
+		im := render()
+		d := r.display
+		frames = append(frames, warmFrame{
+			region: r,
+			im:     im,
+			preset: preset,
+			data:   d.encode(im, r.x+d.offsetx, r.y+d.offsety, preset),
+		})
+	}
+	return frames
+}
+
+// drawPageImages draws page's Images, using the warmed-up copies if
+// there are any, and waiting for them if they're still being
+// rendered.
+func (l *Loupedeck) drawPageImages(page *Page) {
+	if len(page.Images) == 0 {
+		return
+	}
+	s := &l.warmup
+	s.mutex.Lock()
+	w := s.pages[page]
+	s.mutex.Unlock()
+
+	var frames []warmFrame
+	if w != nil {
+		<-w.done
+		frames = w.frames
+	} else {
+		frames = l.renderPageImages(page, l.colorPreset.Load())
+	}
+
+	for _, f := range frames {
+		r, d := f.region, f.region.display
+		x, y := r.x+d.offsetx, r.y+d.offsety
+		// Anything that changes the pixels on the way out means
+		// that the pre-encoded data can't be used.
+		if f.preset != l.colorPreset.Load() || l.holdDraws.Load() || (len(l.badges) > 0 && l.hasMirror(d.id)) {
+			r.Draw(f.im)
+			continue
+		}
+		l.drawMutex.Lock()
+		d.updateMirror(f.im, x, y)
+		l.cache.invalidateRect(d.id, image.Rect(x, y, x+r.width, y+r.height))
+		err := d.writeFramebufferLocked(f.data, nil)
+		l.drawMutex.Unlock()
+		if err != nil {
+			l.reportError(err)
+		}
+	}
+}
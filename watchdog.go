@@ -0,0 +1,74 @@
+package loupedeck
+
+import (
+	"fmt"
+	"time"
+)
+
+// StartWatchdog starts a background check for a wedged Loupedeck.
+// Almost every message sent to the Loupedeck gets a reply, so if
+// we've sent something and then heard nothing at all for longer than
+// timeout, the device is assumed to be stuck.  When that happens, the
+// watchdog re-initializes it: it sends a Reset, restores the
+// brightness and button colors, and redraws the displays from the
+// client-side mirror (if enabled; see SetMirroring).  The display
+// layout is client-side, so it's left alone.
+//
+// Calling StartWatchdog again replaces the previous watchdog.
+func (l *Loupedeck) StartWatchdog(timeout time.Duration) {
+	l.StopWatchdog()
+	stop := make(chan struct{})
+	l.watchdogStop = stop
+	go l.watchdog(stop, timeout)
+}
+
+// StopWatchdog stops the watchdog started by StartWatchdog.
+func (l *Loupedeck) StopWatchdog() {
+	if l.watchdogStop != nil {
+		close(l.watchdogStop)
+		l.watchdogStop = nil
+	}
+}
+
+func (l *Loupedeck) watchdog(stop chan struct{}, timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if l.wedged(timeout) {
+				l.log().Warn("Loupedeck appears to be wedged, reinitializing", "timeout", timeout)
+				l.reinit()
+			}
+		}
+	}
+}
+
+// wedged returns true if a message was sent more than timeout ago
+// and nothing has been received since.
+func (l *Loupedeck) wedged(timeout time.Duration) bool {
+	sent := l.lastSend.Load()
+	received := l.lastReceive.Load()
+	return sent > received && time.Since(time.Unix(0, sent)) > timeout
+}
+
+// reinit performs a soft re-initialization of the Loupedeck, without
+// reconnecting.
+func (l *Loupedeck) reinit() {
+	// Don't immediately trigger again if the reset doesn't get a
+	// reply either.
+	l.lastReceive.Store(time.Now().UnixNano())
+
+	err := l.Send(l.NewMessage(Reset, []byte{}))
+	if err != nil {
+		l.reportError(fmt.Errorf("unable to send reset: %v", err))
+		return
+	}
+
+	if err := l.restoreLEDs(); err != nil {
+		l.reportError(err)
+	}
+	l.replayMirrors()
+}
@@ -16,11 +16,17 @@
 
 package loupedeck
 
-import ()
+import (
+	"math"
+)
 
 // WatchFunc is used for callbacks for changes to a WatchedInt.
 type WatchFunc func(int)
 
+// TransformFunc is used to adjust the values read from a WatchedInt.
+// See AddTransform.
+type TransformFunc func(int) int
+
 // WatchedInt wraps an int with zero or more callback watchers;
 // whenever the value of the int changes (via Set), all of the
 // callbacks will be called.  This is used to implement a sane model
@@ -28,32 +34,130 @@ type WatchFunc func(int)
 // any impacted displays and should trigger any required underlying
 // behaviour.
 type WatchedInt struct {
-	value     int
-	notifiers []WatchFunc
+	raw        int // as passed to Set, limited to the range
+	value      int // raw, after transforms
+	bounded    bool
+	min, max   int
+	notifiers  []WatchFunc
+	transforms []TransformFunc
 }
 
 // NewWatchedInt creates a new WatchedInt with the specified initial value.
 func NewWatchedInt(value int) *WatchedInt {
 	return &WatchedInt{
+		raw:       value,
 		value:     value,
 		notifiers: make([]WatchFunc, 0),
 	}
 }
 
-// Get returns the current value of the WatchedInt.
+// Get returns the current value of the WatchedInt, after any
+// transforms.
 func (w *WatchedInt) Get() int {
 	return w.value
 }
 
 // Set updates the current value of the WatchedInt and calls all callback functions added via AddWatcher.
+// The value is limited to the range set with SetRange and passed
+// through any transforms added via AddTransform first.
 func (w *WatchedInt) Set(value int) {
-	w.value = value
+	if w.bounded {
+		value = clamp(value, w.min, w.max)
+	}
+	w.raw = value
+	w.value = w.transform(value)
 	for _, f := range w.notifiers {
-		f(value)
+		f(w.value)
+	}
+}
+
+// inc adds delta to the value most recently passed to Set, not to the
+// transformed value returned by Get.  Otherwise a transform like
+// Quantize would round small steps away, and the value would never
+// move.
+func (w *WatchedInt) inc(delta int) {
+	w.Set(w.raw + delta)
+}
+
+// transform runs v through all of the transforms, in order.
+func (w *WatchedInt) transform(v int) int {
+	for _, t := range w.transforms {
+		v = t(v)
 	}
+	return v
 }
 
 // AddWatcher adds a callback function for this WatchedInt.  The callback will be called whenever Set is called.
 func (w *WatchedInt) AddWatcher(f WatchFunc) {
 	w.notifiers = append(w.notifiers, f)
 }
+
+// AddTransform adds a function that adjusts the value returned by Get
+// and passed to watchers.  The value passed to Set is kept as well,
+// and increments made by knobs apply to it, so transforms never stop
+// a knob from moving.  Transforms are applied in the order that they
+// were added, so (for example) Quantize followed by Clamp always
+// produces a value within range.
+func (w *WatchedInt) AddTransform(f TransformFunc) {
+	w.transforms = append(w.transforms, f)
+	w.value = w.transform(w.raw)
+}
+
+// SetRange limits values passed to Set to between min and max,
+// inclusive.  Unlike the Clamp transform, this limits the stored
+// value itself, so a knob that's been turned past the end of its
+// range starts moving back as soon as it's turned the other way.
+// IntKnob and DisplayKnob set this for their WatchedInt.
+func (w *WatchedInt) SetRange(min, max int) {
+	w.bounded = true
+	w.min, w.max = min, max
+	w.raw = clamp(w.raw, min, max)
+	w.value = w.transform(w.raw)
+}
+
+// Clamp returns a TransformFunc that limits values to between min
+// and max, inclusive.
+func Clamp(min, max int) TransformFunc {
+	return func(v int) int {
+		return clamp(v, min, max)
+	}
+}
+
+// Quantize returns a TransformFunc that rounds values to the nearest
+// multiple of step.  Steps of less than 2 leave values unchanged.
+func Quantize(step int) TransformFunc {
+	return func(v int) int {
+		if step < 2 {
+			return v
+		}
+		return int(math.Round(float64(v)/float64(step))) * step
+	}
+}
+
+// Curve returns a TransformFunc that maps values between min and max
+// through f, which takes and returns values between 0 and 1.  This
+// is useful for things like volume or brightness, where a linear knob
+// feels wrong; Curve(0, 100, func(x float64) float64 { return x * x })
+// gives finer control at the low end.  Values outside of min and max
+// are clamped first.
+func Curve(min, max int, f func(float64) float64) TransformFunc {
+	return func(v int) int {
+		if max <= min {
+			return min
+		}
+		x := float64(clamp(v, min, max)-min) / float64(max-min)
+		y := math.Max(0, math.Min(1, f(x)))
+		return min + int(math.Round(y*float64(max-min)))
+	}
+}
+
+// clamp limits v to between min and max, inclusive.
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
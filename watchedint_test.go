@@ -0,0 +1,27 @@
+package loupedeck
+
+import "testing"
+
+func TestTransformsDontStopIncrements(t *testing.T) {
+	w := NewWatchedInt(0)
+	w.SetRange(0, 100)
+	w.AddTransform(Quantize(5))
+
+	var got []int
+	for i := 0; i < 5; i++ {
+		w.inc(1)
+		got = append(got, w.Get())
+	}
+	want := []int{0, 0, 5, 5, 5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("values after 1..5 increments = %v, want %v", got, want)
+		}
+	}
+
+	w.Set(500)
+	w.inc(-1)
+	if v := w.Get(); v != 100 {
+		t.Errorf("Get() = %d after turning back from past the end, want 100", v)
+	}
+}